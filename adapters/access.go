@@ -0,0 +1,266 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/overmindtech/discovery"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// ResourceAdapter An adapter that knows which API resource it queries, so
+// that the permissions needed to query it can be checked
+type ResourceAdapter interface {
+	GroupResource() (schema.GroupResource, error)
+}
+
+// GroupResource Returns the API resource that the adapter queries, e.g.
+// `deployments.apps`. This is worked out from the type of the resource
+func (s *KubeTypeAdapter[Resource, ResourceList]) GroupResource() (schema.GroupResource, error) {
+	gvk, err := s.groupVersionKind()
+
+	if err != nil {
+		return schema.GroupResource{}, err
+	}
+
+	plural, _ := meta.UnsafeGuessKindToResource(gvk)
+
+	return plural.GroupResource(), nil
+}
+
+// groupVersionKind Returns the kind of the resource that the adapter queries,
+// by looking up its type in the scheme
+func (s *KubeTypeAdapter[Resource, ResourceList]) groupVersionKind() (schema.GroupVersionKind, error) {
+	var resource Resource
+
+	t := reflect.TypeOf(resource)
+
+	if t == nil || t.Kind() != reflect.Pointer {
+		return schema.GroupVersionKind{}, fmt.Errorf("%v is not a pointer", t)
+	}
+
+	// The scheme needs a real object, but only its type is used
+	obj, ok := reflect.New(t.Elem()).Interface().(runtime.Object)
+
+	if !ok {
+		return schema.GroupVersionKind{}, fmt.Errorf("%v is not a runtime.Object", t)
+	}
+
+	gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+
+	return gvks[0], nil
+}
+
+// GroupResource Returns the API resource that the adapter queries
+func (s *customResourceAdapter) GroupResource() (schema.GroupResource, error) {
+	return s.Resource.GVR.GroupResource(), nil
+}
+
+// AdapterAccess The scopes of an adapter that the source does and doesn't have
+// permission to query
+type AdapterAccess struct {
+	Type       string `json:"type"`
+	Resource   string `json:"resource"`
+	Namespaced bool   `json:"namespaced"`
+	// Whether the source can query every namespace, or the whole cluster for
+	// cluster-wide types. If this is false, new namespaces need to be checked
+	// before they are queried
+	ClusterWide bool     `json:"clusterWide"`
+	Allowed     []string `json:"allowed"`
+	Denied      []string `json:"denied"`
+}
+
+// AccessChecker Works out what the source is allowed to query by asking the
+// API server, so that adapters can be restricted to what they have access to
+// rather than failing with a 403 at query time
+type AccessChecker struct {
+	Client kubernetes.Interface
+	// The verbs that are needed to query an adapter, e.g. get and list
+	Verbs []string
+}
+
+// Check Works out which scopes of each adapter can be queried. Adapters that
+// don't implement `ResourceAdapter` are skipped
+func (c *AccessChecker) Check(ctx context.Context, adapterList []discovery.Adapter) ([]*AdapterAccess, error) {
+	rules := make(map[string]*authorizationv1.SubjectRulesReviewStatus)
+	results := make([]*AdapterAccess, 0, len(adapterList))
+
+	for _, adapter := range adapterList {
+		resourceAdapter, ok := adapter.(ResourceAdapter)
+
+		if !ok {
+			continue
+		}
+
+		gr, err := resourceAdapter.GroupResource()
+
+		if err != nil {
+			return nil, fmt.Errorf("could not work out the resource for %v: %w", adapter.Type(), err)
+		}
+
+		access := &AdapterAccess{
+			Type:       adapter.Type(),
+			Resource:   gr.String(),
+			Namespaced: isNamespaced(adapter),
+			Allowed:    make([]string, 0),
+			Denied:     make([]string, 0),
+		}
+
+		// Check access to the whole cluster first, since if this is allowed
+		// there is no need to check each namespace
+		access.ClusterWide, err = c.allowed(ctx, gr, "")
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, scope := range itemScopes(adapter) {
+			allowed := access.ClusterWide
+
+			if !allowed && access.Namespaced {
+				details, err := ParseScope(scope, true)
+
+				if err != nil {
+					return nil, err
+				}
+
+				allowed, err = c.allowedByRules(ctx, rules, gr, details.Namespace)
+
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if allowed {
+				access.Allowed = append(access.Allowed, scope)
+			} else {
+				access.Denied = append(access.Denied, scope)
+			}
+		}
+
+		results = append(results, access)
+	}
+
+	return results, nil
+}
+
+// AllowedInNamespace Returns whether the adapter can query the given
+// namespace. This is used to check namespaces that are created after the
+// initial check
+func (c *AccessChecker) AllowedInNamespace(ctx context.Context, adapter discovery.Adapter, namespace string) (bool, error) {
+	resourceAdapter, ok := adapter.(ResourceAdapter)
+
+	if !ok {
+		return true, nil
+	}
+
+	gr, err := resourceAdapter.GroupResource()
+
+	if err != nil {
+		return false, err
+	}
+
+	return c.allowedByRules(ctx, make(map[string]*authorizationv1.SubjectRulesReviewStatus), gr, namespace)
+}
+
+func isNamespaced(adapter discovery.Adapter) bool {
+	namespacedAdapter, ok := adapter.(interface{ namespaced() bool })
+
+	return ok && namespacedAdapter.namespaced()
+}
+
+// allowed Uses a SelfSubjectAccessReview to check whether all of the verbs are
+// allowed for a resource. A blank namespace means all namespaces
+func (c *AccessChecker) allowed(ctx context.Context, gr schema.GroupResource, namespace string) (bool, error) {
+	for _, verb := range c.Verbs {
+		review, err := c.Client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      verb,
+					Group:     gr.Group,
+					Resource:  gr.Resource,
+				},
+			},
+		}, metav1.CreateOptions{})
+
+		if err != nil {
+			return false, fmt.Errorf("could not check access to %v: %w", gr, err)
+		}
+
+		if !review.Status.Allowed {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// allowedByRules Checks whether all of the verbs are allowed for a resource
+// in a namespace. A SelfSubjectRulesReview returns the rules for every
+// resource in a namespace in one request, so these are cached. If the rules
+// are incomplete, for example because a webhook authorizer is used, this falls
+// back to a SelfSubjectAccessReview
+func (c *AccessChecker) allowedByRules(ctx context.Context, cache map[string]*authorizationv1.SubjectRulesReviewStatus, gr schema.GroupResource, namespace string) (bool, error) {
+	status, ok := cache[namespace]
+
+	if !ok {
+		review, err := c.Client.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+			Spec: authorizationv1.SelfSubjectRulesReviewSpec{
+				Namespace: namespace,
+			},
+		}, metav1.CreateOptions{})
+
+		if err != nil {
+			return false, fmt.Errorf("could not check access to namespace %v: %w", namespace, err)
+		}
+
+		status = &review.Status
+		cache[namespace] = status
+	}
+
+	if rulesAllow(status.ResourceRules, gr, c.Verbs) {
+		return true, nil
+	}
+
+	if status.Incomplete {
+		return c.allowed(ctx, gr, namespace)
+	}
+
+	return false, nil
+}
+
+// rulesAllow Returns whether the rules allow all of the verbs on every object
+// of a resource. Rules that are limited to specific names don't allow listing
+func rulesAllow(rules []authorizationv1.ResourceRule, gr schema.GroupResource, verbs []string) bool {
+	for _, verb := range verbs {
+		allowed := slices.ContainsFunc(rules, func(rule authorizationv1.ResourceRule) bool {
+			return len(rule.ResourceNames) == 0 &&
+				matchesRule(rule.APIGroups, gr.Group) &&
+				matchesRule(rule.Resources, gr.Resource) &&
+				matchesRule(rule.Verbs, verb)
+		})
+
+		if !allowed {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesRule(values []string, value string) bool {
+	return slices.Contains(values, "*") || slices.Contains(values, value)
+}
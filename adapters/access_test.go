@@ -0,0 +1,175 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/overmindtech/discovery"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newAccessClient Creates a fake clientset that answers access reviews. Only
+// pods can be queried cluster-wide, and the rules for each namespace are given
+// by the map. Namespaces that aren't in the map have incomplete rules, so
+// access reviews are used instead, which allow anything in that namespace
+func newAccessClient(rules map[string][]authorizationv1.ResourceRule) *fake.Clientset {
+	cs := fake.NewClientset()
+
+	cs.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+
+		review.Status.Allowed = attributes.Resource == "pods" || attributes.Namespace != ""
+
+		return true, review, nil
+	})
+
+	cs.PrependReactor("create", "selfsubjectrulesreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+
+		namespaceRules, ok := rules[review.Spec.Namespace]
+
+		review.Status.ResourceRules = namespaceRules
+		review.Status.Incomplete = !ok
+
+		return true, review, nil
+	})
+
+	return cs
+}
+
+func TestGroupResource(t *testing.T) {
+	cs := fake.NewClientset()
+
+	tests := []struct {
+		Adapter  discovery.Adapter
+		Expected schema.GroupResource
+	}{
+		{newPodAdapter(cs, "test", nil), schema.GroupResource{Resource: "pods"}},
+		{newEndpointsAdapter(cs, "test", nil), schema.GroupResource{Resource: "endpoints"}},
+		{newDeploymentAdapter(cs, "test", nil), schema.GroupResource{Group: "apps", Resource: "deployments"}},
+		{newIngressAdapter(cs, "test", nil), schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}},
+		{newStorageClassAdapter(cs, "test", nil), schema.GroupResource{Group: "storage.k8s.io", Resource: "storageclasses"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Adapter.Type(), func(t *testing.T) {
+			gr, err := test.Adapter.(ResourceAdapter).GroupResource()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if gr != test.Expected {
+				t.Errorf("expected %v, got %v", test.Expected, gr)
+			}
+		})
+	}
+
+	t.Run("every adapter", func(t *testing.T) {
+		for _, adapter := range LoadAllAdapters(cs, "test", nil, TypeFilter{}) {
+			if _, err := adapter.(ResourceAdapter).GroupResource(); err != nil {
+				t.Errorf("%v: %v", adapter.Type(), err)
+			}
+		}
+	})
+}
+
+func TestAccessChecker(t *testing.T) {
+	cs := newAccessClient(map[string][]authorizationv1.ResourceRule{
+		"team-a": {
+			{
+				Verbs:     []string{"get", "list"},
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+			},
+		},
+		"team-b": {
+			{
+				Verbs:     []string{"*"},
+				APIGroups: []string{"*"},
+				Resources: []string{"configmaps"},
+			},
+			{
+				// Rules for specific names don't allow listing
+				Verbs:         []string{"get", "list"},
+				APIGroups:     []string{""},
+				Resources:     []string{"secrets"},
+				ResourceNames: []string{"my-secret"},
+			},
+		},
+	})
+
+	namespaces := []string{"team-a", "team-b", "team-c"}
+	adapterList := []discovery.Adapter{
+		newPodAdapter(cs, "test", namespaces),
+		newSecretAdapter(cs, "test", namespaces),
+		newNodeAdapter(cs, "test", namespaces),
+	}
+
+	checker := &AccessChecker{
+		Client: cs,
+		Verbs:  []string{"get", "list"},
+	}
+
+	accessList, err := checker.Check(context.Background(), adapterList)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(accessList) != 3 {
+		t.Fatalf("expected 3 results, got %v", len(accessList))
+	}
+
+	t.Run("cluster-wide", func(t *testing.T) {
+		pods := accessList[0]
+
+		if !pods.ClusterWide || len(pods.Allowed) != 3 || len(pods.Denied) != 0 {
+			t.Errorf("expected pods to be allowed everywhere, got %+v", pods)
+		}
+	})
+
+	t.Run("some namespaces", func(t *testing.T) {
+		secrets := accessList[1]
+
+		if secrets.ClusterWide || !secrets.Namespaced {
+			t.Errorf("expected secrets to be namespaced and not cluster-wide, got %+v", secrets)
+		}
+
+		// team-c has incomplete rules so falls back to an access review,
+		// which allows it
+		if !slices.Equal(secrets.Allowed, []string{"test.team-a", "test.team-c"}) {
+			t.Errorf("unexpected allowed scopes %v", secrets.Allowed)
+		}
+
+		if !slices.Equal(secrets.Denied, []string{"test.team-b"}) {
+			t.Errorf("unexpected denied scopes %v", secrets.Denied)
+		}
+	})
+
+	t.Run("cluster-scoped", func(t *testing.T) {
+		nodes := accessList[2]
+
+		if nodes.Namespaced || len(nodes.Allowed) != 0 || !slices.Equal(nodes.Denied, []string{"test"}) {
+			t.Errorf("expected nodes to be denied, got %+v", nodes)
+		}
+	})
+
+	t.Run("AllowedInNamespace", func(t *testing.T) {
+		allowed, err := checker.AllowedInNamespace(context.Background(), adapterList[1], "team-b")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if allowed {
+			t.Error("expected secrets in team-b to be denied")
+		}
+	})
+}
@@ -8,7 +8,46 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func newClusterRoleAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func clusterRoleExtractor(resource *v1.ClusterRole, scope string) ([]*sdp.LinkedItemQuery, error) {
+	sd, err := ParseScope(scope, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	if resource.AggregationRule != nil {
+		for _, selector := range resource.AggregationRule.ClusterRoleSelectors {
+			// An empty selector doesn't aggregate anything
+			if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+				continue
+			}
+
+			queries = append(queries, &sdp.LinkedItemQuery{
+				Query: &sdp.Query{
+					Type:   "ClusterRole",
+					Method: sdp.QueryMethod_SEARCH,
+					Query:  LabelSelectorToQuery(&selector),
+					Scope:  sd.String(),
+				},
+				BlastPropagation: &sdp.BlastPropagation{
+					// The rules of the aggregated roles are copied into this
+					// one, so changes to them change this role
+					In: true,
+					// The aggregated roles aren't affected by this one
+					Out: false,
+				},
+			})
+		}
+	}
+
+	// A ClusterRole can be bound in any namespace, so the namespaced objects
+	// that it names could be in any of them
+	return append(queries, policyRuleQueries(resource.Rules, sd.ClusterName, "*")...), nil
+}
+
+func newClusterRoleAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.ClusterRole, *v1.ClusterRoleList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -25,7 +64,8 @@ func newClusterRoleAdapter(cs *kubernetes.Clientset, cluster string, namespaces
 
 			return bindings, nil
 		},
-		AdapterMetadata: clusterRoleAdapterMetadata,
+		LinkedItemQueryExtractor: clusterRoleExtractor,
+		AdapterMetadata:          clusterRoleAdapterMetadata,
 	}
 }
 
@@ -33,6 +73,7 @@ var clusterRoleAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
 	Type:                  "ClusterRole",
 	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
 	DescriptiveName:       "Cluster Role",
+	PotentialLinks:        []string{"ClusterRole", "Secret", "ConfigMap", "ServiceAccount", "Pod", "Namespace", "Node"},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Cluster Role"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
@@ -1,7 +1,10 @@
 package adapters
 
 import (
+	"context"
 	"testing"
+
+	"github.com/overmindtech/sdp-go"
 )
 
 var clusterRoleYAML = `
@@ -13,19 +16,56 @@ rules:
 - apiGroups: [""]
   resources: ["*"]
   verbs: ["get", "list", "watch"]
-
+- apiGroups: [""]
+  resources: ["secrets"]
+  resourceNames: ["db-creds"]
+  verbs: ["get"]
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: aggregated-read-only
+aggregationRule:
+  clusterRoleSelectors:
+  - matchLabels:
+      aggregate-to-read-only: "true"
+rules: []
 `
 
 func TestClusterRoleAdapter(t *testing.T) {
 	adapter := newClusterRoleAdapter(CurrentCluster.ClientSet, CurrentCluster.Name, []string{})
 
 	st := AdapterTests{
-		Adapter:       adapter,
-		GetQuery:      "read-only",
-		GetScope:      CurrentCluster.Name,
-		SetupYAML:     clusterRoleYAML,
-		GetQueryTests: QueryTests{},
+		Adapter:   adapter,
+		GetQuery:  "read-only",
+		GetScope:  CurrentCluster.Name,
+		SetupYAML: clusterRoleYAML,
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "Secret",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "db-creds",
+				ExpectedScope:  "*",
+			},
+		},
 	}
 
 	st.Execute(t)
+
+	t.Run("with an aggregation rule", func(t *testing.T) {
+		item, err := adapter.Get(context.Background(), CurrentCluster.Name, "aggregated-read-only", true)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "ClusterRole",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  `{"labelSelector":"aggregate-to-read-only=true"}`,
+				ExpectedScope:  CurrentCluster.Name,
+			},
+		}.Execute(t, item)
+	})
 }
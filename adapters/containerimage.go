@@ -0,0 +1,530 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	"github.com/overmindtech/sdpcache"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// This file contains the ContainerImage type. Images aren't kubernetes
+// resources, so the items are worked out from the pods that are running them,
+// with one item per image in each namespace
+
+const (
+	defaultImageRegistry = "docker.io"
+	defaultImageTag      = "latest"
+)
+
+var (
+	// ECR registries e.g. 123456789012.dkr.ecr.eu-west-2.amazonaws.com
+	ecrRegistryRegex = regexp.MustCompile(`^\d{12}\.dkr\.ecr(?:-fips)?\.[a-z0-9-]+\.amazonaws\.com(?:\.cn)?$`)
+	// Artifact Registry registries e.g. europe-west2-docker.pkg.dev
+	artifactRegistryRegex = regexp.MustCompile(`^[a-z0-9-]+-docker\.pkg\.dev$`)
+	// Container Registry registries e.g. gcr.io or eu.gcr.io
+	gcrRegistryRegex = regexp.MustCompile(`^(?:[a-z]+\.)?gcr\.io$`)
+)
+
+// ImageReference The parts of a container image reference e.g.
+// `registry.example.com/team/app:1.2.3@sha256:...`
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImageReference Parses an image reference in the same way as the
+// container runtime does, so images without a registry are from Docker Hub. A
+// bare digest e.g. `sha256:...` is also accepted since it is what is reported
+// for running containers
+func ParseImageReference(image string) (ImageReference, error) {
+	var ref ImageReference
+
+	image = strings.TrimSpace(image)
+
+	if image == "" {
+		return ref, fmt.Errorf("image reference is empty")
+	}
+
+	if strings.HasPrefix(image, "sha256:") {
+		ref.Digest = image
+		return ref, nil
+	}
+
+	if name, digest, found := strings.Cut(image, "@"); found {
+		image = name
+		ref.Digest = digest
+	}
+
+	// The tag comes after the last colon, as long as it isn't the port of
+	// the registry
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		ref.Tag = image[i+1:]
+		image = image[:i]
+	}
+
+	// The first part of the name is only a registry if it looks like a
+	// hostname, otherwise it is part of a Docker Hub repository
+	if first, rest, found := strings.Cut(image, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry = first
+		ref.Repository = rest
+	} else {
+		ref.Registry = defaultImageRegistry
+		ref.Repository = image
+	}
+
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = defaultImageRegistry
+	}
+
+	// Official images are in the library namespace
+	if ref.Registry == defaultImageRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	if ref.Repository == "" {
+		return ref, fmt.Errorf("image reference %v has no repository", image)
+	}
+
+	return ref, nil
+}
+
+// String Returns the full reference. Images that have neither a tag nor a
+// digest use the latest tag
+func (r ImageReference) String() string {
+	if r.Repository == "" {
+		return r.Digest
+	}
+
+	s := r.Registry + "/" + r.Repository
+
+	switch {
+	case r.Tag != "":
+		s += ":" + r.Tag
+	case r.Digest == "":
+		s += ":" + defaultImageTag
+	}
+
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+
+	return s
+}
+
+// imageIDDigest Returns the digest from the image ID of a running container.
+// Depending on the runtime this is either the digest itself or a full
+// reference with a prefix e.g. `docker-pullable://nginx@sha256:...`
+func imageIDDigest(imageID string) string {
+	if _, digest, found := strings.Cut(imageID, "@"); found {
+		return digest
+	}
+
+	if strings.HasPrefix(imageID, "sha256:") {
+		return imageID
+	}
+
+	return ""
+}
+
+// containerImage An image and the pods in a namespace that are using it
+type containerImage struct {
+	Reference ImageReference
+	// The digests that the containers using the image are actually running
+	Digests []string
+	Pods    []string
+}
+
+// matches Returns whether the image matches a query. Queries with a digest
+// match on either the digest in the reference or the digests that are
+// running, queries without a tag match every tag in the repository
+func (c *containerImage) matches(query ImageReference) bool {
+	if query.Repository != "" && (query.Registry != c.Reference.Registry || query.Repository != c.Reference.Repository) {
+		return false
+	}
+
+	if query.Digest != "" {
+		return query.Digest == c.Reference.Digest || slices.Contains(c.Digests, query.Digest)
+	}
+
+	if query.Tag != "" {
+		return query.Tag == c.Reference.Tag || (c.Reference.Tag == "" && c.Reference.Digest == "" && query.Tag == defaultImageTag)
+	}
+
+	return true
+}
+
+// podContainers Returns all of the containers in a pod along with their
+// statuses. Containers that haven't been created yet have no status
+func podContainers(pod *v1.Pod) ([]v1.Container, []v1.ContainerStatus) {
+	containers := slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers)
+	statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+
+	return containers, statuses
+}
+
+// podRunsImage Returns whether any of the containers in a pod match an image
+// query
+func podRunsImage(pod *v1.Pod, query ImageReference) bool {
+	for _, image := range podImages([]*v1.Pod{pod}) {
+		if image.matches(query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// podImages Works out which images are used by a set of pods. Images with
+// references that can't be parsed are skipped, since they won't run
+func podImages(pods []*v1.Pod) []*containerImage {
+	images := make(map[string]*containerImage)
+
+	for _, pod := range pods {
+		containers, statuses := podContainers(pod)
+
+		for _, container := range containers {
+			ref, err := ParseImageReference(container.Image)
+
+			if err != nil {
+				continue
+			}
+
+			image, ok := images[ref.String()]
+
+			if !ok {
+				image = &containerImage{
+					Reference: ref,
+				}
+				images[ref.String()] = image
+			}
+
+			if !slices.Contains(image.Pods, pod.Name) {
+				image.Pods = append(image.Pods, pod.Name)
+			}
+
+			for _, status := range statuses {
+				if status.Name != container.Name {
+					continue
+				}
+
+				if digest := imageIDDigest(status.ImageID); digest != "" && !slices.Contains(image.Digests, digest) {
+					image.Digests = append(image.Digests, digest)
+				}
+			}
+		}
+	}
+
+	sorted := make([]*containerImage, 0, len(images))
+
+	for _, name := range slices.Sorted(maps.Keys(images)) {
+		image := images[name]
+		slices.Sort(image.Pods)
+		slices.Sort(image.Digests)
+		sorted = append(sorted, image)
+	}
+
+	return sorted
+}
+
+// containerImageRegistryQuery Links an image to the cloud registry repository
+// that it is stored in, if it is in one that we know about
+func containerImageRegistryQuery(ref ImageReference) *sdp.LinkedItemQuery {
+	blastProp := &sdp.BlastPropagation{
+		// Deleting or overwriting the image in the registry will stop pods
+		// from starting
+		In: true,
+		// Running the image doesn't change the registry
+		Out: false,
+	}
+
+	switch {
+	case ecrRegistryRegex.MatchString(ref.Registry):
+		return &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "ecr-repository",
+				Method: sdp.QueryMethod_GET,
+				Query:  ref.Repository,
+				Scope:  "*",
+			},
+			BlastPropagation: blastProp,
+		}
+	case artifactRegistryRegex.MatchString(ref.Registry), gcrRegistryRegex.MatchString(ref.Registry):
+		// Artifact Registry images are named
+		// <location>-docker.pkg.dev/<project>/<repository>/<image>, and
+		// Container Registry images <host>/<project>/<image>, which are
+		// stored in a repository named after the host
+		parts := strings.Split(ref.Repository, "/")
+		repository := ref.Registry + "/" + parts[0]
+
+		if artifactRegistryRegex.MatchString(ref.Registry) {
+			if len(parts) < 3 {
+				return nil
+			}
+
+			repository += "/" + parts[1]
+		}
+
+		return &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "gcp-artifact-registry-repository",
+				Method: sdp.QueryMethod_SEARCH,
+				Query:  repository,
+				Scope:  "*",
+			},
+			BlastPropagation: blastProp,
+		}
+	}
+
+	return nil
+}
+
+// containerImageAdapter Serves ContainerImage items, which are worked out
+// from the pods in each namespace. It uses the loaded Pod adapter to do the
+// querying, so it has the same scopes and permissions as the Pod type, and is
+// served from its informers when they are enabled
+type containerImageAdapter struct {
+	pods *KubeTypeAdapter[*v1.Pod, *v1.PodList]
+}
+
+func (s *containerImageAdapter) Type() string {
+	return "ContainerImage"
+}
+
+func (s *containerImageAdapter) Name() string {
+	return "k8s-ContainerImage"
+}
+
+func (s *containerImageAdapter) Metadata() *sdp.AdapterMetadata {
+	return containerImageAdapterMetadata
+}
+
+func (s *containerImageAdapter) Weight() int {
+	return s.pods.Weight()
+}
+
+// Scopes Images are only served in the namespaces that the pods are in
+func (s *containerImageAdapter) Scopes() []string {
+	return itemScopes(s.pods)
+}
+
+// GroupResource The images are worked out from pods, so this needs the same
+// permissions as the Pod type
+func (s *containerImageAdapter) GroupResource() (schema.GroupResource, error) {
+	return s.pods.GroupResource()
+}
+
+func (s *containerImageAdapter) namespaced() bool {
+	return s.pods.namespaced()
+}
+
+// AddNamespace The namespaces are the Pod adapter's, which is updated itself
+func (s *containerImageAdapter) AddNamespace(namespace string) {}
+
+// RemoveNamespace Purges the images that were cached for a namespace. The Pod
+// adapter is updated itself
+func (s *containerImageAdapter) RemoveNamespace(namespace string) {
+	s.pods.Cache().Delete(sdpcache.CacheKey{
+		SST: sdpcache.SST{
+			SourceName: s.Name(),
+			Scope: ScopeDetails{
+				ClusterName: s.pods.ClusterName,
+				Namespace:   namespace,
+			}.String(),
+			Type: s.Type(),
+		},
+	})
+}
+
+// Get Returns a single image. Images that have been listed are cached, so
+// these are served from the cache if they are there
+func (s *containerImageAdapter) Get(ctx context.Context, scope string, query string, ignoreCache bool) (*sdp.Item, error) {
+	items, err := s.cachedImages(ctx, sdp.QueryMethod_GET, scope, query, ignoreCache, func(image *containerImage) bool {
+		return image.Reference.String() == query
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items[0], nil
+}
+
+func (s *containerImageAdapter) List(ctx context.Context, scope string, ignoreCache bool) ([]*sdp.Item, error) {
+	return s.cachedImages(ctx, sdp.QueryMethod_LIST, scope, "", ignoreCache, func(*containerImage) bool {
+		return true
+	})
+}
+
+// Search Finds the images that match a reference. The query can be a full
+// reference, a repository without a tag to find all of its tags, or a digest.
+// The pods that are running the images are found by following the links
+func (s *containerImageAdapter) Search(ctx context.Context, scope string, query string, ignoreCache bool) ([]*sdp.Item, error) {
+	ref, err := ParseImageReference(query)
+	if err != nil {
+		return nil, &sdp.QueryError{
+			ErrorType:   sdp.QueryError_OTHER,
+			ErrorString: err.Error(),
+		}
+	}
+
+	return s.cachedImages(ctx, sdp.QueryMethod_SEARCH, scope, query, ignoreCache, func(image *containerImage) bool {
+		return image.matches(ref)
+	})
+}
+
+// cachedImages Returns the images in a scope that match a filter. These aren't
+// cached if the Pod adapter is using informers, since its store is always up
+// to date
+func (s *containerImageAdapter) cachedImages(ctx context.Context, method sdp.QueryMethod, scope string, query string, ignoreCache bool, filter func(*containerImage) bool) ([]*sdp.Item, error) {
+	if s.pods.informersEnabled() {
+		return s.images(ctx, method, scope, query, filter)
+	}
+
+	cache := s.pods.Cache()
+	cacheHit, ck, cachedItems, qErr := cache.Lookup(ctx, s.Name(), method, scope, s.Type(), query, ignoreCache)
+	if qErr != nil {
+		return nil, qErr
+	}
+	if cacheHit {
+		return cachedItems, nil
+	}
+
+	items, err := s.images(ctx, method, scope, query, filter)
+	if err != nil {
+		cache.StoreError(err, s.pods.cacheDuration(), ck)
+		return nil, err
+	}
+
+	for _, item := range items {
+		cache.StoreItem(item, s.pods.cacheDuration(), ck)
+	}
+
+	return items, nil
+}
+
+// images Lists the pods in a scope and converts the images that they use to
+// items. Gets return a NOTFOUND error if no pods are using the image
+func (s *containerImageAdapter) images(ctx context.Context, method sdp.QueryMethod, scope string, query string, filter func(*containerImage) bool) ([]*sdp.Item, error) {
+	pods, err := s.pods.listResources(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*sdp.Item, 0)
+
+	for _, image := range podImages(pods) {
+		if !filter(image) {
+			continue
+		}
+
+		item, err := s.imageToItem(image, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if method == sdp.QueryMethod_GET && len(items) == 0 {
+		return nil, &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOTFOUND,
+			ErrorString: fmt.Sprintf("no pods in %v are using the image %v", scope, query),
+		}
+	}
+
+	return items, nil
+}
+
+func (s *containerImageAdapter) imageToItem(image *containerImage, scope string) (*sdp.Item, error) {
+	attributes, err := sdp.ToAttributes(map[string]interface{}{
+		"name":       image.Reference.String(),
+		"registry":   image.Reference.Registry,
+		"repository": image.Reference.Repository,
+		"tag":        image.Reference.Tag,
+		"digest":     image.Reference.Digest,
+		"digests":    image.Digests,
+		"pods":       image.Pods,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item := &sdp.Item{
+		Type:            s.Type(),
+		UniqueAttribute: "name",
+		Scope:           scope,
+		Attributes:      attributes,
+	}
+
+	for _, pod := range image.Pods {
+		item.LinkedItemQueries = append(item.LinkedItemQueries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "Pod",
+				Method: sdp.QueryMethod_GET,
+				Query:  pod,
+				Scope:  scope,
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// The pod doesn't change the image
+				In: false,
+				// A bad image will break every pod that runs it
+				Out: true,
+			},
+		})
+	}
+
+	if query := containerImageRegistryQuery(image.Reference); query != nil {
+		item.LinkedItemQueries = append(item.LinkedItemQueries, query)
+	}
+
+	return item, nil
+}
+
+// linkAdapters Uses the loaded Pod adapter rather than the adapter's own, so
+// that they share a cache and informers, and follow namespace updates. Images
+// can't be worked out without it
+func (s *containerImageAdapter) linkAdapters(adapterList []discovery.Adapter) bool {
+	pods, ok := linkedAdapter[*KubeTypeAdapter[*v1.Pod, *v1.PodList]](adapterList, "Pod")
+	if !ok {
+		return false
+	}
+
+	s.pods = pods
+
+	return true
+}
+
+func newContainerImageAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &containerImageAdapter{
+		pods: newPodAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*v1.Pod, *v1.PodList]),
+	}
+}
+
+var containerImageAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:            "ContainerImage",
+	DescriptiveName: "Container Image",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
+	PotentialLinks:  []string{"Pod", "ecr-repository", "gcp-artifact-registry-repository"},
+	SupportedQueryMethods: &sdp.AdapterSupportedQueryMethods{
+		Get:               true,
+		GetDescription:    "Get a Container Image by its full reference e.g. docker.io/library/nginx:1.27",
+		List:              true,
+		ListDescription:   "List all Container Images that are used by pods",
+		Search:            true,
+		SearchDescription: "Search for Container Images by reference. A repository without a tag e.g. docker.io/library/nginx finds every tag that is in use, and a digest e.g. sha256:... finds the images that are running it. The pods that are running each image are linked from it",
+	},
+})
+
+func init() {
+	registerAdapterLoader(newContainerImageAdapter)
+}
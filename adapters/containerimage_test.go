@@ -0,0 +1,401 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var containerImageYAML = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: containerimage-test-pod
+spec:
+  initContainers:
+  - name: init
+    image: busybox:1.36
+    command: ["true"]
+  containers:
+  - name: web
+    image: nginx
+`
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected ImageReference
+		name     string
+	}{
+		{
+			image:    "nginx",
+			expected: ImageReference{Registry: "docker.io", Repository: "library/nginx"},
+			name:     "docker.io/library/nginx:latest",
+		},
+		{
+			image:    "bitnami/redis:7.2",
+			expected: ImageReference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.2"},
+			name:     "docker.io/bitnami/redis:7.2",
+		},
+		{
+			image:    "index.docker.io/library/nginx:1.27",
+			expected: ImageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.27"},
+			name:     "docker.io/library/nginx:1.27",
+		},
+		{
+			image:    "localhost:5000/app:dev",
+			expected: ImageReference{Registry: "localhost:5000", Repository: "app", Tag: "dev"},
+			name:     "localhost:5000/app:dev",
+		},
+		{
+			image:    "123456789012.dkr.ecr.eu-west-2.amazonaws.com/team/app@" + testDigest,
+			expected: ImageReference{Registry: "123456789012.dkr.ecr.eu-west-2.amazonaws.com", Repository: "team/app", Digest: testDigest},
+			name:     "123456789012.dkr.ecr.eu-west-2.amazonaws.com/team/app@" + testDigest,
+		},
+		{
+			image:    "ghcr.io/org/app:v1@" + testDigest,
+			expected: ImageReference{Registry: "ghcr.io", Repository: "org/app", Tag: "v1", Digest: testDigest},
+			name:     "ghcr.io/org/app:v1@" + testDigest,
+		},
+		{
+			image:    testDigest,
+			expected: ImageReference{Digest: testDigest},
+			name:     testDigest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			ref, err := ParseImageReference(tt.image)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ref != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, ref)
+			}
+
+			if ref.String() != tt.name {
+				t.Errorf("expected name %v, got %v", tt.name, ref.String())
+			}
+		})
+	}
+
+	t.Run("with an empty reference", func(t *testing.T) {
+		if _, err := ParseImageReference(" "); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+}
+
+func TestImageIDDigest(t *testing.T) {
+	tests := map[string]string{
+		"docker-pullable://nginx@" + testDigest: testDigest,
+		"docker.io/library/nginx@" + testDigest: testDigest,
+		testDigest:                              testDigest,
+		"docker://sha256-but-not-really":        "",
+		"":                                      "",
+	}
+
+	for imageID, expected := range tests {
+		if digest := imageIDDigest(imageID); digest != expected {
+			t.Errorf("expected digest of %q to be %q, got %q", imageID, expected, digest)
+		}
+	}
+}
+
+func testImagePods() []*v1.Pod {
+	return []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-b"},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{Name: "web", Image: "nginx:1.27"},
+				},
+			},
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{Name: "web", ImageID: "docker.io/library/nginx@" + testDigest},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-a"},
+			Spec: v1.PodSpec{
+				InitContainers: []v1.Container{
+					{Name: "migrate", Image: "123456789012.dkr.ecr.eu-west-2.amazonaws.com/team/migrate:v3"},
+				},
+				Containers: []v1.Container{
+					{Name: "web", Image: "docker.io/library/nginx:1.27"},
+					{Name: "sidecar", Image: "nginx:1.26"},
+				},
+			},
+		},
+	}
+}
+
+func TestPodImages(t *testing.T) {
+	images := podImages(testImagePods())
+
+	names := make([]string, len(images))
+
+	for i, image := range images {
+		names[i] = image.Reference.String()
+	}
+
+	expected := []string{
+		"123456789012.dkr.ecr.eu-west-2.amazonaws.com/team/migrate:v3",
+		"docker.io/library/nginx:1.26",
+		"docker.io/library/nginx:1.27",
+	}
+
+	if !slices.Equal(names, expected) {
+		t.Fatalf("expected images %v, got %v", expected, names)
+	}
+
+	nginx := images[2]
+
+	if !slices.Equal(nginx.Pods, []string{"web-a", "web-b"}) {
+		t.Errorf("expected nginx:1.27 to be used by both pods, got %v", nginx.Pods)
+	}
+
+	if !slices.Equal(nginx.Digests, []string{testDigest}) {
+		t.Errorf("expected nginx:1.27 to be running %v, got %v", testDigest, nginx.Digests)
+	}
+}
+
+func TestContainerImageGet(t *testing.T) {
+	objects := make([]runtime.Object, 0)
+
+	for _, pod := range testImagePods() {
+		pod.Namespace = "default"
+		objects = append(objects, pod)
+	}
+
+	cs := fake.NewClientset(objects...)
+
+	adapter, ok := linkedAdapter[*containerImageAdapter](LoadAllAdapters(cs, "test", []string{"default"}, TypeFilter{}), "ContainerImage")
+
+	if !ok {
+		t.Fatal("expected ContainerImage to be loaded")
+	}
+
+	scope := ScopeDetails{ClusterName: "test", Namespace: "default"}.String()
+
+	if _, err := adapter.List(context.Background(), scope, false); err != nil {
+		t.Fatal(err)
+	}
+
+	actions := len(cs.Actions())
+
+	t.Run("getting a listed image", func(t *testing.T) {
+		item, err := adapter.Get(context.Background(), scope, "docker.io/library/nginx:1.27", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.UniqueAttributeValue() != "docker.io/library/nginx:1.27" {
+			t.Errorf("expected nginx:1.27, got %v", item.UniqueAttributeValue())
+		}
+
+		if len(cs.Actions()) != actions {
+			t.Errorf("expected the image to be served from the cache, got actions %v", cs.Actions()[actions:])
+		}
+	})
+
+	t.Run("getting an image that isn't used", func(t *testing.T) {
+		_, err := adapter.Get(context.Background(), scope, "docker.io/library/redis:latest", false)
+
+		var qErr *sdp.QueryError
+
+		if !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOTFOUND {
+			t.Errorf("expected NOTFOUND, got %v", err)
+		}
+	})
+}
+
+func TestContainerImageMatches(t *testing.T) {
+	image := &containerImage{
+		Reference: ImageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "1.27"},
+		Digests:   []string{testDigest},
+	}
+
+	tests := map[string]bool{
+		"nginx:1.27":                   true,
+		"nginx":                        true,
+		"docker.io/library/nginx":      true,
+		"nginx:1.26":                   false,
+		"redis:1.27":                   false,
+		testDigest:                     true,
+		"nginx@" + testDigest:          true,
+		"redis@" + testDigest:          false,
+		"sha256:" + "0000000000000000": false,
+	}
+
+	for query, expected := range tests {
+		ref, err := ParseImageReference(query)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if matches := image.matches(ref); matches != expected {
+			t.Errorf("expected %v to match: %v, got %v", query, expected, matches)
+		}
+	}
+}
+
+func TestPodImageMatcher(t *testing.T) {
+	pods := testImagePods()
+
+	if !podImageMatcher(testDigest, pods[0]) {
+		t.Error("expected pod to match the digest it is running")
+	}
+
+	if podImageMatcher(testDigest, pods[1]) {
+		t.Error("expected pod not to match a digest it isn't running")
+	}
+
+	if !podImageMatcher("123456789012.dkr.ecr.eu-west-2.amazonaws.com/team/migrate:v3", pods[1]) {
+		t.Error("expected pod to match the image of its init container")
+	}
+
+	if podImageMatcher("", pods[1]) {
+		t.Error("expected an empty query not to match")
+	}
+}
+
+func TestContainerImageRegistryQuery(t *testing.T) {
+	tests := []struct {
+		image string
+		typ   string
+		query string
+	}{
+		{
+			image: "123456789012.dkr.ecr.eu-west-2.amazonaws.com/team/app:v1",
+			typ:   "ecr-repository",
+			query: "team/app",
+		},
+		{
+			image: "europe-west2-docker.pkg.dev/my-project/my-repo/app:v1",
+			typ:   "gcp-artifact-registry-repository",
+			query: "europe-west2-docker.pkg.dev/my-project/my-repo",
+		},
+		{
+			image: "eu.gcr.io/my-project/app:v1",
+			typ:   "gcp-artifact-registry-repository",
+			query: "eu.gcr.io/my-project",
+		},
+		{
+			image: "nginx",
+		},
+		{
+			image: "europe-west2-docker.pkg.dev/my-project/app",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			ref, err := ParseImageReference(tt.image)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query := containerImageRegistryQuery(ref)
+
+			if tt.typ == "" {
+				if query != nil {
+					t.Errorf("expected no query, got %v", query)
+				}
+
+				return
+			}
+
+			if query == nil {
+				t.Fatal("expected a query, got none")
+			}
+
+			if query.GetQuery().GetType() != tt.typ {
+				t.Errorf("expected type %v, got %v", tt.typ, query.GetQuery().GetType())
+			}
+
+			if query.GetQuery().GetQuery() != tt.query {
+				t.Errorf("expected query %v, got %v", tt.query, query.GetQuery().GetQuery())
+			}
+
+			if query.GetQuery().GetScope() != "*" {
+				t.Errorf("expected scope *, got %v", query.GetQuery().GetScope())
+			}
+		})
+	}
+}
+
+func TestContainerImageAdapter(t *testing.T) {
+	sd := ScopeDetails{
+		ClusterName: CurrentCluster.Name,
+		Namespace:   "default",
+	}
+
+	adapterList := LoadAllAdapters(CurrentCluster.ClientSet, sd.ClusterName, []string{sd.Namespace}, TypeFilter{})
+
+	adapter, ok := linkedAdapter[*containerImageAdapter](adapterList, "ContainerImage")
+
+	if !ok {
+		t.Fatal("expected ContainerImage to be loaded")
+	}
+
+	pods, _ := linkedAdapter[*KubeTypeAdapter[*v1.Pod, *v1.PodList]](adapterList, "Pod")
+
+	if adapter.pods != pods {
+		t.Fatal("expected ContainerImage to use the loaded Pod adapter")
+	}
+
+	st := AdapterTests{
+		Adapter:   adapter,
+		GetQuery:  "docker.io/library/nginx:latest",
+		GetScope:  sd.String(),
+		SetupYAML: containerImageYAML,
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "Pod",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "containerimage-test-pod",
+				ExpectedScope:  sd.String(),
+			},
+		},
+	}
+
+	st.Execute(t)
+
+	t.Run("searching for a repository", func(t *testing.T) {
+		items, err := adapter.Search(context.Background(), sd.String(), "busybox", true)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 || items[0].UniqueAttributeValue() != "docker.io/library/busybox:1.36" {
+			t.Errorf("expected to find busybox:1.36, got %v", items)
+		}
+	})
+
+	t.Run("getting an image that isn't used", func(t *testing.T) {
+		_, err := adapter.Get(context.Background(), sd.String(), "docker.io/library/redis:latest", true)
+
+		if err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+}
@@ -8,7 +8,31 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func newCronJobAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+// cronJobHealthExtractor A cron job is healthy if the last job that it
+// scheduled succeeded
+func cronJobHealthExtractor(resource *v1.CronJob) *sdp.Health {
+	lastSchedule := resource.Status.LastScheduleTime
+	lastSuccess := resource.Status.LastSuccessfulTime
+
+	if lastSchedule == nil {
+		// Nothing has been scheduled yet
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	if lastSuccess != nil && !lastSuccess.Before(lastSchedule) {
+		return sdp.Health_HEALTH_OK.Enum()
+	}
+
+	// The last job hasn't succeeded. If it's still running we don't know yet,
+	// otherwise it failed
+	if len(resource.Status.Active) > 0 {
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	return sdp.Health_HEALTH_ERROR.Enum()
+}
+
+func newCronJobAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.CronJob, *v1.CronJobList]{
 		ClusterName:      cluster,
 		Namespaces:       namespaces,
@@ -26,16 +50,32 @@ func newCronJobAdapter(cs *kubernetes.Clientset, cluster string, namespaces []st
 
 			return bindings, nil
 		},
-		// Cronjobs don't need linked items as the jobs they produce are linked
-		// automatically
+		// The jobs that a cronjob produces are linked automatically, but we
+		// also link to everything that their pods will use so that this works
+		// even if no jobs are running
+		LinkedItemQueryExtractor: func(resource *v1.CronJob, scope string) ([]*sdp.LinkedItemQuery, error) {
+			return PodSpecExtractor(&resource.Spec.JobTemplate.Spec.Template.Spec, scope)
+		},
+		HealthExtractor: cronJobHealthExtractor,
 		AdapterMetadata: cronJobAdapterMetadata,
 	}
 }
 
 var cronJobAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "CronJob",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
-	DescriptiveName:       "Cron Job",
+	Type:            "CronJob",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
+	DescriptiveName: "Cron Job",
+	PotentialLinks: []string{
+		"ConfigMap",
+		"ContainerImage",
+		"dns",
+		"ec2-volume",
+		"ip",
+		"PersistentVolumeClaim",
+		"PriorityClass",
+		"Secret",
+		"ServiceAccount",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Cron Job"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
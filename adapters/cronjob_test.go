@@ -4,6 +4,11 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var cronJobYAML = `
@@ -76,3 +81,46 @@ func TestCronJobAdapter(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestCronJobHealthExtractor(t *testing.T) {
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour))
+	later := metav1.NewTime(time.Now())
+
+	cronJob := func(lastSchedule, lastSuccess *metav1.Time, active int) *v1.CronJob {
+		return &v1.CronJob{
+			Status: v1.CronJobStatus{
+				LastScheduleTime:   lastSchedule,
+				LastSuccessfulTime: lastSuccess,
+				Active:             make([]corev1.ObjectReference, active),
+			},
+		}
+	}
+
+	HealthTests[*v1.CronJob]{
+		{
+			Name:           "never scheduled",
+			Resource:       cronJob(nil, nil, 0),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name:           "last run succeeded",
+			Resource:       cronJob(&earlier, &later, 0),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "last run running",
+			Resource:       cronJob(&later, &earlier, 1),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name:           "last run failed",
+			Resource:       cronJob(&later, &earlier, 0),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name:           "never succeeded",
+			Resource:       cronJob(&later, nil, 0),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+	}.Execute(t, cronJobHealthExtractor)
+}
@@ -0,0 +1,476 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	k8sdiscovery "k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// CustomResourceDefinitionGVR The resource used to list and watch CRDs. These
+// are read using the dynamic client so that we don't need to depend on the
+// apiextensions types
+var CustomResourceDefinitionGVR = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// CustomResource A custom resource that is being served by the API
+type CustomResource struct {
+	GVR        schema.GroupVersionResource
+	Kind       string
+	Namespaced bool
+}
+
+// knownCustomResource Links and health for a custom resource whose schema we
+// know, such as the Gateway API. These are added to the adapter when the CRD is
+// installed, otherwise custom resources only have their attributes
+type knownCustomResource struct {
+	LinkedItemQueryExtractor func(resource *unstructured.Unstructured, scope string) ([]*sdp.LinkedItemQuery, error)
+	HealthExtractor          func(resource *unstructured.Unstructured) *sdp.Health
+	AdapterMetadata          *sdp.AdapterMetadata
+}
+
+var knownCustomResources = make(map[schema.GroupKind]knownCustomResource)
+
+// registerKnownCustomResource Registers the links and health for a custom
+// resource, this should be called from `init()`
+func registerKnownCustomResource(gk schema.GroupKind, known knownCustomResource) {
+	knownCustomResources[gk] = known
+}
+
+// dynamicItemInterface Wraps a dynamic client so that it matches
+// `WatchableItemInterface` and can be used by a KubeTypeAdapter
+type dynamicItemInterface struct {
+	client dynamic.ResourceInterface
+}
+
+func (d dynamicItemInterface) Get(ctx context.Context, name string, opts metav1.GetOptions) (*unstructured.Unstructured, error) {
+	return d.client.Get(ctx, name, opts)
+}
+
+func (d dynamicItemInterface) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return d.client.List(ctx, opts)
+}
+
+func (d dynamicItemInterface) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return d.client.Watch(ctx, opts)
+}
+
+// customResourceAdapter An adapter for a single custom resource. Adapters
+// can't be removed from a running engine, so when a CRD is deleted its adapter
+// is disabled instead. A disabled adapter has no scopes and fails all queries
+type customResourceAdapter struct {
+	*KubeTypeAdapter[*unstructured.Unstructured, *unstructured.UnstructuredList]
+
+	Resource CustomResource
+
+	disabled          bool
+	informersWereUsed bool       // Whether to re-enable informers when the adapter is re-enabled
+	disabledMu        sync.Mutex // Protects disabled and informersWereUsed
+}
+
+func newCustomResourceAdapter(client dynamic.Interface, resource CustomResource, cluster string, namespaces []string) *customResourceAdapter {
+	adapter := &KubeTypeAdapter[*unstructured.Unstructured, *unstructured.UnstructuredList]{
+		ClusterName: cluster,
+		Namespaces:  namespaces,
+		TypeName:    resource.Kind,
+		ListExtractor: func(list *unstructured.UnstructuredList) ([]*unstructured.Unstructured, error) {
+			extracted := make([]*unstructured.Unstructured, len(list.Items))
+
+			for i := range list.Items {
+				extracted[i] = &list.Items[i]
+			}
+
+			return extracted, nil
+		},
+		AdapterMetadata: &sdp.AdapterMetadata{
+			Type:                  resource.Kind,
+			DescriptiveName:       resource.Kind,
+			Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_CONFIGURATION,
+			SupportedQueryMethods: DefaultSupportedQueryMethods(resource.Kind),
+		},
+	}
+
+	if known, ok := knownCustomResources[schema.GroupKind{Group: resource.GVR.Group, Kind: resource.Kind}]; ok {
+		adapter.LinkedItemQueryExtractor = known.LinkedItemQueryExtractor
+		adapter.HealthExtractor = known.HealthExtractor
+		adapter.AdapterMetadata = known.AdapterMetadata
+	}
+
+	if resource.Namespaced {
+		adapter.NamespacedInterfaceBuilder = func(namespace string) ItemInterface[*unstructured.Unstructured, *unstructured.UnstructuredList] {
+			return dynamicItemInterface{client: client.Resource(resource.GVR).Namespace(namespace)}
+		}
+	} else {
+		adapter.ClusterInterfaceBuilder = func() ItemInterface[*unstructured.Unstructured, *unstructured.UnstructuredList] {
+			return dynamicItemInterface{client: client.Resource(resource.GVR)}
+		}
+	}
+
+	return &customResourceAdapter{
+		KubeTypeAdapter: adapter,
+		Resource:        resource,
+	}
+}
+
+// Disabled Returns whether the adapter has been disabled because its CRD was
+// removed
+func (s *customResourceAdapter) Disabled() bool {
+	s.disabledMu.Lock()
+	defer s.disabledMu.Unlock()
+
+	return s.disabled
+}
+
+// disable Stops the adapter from returning anything and throws away anything
+// it has stored
+func (s *customResourceAdapter) disable() {
+	s.disabledMu.Lock()
+	defer s.disabledMu.Unlock()
+
+	if s.disabled {
+		return
+	}
+
+	s.disabled = true
+	s.informersWereUsed = s.informersEnabled()
+
+	s.StopInformers()
+	s.Cache().Clear()
+}
+
+// enable Re-enables a disabled adapter
+func (s *customResourceAdapter) enable() {
+	s.disabledMu.Lock()
+	defer s.disabledMu.Unlock()
+
+	if !s.disabled {
+		return
+	}
+
+	s.disabled = false
+
+	if s.informersWereUsed {
+		s.EnableInformers()
+	}
+}
+
+func (s *customResourceAdapter) disabledError() error {
+	return &sdp.QueryError{
+		ErrorType:   sdp.QueryError_NOSCOPE,
+		ErrorString: fmt.Sprintf("the %v custom resource is no longer installed", s.TypeName),
+	}
+}
+
+func (s *customResourceAdapter) Scopes() []string {
+	if s.Disabled() {
+		return []string{}
+	}
+
+	return s.KubeTypeAdapter.Scopes()
+}
+
+func (s *customResourceAdapter) Get(ctx context.Context, scope string, query string, ignoreCache bool) (*sdp.Item, error) {
+	if s.Disabled() {
+		return nil, s.disabledError()
+	}
+
+	return s.KubeTypeAdapter.Get(ctx, scope, query, ignoreCache)
+}
+
+func (s *customResourceAdapter) List(ctx context.Context, scope string, ignoreCache bool) ([]*sdp.Item, error) {
+	if s.Disabled() {
+		return nil, s.disabledError()
+	}
+
+	return s.KubeTypeAdapter.List(ctx, scope, ignoreCache)
+}
+
+func (s *customResourceAdapter) Search(ctx context.Context, scope string, query string, ignoreCache bool) ([]*sdp.Item, error) {
+	if s.Disabled() {
+		return nil, s.disabledError()
+	}
+
+	return s.KubeTypeAdapter.Search(ctx, scope, query, ignoreCache)
+}
+
+// CustomResourceManager Keeps a set of adapters in sync with the custom
+// resources that are installed in a cluster. Each CRD is exposed as a type
+// named after its kind, using the version that is stored by the API server
+type CustomResourceManager struct {
+	DynamicClient   dynamic.Interface
+	DiscoveryClient k8sdiscovery.DiscoveryInterface
+	ClusterName     string
+
+	reservedTypes map[string]bool                   // Types that are handled by other adapters
+	adapters      map[string]*customResourceAdapter // Adapters by type
+	skipped       map[string]string                 // CRDs that were skipped in the last sync because their type is taken, and why
+	snapshot      *ClusterSnapshot                  // Serves the CRDs and custom resources instead of the clients if set
+	mu            sync.Mutex
+}
+
+// NewCustomResourceManager Creates a manager for the custom resources in a
+// cluster. CRDs whose kind matches one of the reserved types are skipped so
+// that built-in adapters take precedence
+func NewCustomResourceManager(dynamicClient dynamic.Interface, discoveryClient k8sdiscovery.DiscoveryInterface, cluster string, reservedTypes []string) *CustomResourceManager {
+	reserved := make(map[string]bool)
+
+	for _, itemType := range reservedTypes {
+		reserved[itemType] = true
+	}
+
+	return &CustomResourceManager{
+		DynamicClient:   dynamicClient,
+		DiscoveryClient: discoveryClient,
+		ClusterName:     cluster,
+		reservedTypes:   reserved,
+		adapters:        make(map[string]*customResourceAdapter),
+	}
+}
+
+// Sync Brings the adapters in line with the CRDs that are installed. Adapters
+// for CRDs that have been removed are disabled, and are re-enabled if the CRD
+// comes back. Any adapters that had to be created are returned so that they
+// can be added to the engine. Namespaced adapters are created with the given
+// namespaces.
+//
+// If a CRD has changed in a way that means it needs a new adapter, e.g. a new
+// storage version, the old adapter is disabled and returned in `replaced`.
+// These must be removed from the engine since it can't have two adapters for
+// the same type
+func (m *CustomResourceManager) Sync(ctx context.Context, namespaces []string) (created []discovery.Adapter, replaced []discovery.Adapter, err error) {
+	resources, skipped, err := m.servedResources(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.skipped = skipped
+
+	created = make([]discovery.Adapter, 0)
+	replaced = make([]discovery.Adapter, 0)
+
+	for kind, resource := range resources {
+		adapter, ok := m.adapters[kind]
+
+		if ok && adapter.Resource == resource {
+			adapter.enable()
+			continue
+		}
+
+		if ok {
+			adapter.disable()
+			replaced = append(replaced, adapter)
+		}
+
+		adapter = newCustomResourceAdapter(m.DynamicClient, resource, m.ClusterName, slices.Clone(namespaces))
+
+		if m.snapshot != nil {
+			err = m.snapshot.serve(adapter)
+
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not serve %v from snapshot: %w", kind, err)
+			}
+		}
+
+		m.adapters[kind] = adapter
+		created = append(created, adapter)
+	}
+
+	// Adapters for CRDs that have been removed are kept so that they can be
+	// re-enabled if it comes back
+	for kind, adapter := range m.adapters {
+		if _, ok := resources[kind]; !ok {
+			adapter.disable()
+		}
+	}
+
+	return created, replaced, nil
+}
+
+// Skipped Returns the CRDs that were skipped in the last sync because another
+// adapter already has their type, along with the reason
+func (m *CustomResourceManager) Skipped() map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return maps.Clone(m.skipped)
+}
+
+// servedResources Lists the CRDs in the cluster and uses the discovery API to
+// find out which of them are actually being served, returning them by kind.
+// CRDs that are skipped because their kind is taken are also returned by name
+func (m *CustomResourceManager) servedResources(ctx context.Context) (map[string]CustomResource, map[string]string, error) {
+	crds, err := m.customResourceDefinitions(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Sort by name so that if two CRDs have the same kind, the same one is
+	// always picked
+	slices.SortFunc(crds, func(a, b unstructured.Unstructured) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+
+	resources := make(map[string]CustomResource)
+	skipped := make(map[string]string)
+	groupVersions := make(map[string]*metav1.APIResourceList)
+
+	for i := range crds {
+		gvr, err := crdGroupVersionResource(&crds[i])
+		if err != nil {
+			// CRDs that don't have any served versions can't be queried
+			continue
+		}
+
+		apiResource, ok := m.apiResource(&crds[i], gvr, groupVersions)
+
+		if !ok {
+			continue
+		}
+
+		// We need to be able to get and list for the adapter to work
+		if !slices.Contains(apiResource.Verbs, "get") || !slices.Contains(apiResource.Verbs, "list") {
+			continue
+		}
+
+		// Types must be unique, so skip anything that is already handled by
+		// a built-in adapter or by another CRD with the same kind
+		if m.reservedTypes[apiResource.Kind] {
+			skipped[crds[i].GetName()] = fmt.Sprintf("%v is a built-in type", apiResource.Kind)
+			continue
+		}
+
+		if existing, exists := resources[apiResource.Kind]; exists {
+			skipped[crds[i].GetName()] = fmt.Sprintf("%v is already provided by %v", apiResource.Kind, existing.GVR.GroupResource())
+			continue
+		}
+
+		resources[apiResource.Kind] = CustomResource{
+			GVR:        gvr,
+			Kind:       apiResource.Kind,
+			Namespaced: apiResource.Namespaced,
+		}
+	}
+
+	return resources, skipped, nil
+}
+
+// customResourceDefinitions Lists the CRDs in the cluster, or in the snapshot
+// that is being served
+func (m *CustomResourceManager) customResourceDefinitions(ctx context.Context) ([]unstructured.Unstructured, error) {
+	if m.snapshot != nil {
+		return m.snapshot.customResourceDefinitions(), nil
+	}
+
+	list, err := m.DynamicClient.Resource(CustomResourceDefinitionGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list CustomResourceDefinitions: %w", err)
+	}
+
+	return list.Items, nil
+}
+
+// apiResource Finds out how a CRD's resource is being served using the
+// discovery API. Responses are stored in `groupVersions` so that each group
+// version is only looked up once per sync
+func (m *CustomResourceManager) apiResource(crd *unstructured.Unstructured, gvr schema.GroupVersionResource, groupVersions map[string]*metav1.APIResourceList) (metav1.APIResource, bool) {
+	if m.snapshot != nil {
+		return snapshotAPIResource(crd, gvr), true
+	}
+
+	groupVersion := gvr.GroupVersion().String()
+	resourceList, ok := groupVersions[groupVersion]
+
+	if !ok {
+		var err error
+
+		resourceList, err = m.DiscoveryClient.ServerResourcesForGroupVersion(groupVersion)
+		if err != nil {
+			// This will happen if the CRD hasn't been established yet, it'll
+			// be picked up on the next sync
+			return metav1.APIResource{}, false
+		}
+
+		groupVersions[groupVersion] = resourceList
+	}
+
+	return findAPIResource(resourceList, gvr.Resource)
+}
+
+// findAPIResource Finds a resource by name in a discovery response
+func findAPIResource(list *metav1.APIResourceList, name string) (metav1.APIResource, bool) {
+	for _, resource := range list.APIResources {
+		if resource.Name == name {
+			return resource, true
+		}
+	}
+
+	return metav1.APIResource{}, false
+}
+
+// crdGroupVersionResource Works out which GVR to query for a CRD. This is the
+// storage version if it is served, otherwise the first version that is
+func crdGroupVersionResource(crd *unstructured.Unstructured) (schema.GroupVersionResource, error) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+
+	if group == "" || plural == "" {
+		return schema.GroupVersionResource{}, errors.New("CRD is missing spec.group or spec.names.plural")
+	}
+
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+	var version string
+
+	for _, v := range versions {
+		versionMap, ok := v.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		name, _, _ := unstructured.NestedString(versionMap, "name")
+		served, _, _ := unstructured.NestedBool(versionMap, "served")
+		storage, _, _ := unstructured.NestedBool(versionMap, "storage")
+
+		if !served {
+			continue
+		}
+
+		if storage {
+			version = name
+			break
+		}
+
+		if version == "" {
+			version = name
+		}
+	}
+
+	if version == "" {
+		return schema.GroupVersionResource{}, errors.New("CRD has no served versions")
+	}
+
+	return schema.GroupVersionResource{
+		Group:    group,
+		Version:  version,
+		Resource: plural,
+	}, nil
+}
@@ -0,0 +1,419 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var widgetGVR = schema.GroupVersionResource{
+	Group:    "example.com",
+	Version:  "v1",
+	Resource: "widgets",
+}
+
+var gatewayGVR = schema.GroupVersionResource{
+	Group:    GatewayAPIGroup,
+	Version:  "v1",
+	Resource: "gateways",
+}
+
+func newTestCRD(name string, group string, plural string, kind string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"group": group,
+				"scope": "Namespaced",
+				"names": map[string]interface{}{
+					"plural": plural,
+					"kind":   kind,
+				},
+				"versions": []interface{}{
+					map[string]interface{}{
+						"name":    "v1alpha1",
+						"served":  true,
+						"storage": false,
+					},
+					map[string]interface{}{
+						"name":    "v1",
+						"served":  true,
+						"storage": true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func createCustomResourceManager(objects ...runtime.Object) (*CustomResourceManager, *dynamicfake.FakeDynamicClient) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			CustomResourceDefinitionGVR: "CustomResourceDefinitionList",
+			widgetGVR:                   "WidgetList",
+			gatewayGVR:                  "GatewayList",
+		},
+		objects...,
+	)
+
+	discoveryClient := &fakediscovery.FakeDiscovery{
+		Fake: &k8stesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "example.com/v1",
+					APIResources: []metav1.APIResource{
+						{
+							Name:       "widgets",
+							Kind:       "Widget",
+							Namespaced: true,
+							Verbs:      []string{"get", "list", "watch"},
+						},
+						{
+							Name:       "widgets/status",
+							Kind:       "Widget",
+							Namespaced: true,
+							Verbs:      []string{"get"},
+						},
+						{
+							Name:       "pods",
+							Kind:       "Pod",
+							Namespaced: true,
+							Verbs:      []string{"get", "list", "watch"},
+						},
+					},
+				},
+				{
+					GroupVersion: "gateway.networking.k8s.io/v1",
+					APIResources: []metav1.APIResource{
+						{
+							Name:       "gateways",
+							Kind:       "Gateway",
+							Namespaced: true,
+							Verbs:      []string{"get", "list", "watch"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return NewCustomResourceManager(client, discoveryClient, "test-cluster", []string{"Pod"}), client
+}
+
+func TestCustomResourceManager(t *testing.T) {
+	ctx := context.Background()
+
+	widget := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name":      "foo",
+				"namespace": "default",
+				"labels": map[string]interface{}{
+					"app": "foo",
+				},
+				"ownerReferences": []interface{}{
+					map[string]interface{}{
+						"apiVersion": "example.com/v1",
+						"kind":       "Gadget",
+						"name":       "bar",
+						"uid":        "1234",
+					},
+				},
+			},
+			"spec": map[string]interface{}{
+				"size": "large",
+			},
+		},
+	}
+
+	manager, client := createCustomResourceManager(
+		newTestCRD("widgets.example.com", "example.com", "widgets", "Widget"),
+		// This clashes with a built-in type so should be skipped
+		newTestCRD("pods.example.com", "example.com", "pods", "Pod"),
+		widget,
+	)
+
+	created, _, err := manager.Sync(ctx, []string{"default"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != 1 {
+		t.Fatalf("expected 1 adapter, got %v", len(created))
+	}
+
+	adapter, ok := created[0].(*customResourceAdapter)
+
+	if !ok {
+		t.Fatalf("expected *customResourceAdapter, got %T", created[0])
+	}
+
+	if adapter.Resource.GVR != widgetGVR {
+		t.Errorf("expected the storage version %v, got %v", widgetGVR, adapter.Resource.GVR)
+	}
+
+	if reason, ok := manager.Skipped()["pods.example.com"]; !ok {
+		t.Errorf("expected pods.example.com to be reported as skipped, got %v", manager.Skipped())
+	} else if reason != "Pod is a built-in type" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+
+	scope := "test-cluster.default"
+
+	t.Run("Get", func(t *testing.T) {
+		item, err := adapter.Get(ctx, scope, "foo", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.GetType() != "Widget" {
+			t.Errorf("expected type Widget, got %v", item.GetType())
+		}
+
+		if item.GetScope() != scope {
+			t.Errorf("expected scope %v, got %v", scope, item.GetScope())
+		}
+
+		// Metadata should be promoted to the top level
+		if _, err := item.GetAttributes().Get("metadata"); err == nil {
+			t.Error("expected metadata to be promoted")
+		}
+
+		if ns, _ := item.GetAttributes().Get("namespace"); ns != "default" {
+			t.Errorf("expected namespace default, got %v", ns)
+		}
+
+		if item.GetTags()["app"] != "foo" {
+			t.Errorf("expected app tag to be foo, got %v", item.GetTags()["app"])
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "Gadget",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "bar",
+				ExpectedScope:  scope,
+			},
+		}.Execute(t, item)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		items, err := adapter.List(ctx, scope, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 {
+			t.Errorf("expected 1 item, got %v", len(items))
+		}
+	})
+
+	t.Run("With informers", func(t *testing.T) {
+		adapter.EnableInformers()
+		defer adapter.StopInformers()
+
+		item, err := adapter.Get(ctx, scope, "foo", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.UniqueAttributeValue() != "foo" {
+			t.Errorf("expected foo, got %v", item.UniqueAttributeValue())
+		}
+	})
+
+	t.Run("Sync without changes", func(t *testing.T) {
+		created, _, err := manager.Sync(ctx, []string{"default"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(created) != 0 {
+			t.Errorf("expected no new adapters, got %v", len(created))
+		}
+	})
+
+	t.Run("CRD removed", func(t *testing.T) {
+		err := client.Resource(CustomResourceDefinitionGVR).Delete(ctx, "widgets.example.com", metav1.DeleteOptions{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, err = manager.Sync(ctx, []string{"default"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !adapter.Disabled() {
+			t.Fatal("expected adapter to be disabled")
+		}
+
+		if len(adapter.Scopes()) != 0 {
+			t.Errorf("expected no scopes, got %v", adapter.Scopes())
+		}
+
+		_, err = adapter.Get(ctx, scope, "foo", false)
+
+		var qErr *sdp.QueryError
+
+		if !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOSCOPE {
+			t.Errorf("expected NOSCOPE error, got %v", err)
+		}
+	})
+
+	t.Run("CRD re-added", func(t *testing.T) {
+		_, err := client.Resource(CustomResourceDefinitionGVR).Create(ctx, newTestCRD("widgets.example.com", "example.com", "widgets", "Widget"), metav1.CreateOptions{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		created, _, err := manager.Sync(ctx, []string{"default"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(created) != 0 {
+			t.Errorf("expected the existing adapter to be re-used, got %v new adapters", len(created))
+		}
+
+		if adapter.Disabled() {
+			t.Error("expected adapter to be re-enabled")
+		}
+
+		if len(adapter.Scopes()) != 1 {
+			t.Errorf("expected 1 scope, got %v", adapter.Scopes())
+		}
+	})
+
+	t.Run("Storage version changed", func(t *testing.T) {
+		fakeDiscovery, ok := manager.DiscoveryClient.(*fakediscovery.FakeDiscovery)
+
+		if !ok {
+			t.Fatalf("unexpected discovery client %T", manager.DiscoveryClient)
+		}
+
+		fakeDiscovery.Resources = append(fakeDiscovery.Resources, &metav1.APIResourceList{
+			GroupVersion: "example.com/v2",
+			APIResources: []metav1.APIResource{
+				{
+					Name:       "widgets",
+					Kind:       "Widget",
+					Namespaced: true,
+					Verbs:      []string{"get", "list", "watch"},
+				},
+			},
+		})
+
+		crd := newTestCRD("widgets.example.com", "example.com", "widgets", "Widget")
+
+		_ = unstructured.SetNestedSlice(crd.Object, []interface{}{
+			map[string]interface{}{
+				"name":    "v2",
+				"served":  true,
+				"storage": true,
+			},
+		}, "spec", "versions")
+
+		_, err := client.Resource(CustomResourceDefinitionGVR).Update(ctx, crd, metav1.UpdateOptions{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		created, replaced, err := manager.Sync(ctx, []string{"default"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(created) != 1 || created[0].Type() != "Widget" {
+			t.Fatalf("expected a new Widget adapter, got %v", created)
+		}
+
+		// The engine can't have two adapters for the same type, so the old
+		// one has to be removed
+		if len(replaced) != 1 || replaced[0] != adapter {
+			t.Errorf("expected the old adapter to be replaced, got %v", replaced)
+		}
+
+		if !adapter.Disabled() {
+			t.Error("expected the old adapter to be disabled")
+		}
+
+		if gvr := created[0].(*customResourceAdapter).Resource.GVR; gvr.Version != "v2" {
+			t.Errorf("expected the new adapter to use v2, got %v", gvr)
+		}
+	})
+}
+
+func TestCRDGroupVersionResource(t *testing.T) {
+	t.Run("with no served versions", func(t *testing.T) {
+		crd := newTestCRD("widgets.example.com", "example.com", "widgets", "Widget")
+
+		_ = unstructured.SetNestedSlice(crd.Object, []interface{}{
+			map[string]interface{}{
+				"name":    "v1",
+				"served":  false,
+				"storage": true,
+			},
+		}, "spec", "versions")
+
+		_, err := crdGroupVersionResource(crd)
+
+		if err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+
+	t.Run("with a storage version that isn't served", func(t *testing.T) {
+		crd := newTestCRD("widgets.example.com", "example.com", "widgets", "Widget")
+
+		_ = unstructured.SetNestedSlice(crd.Object, []interface{}{
+			map[string]interface{}{
+				"name":    "v1beta1",
+				"served":  true,
+				"storage": false,
+			},
+			map[string]interface{}{
+				"name":    "v1",
+				"served":  false,
+				"storage": true,
+			},
+		}, "spec", "versions")
+
+		gvr, err := crdGroupVersionResource(crd)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if gvr.Version != "v1beta1" {
+			t.Errorf("expected v1beta1, got %v", gvr.Version)
+		}
+	})
+}
@@ -8,7 +8,38 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func newDaemonSetAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func daemonSetHealthExtractor(resource *v1.DaemonSet) *sdp.Health {
+	desired := resource.Status.DesiredNumberScheduled
+
+	// The controller hasn't caught up with the latest spec, or is still
+	// rolling out a new revision
+	if resource.Status.ObservedGeneration < resource.Generation || resource.Status.UpdatedNumberScheduled < desired {
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	// Pods are running on nodes where they shouldn't be
+	if resource.Status.NumberMisscheduled > 0 {
+		return sdp.Health_HEALTH_WARNING.Enum()
+	}
+
+	return replicaHealth(desired, resource.Status.NumberReady)
+}
+
+// replicaHealth Workloads with all of their replicas ready are healthy, ones
+// with only some of them ready are degraded, and ones with none ready are
+// broken
+func replicaHealth(desired int32, ready int32) *sdp.Health {
+	switch {
+	case ready >= desired:
+		return sdp.Health_HEALTH_OK.Enum()
+	case ready > 0:
+		return sdp.Health_HEALTH_WARNING.Enum()
+	default:
+		return sdp.Health_HEALTH_ERROR.Enum()
+	}
+}
+
+func newDaemonSetAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.DaemonSet, *v1.DaemonSetList]{
 		ClusterName:      cluster,
 		Namespaces:       namespaces,
@@ -26,15 +57,31 @@ func newDaemonSetAdapter(cs *kubernetes.Clientset, cluster string, namespaces []
 
 			return extracted, nil
 		},
-		// Pods are linked automatically
+		// Pods are linked automatically, but we also link to everything that
+		// the pods will use so that this works even if none are running
+		LinkedItemQueryExtractor: func(resource *v1.DaemonSet, scope string) ([]*sdp.LinkedItemQuery, error) {
+			return PodSpecExtractor(&resource.Spec.Template.Spec, scope)
+		},
+		HealthExtractor: daemonSetHealthExtractor,
 		AdapterMetadata: daemonSetAdapterMetadata,
 	}
 }
 
 var daemonSetAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "DaemonSet",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
-	DescriptiveName:       "Daemon Set",
+	Type:            "DaemonSet",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
+	DescriptiveName: "Daemon Set",
+	PotentialLinks: []string{
+		"ConfigMap",
+		"ContainerImage",
+		"dns",
+		"ec2-volume",
+		"ip",
+		"PersistentVolumeClaim",
+		"PriorityClass",
+		"Secret",
+		"ServiceAccount",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Daemon Set"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
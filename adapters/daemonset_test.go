@@ -2,6 +2,9 @@ package adapters
 
 import (
 	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/apps/v1"
 )
 
 var daemonSetYAML = `
@@ -43,3 +46,44 @@ func TestDaemonSetSource(t *testing.T) {
 
 	st.Execute(t)
 }
+
+func TestDaemonSetHealthExtractor(t *testing.T) {
+	daemonSet := func(desired, updated, ready, misscheduled int32) *v1.DaemonSet {
+		return &v1.DaemonSet{
+			Status: v1.DaemonSetStatus{
+				DesiredNumberScheduled: desired,
+				UpdatedNumberScheduled: updated,
+				NumberReady:            ready,
+				NumberMisscheduled:     misscheduled,
+			},
+		}
+	}
+
+	HealthTests[*v1.DaemonSet]{
+		{
+			Name:           "all ready",
+			Resource:       daemonSet(3, 3, 3, 0),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "some ready",
+			Resource:       daemonSet(3, 3, 2, 0),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+		{
+			Name:           "none ready",
+			Resource:       daemonSet(3, 3, 0, 0),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name:           "rolling out",
+			Resource:       daemonSet(3, 1, 3, 0),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name:           "misscheduled",
+			Resource:       daemonSet(3, 3, 3, 1),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+	}.Execute(t, daemonSetHealthExtractor)
+}
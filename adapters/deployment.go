@@ -12,7 +12,7 @@ import (
 
 var replicaSetProgressedRegex = regexp.MustCompile(`ReplicaSet "([^"]+)" has successfully progressed`)
 
-func newDeploymentAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func newDeploymentAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.Deployment, *v1.DeploymentList]{
 		ClusterName:      cluster,
 		Namespaces:       namespaces,
@@ -63,6 +63,16 @@ func newDeploymentAdapter(cs *kubernetes.Clientset, cluster string, namespaces [
 				}
 			}
 
+			// Link to everything that the pods will use, even if none are
+			// running
+			podQueries, err := PodSpecExtractor(&deployment.Spec.Template.Spec, scope)
+
+			if err != nil {
+				return nil, err
+			}
+
+			queries = append(queries, podQueries...)
+
 			return queries, nil
 		},
 		HealthExtractor: func(deployment *v1.Deployment) *sdp.Health {
@@ -101,9 +111,20 @@ func newDeploymentAdapter(cs *kubernetes.Clientset, cluster string, namespaces [
 }
 
 var deploymentAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "Deployment",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
-	PotentialLinks:        []string{"ReplicaSet"},
+	Type:     "Deployment",
+	Category: sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
+	PotentialLinks: []string{
+		"ReplicaSet",
+		"ConfigMap",
+		"ContainerImage",
+		"dns",
+		"ec2-volume",
+		"ip",
+		"PersistentVolumeClaim",
+		"PriorityClass",
+		"Secret",
+		"ServiceAccount",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Deployment"),
 	DescriptiveName:       "Deployment",
 	TerraformMappings: []*sdp.TerraformMapping{
@@ -27,6 +27,10 @@ spec:
         image: nginx:latest
         ports:
         - containerPort: 80
+        envFrom:
+        - configMapRef:
+            name: my-deployment-config
+            optional: true
 `
 
 func TestDeploymentSource(t *testing.T) {
@@ -52,6 +56,12 @@ func TestDeploymentSource(t *testing.T) {
 				ExpectedScope:        "local-tests.default",
 				ExpectedQueryMatches: regexp.MustCompile("my-deployment"),
 			},
+			{
+				ExpectedType:   "ConfigMap",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "my-deployment-config",
+				ExpectedScope:  "local-tests.default",
+			},
 		},
 	}
 
@@ -0,0 +1,746 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// This file contains the EffectivePermissions type, which is everything that a
+// service account, user or group is allowed to do. It is the union of the
+// rules of every role that is bound to the subject, including through the
+// groups that kubernetes puts every service account and user in
+
+// allNamespaces Is used as the namespace of rules that apply everywhere
+// because they come from a ClusterRoleBinding
+const allNamespaces = "*"
+
+// Groups that kubernetes adds subjects to when they authenticate
+const (
+	authenticatedGroup         = "system:authenticated"
+	serviceAccountsGroup       = "system:serviceaccounts"
+	serviceAccountsGroupPrefix = "system:serviceaccounts:"
+)
+
+// dangerousVerbs Verbs that let a subject give itself, or act with, more
+// permissions than it has been granted
+var dangerousVerbs = []string{"escalate", "bind", "impersonate"}
+
+// grantedRule A rule that applies to a subject, and where it came from
+type grantedRule struct {
+	rbacv1.PolicyRule
+	// The namespace that the rule applies in, or `allNamespaces`
+	Namespace string
+	Binding   rbacBinding
+	// The roles that the rule came from. This is the bound role, plus the
+	// aggregated ClusterRole that it came from if there is one
+	Roles []rbacv1.RoleRef
+}
+
+// parseEffectivePermissionsQuery Parses the subject out of a query in the
+// format `ServiceAccount/{namespace}/{name}`, `User/{name}` or `Group/{name}`
+func parseEffectivePermissionsQuery(query string) (rbacSubject, error) {
+	kind, rest, _ := strings.Cut(query, "/")
+
+	subject := rbacSubject{
+		Kind: kind,
+		Name: rest,
+	}
+
+	switch kind {
+	case rbacv1.ServiceAccountKind:
+		subject.Namespace, subject.Name, _ = strings.Cut(rest, "/")
+
+		if subject.Namespace == "" {
+			return subject, fmt.Errorf("service account %v has no namespace", query)
+		}
+	case rbacv1.UserKind, rbacv1.GroupKind:
+	default:
+		return subject, fmt.Errorf("query %v must be in the format ServiceAccount/{namespace}/{name}, User/{name} or Group/{name}", query)
+	}
+
+	if subject.Name == "" {
+		return subject, fmt.Errorf("query %v has no name", query)
+	}
+
+	return subject, nil
+}
+
+// effectivePermissionsQuery Returns the query for the effective permissions of
+// a subject
+func effectivePermissionsQuery(subject rbacSubject) string {
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		return subject.Kind + "/" + subject.Namespace + "/" + subject.Name
+	}
+
+	return subject.Kind + "/" + subject.Name
+}
+
+// effectivePermissionsLink Links a subject to its effective permissions
+func effectivePermissionsLink(subject rbacSubject, clusterName string) *sdp.LinkedItemQuery {
+	return &sdp.LinkedItemQuery{
+		Query: &sdp.Query{
+			Type:   "EffectivePermissions",
+			Method: sdp.QueryMethod_GET,
+			Query:  effectivePermissionsQuery(subject),
+			Scope:  clusterName,
+		},
+		BlastPropagation: &sdp.BlastPropagation{
+			// Changes to the permissions change what the subject can do
+			In: true,
+			// The subject doesn't change the permissions
+			Out: false,
+		},
+	}
+}
+
+// implicitGroups Returns the groups that kubernetes puts a subject in. Users
+// can be in other groups too, but these come from the authenticator so can't
+// be known
+func implicitGroups(subject rbacSubject) []rbacSubject {
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		return []rbacSubject{
+			{Kind: rbacv1.GroupKind, Name: serviceAccountsGroup},
+			{Kind: rbacv1.GroupKind, Name: serviceAccountsGroupPrefix + subject.Namespace},
+			{Kind: rbacv1.GroupKind, Name: authenticatedGroup},
+		}
+	case rbacv1.UserKind:
+		return []rbacSubject{
+			{Kind: rbacv1.GroupKind, Name: authenticatedGroup},
+		}
+	}
+
+	return nil
+}
+
+// aggregatedClusterRoleRules Returns the rules of a ClusterRole, expanding its
+// aggregation rule if it has one. The aggregation controller copies the rules
+// into the role, but they are worked out here too so that roles that the
+// controller hasn't caught up with are still correct. Each rule is returned
+// with the role that it came from
+func aggregatedClusterRoleRules(role *rbacv1.ClusterRole, clusterRoles []*rbacv1.ClusterRole) ([]rbacv1.PolicyRule, []string) {
+	rules := make([]rbacv1.PolicyRule, 0)
+	sources := make([]string, 0)
+	visited := make(map[string]bool)
+
+	var expand func(role *rbacv1.ClusterRole)
+
+	expand = func(role *rbacv1.ClusterRole) {
+		if visited[role.Name] {
+			return
+		}
+
+		visited[role.Name] = true
+
+		for _, rule := range role.Rules {
+			rules = append(rules, rule)
+			sources = append(sources, role.Name)
+		}
+
+		if role.AggregationRule == nil {
+			return
+		}
+
+		for _, labelSelector := range role.AggregationRule.ClusterRoleSelectors {
+			selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+
+			// An invalid or empty selector doesn't select anything
+			if err != nil || selector.Empty() {
+				continue
+			}
+
+			for _, candidate := range clusterRoles {
+				if selector.Matches(labels.Set(candidate.Labels)) {
+					expand(candidate)
+				}
+			}
+		}
+	}
+
+	expand(role)
+
+	return rules, sources
+}
+
+// effectivePermissions The rules that apply to a subject, worked out from the
+// bindings that name it
+type effectivePermissions struct {
+	Subject rbacSubject
+	Rules   []grantedRule
+}
+
+// Verbs Returns the distinct verbs that are granted
+func (p *effectivePermissions) Verbs() []string {
+	return p.distinct(func(rule grantedRule) []string {
+		return rule.Verbs
+	})
+}
+
+// Resources Returns the distinct resources that are granted, in the format
+// `{resource}.{group}` for resources that aren't in the core group, and the
+// non-resource URLs
+func (p *effectivePermissions) Resources() []string {
+	return p.distinct(func(rule grantedRule) []string {
+		resources := make([]string, 0)
+
+		for _, resource := range rule.Resources {
+			for _, group := range rule.APIGroups {
+				resources = append(resources, schema.GroupResource{Group: group, Resource: resource}.String())
+			}
+		}
+
+		return append(resources, rule.NonResourceURLs...)
+	})
+}
+
+// Namespaces Returns the namespaces that the subject has permissions in, this
+// includes `*` if it has permissions in all namespaces
+func (p *effectivePermissions) Namespaces() []string {
+	return p.distinct(func(rule grantedRule) []string {
+		return []string{rule.Namespace}
+	})
+}
+
+func (p *effectivePermissions) distinct(values func(rule grantedRule) []string) []string {
+	all := make([]string, 0)
+
+	for _, rule := range p.Rules {
+		all = append(all, values(rule)...)
+	}
+
+	slices.Sort(all)
+
+	return slices.Compact(all)
+}
+
+// Dangerous Returns descriptions of the grants that are dangerous, either
+// because they allow anything, allow reading secrets or running commands in
+// pods, or allow the subject to gain more permissions
+func (p *effectivePermissions) Dangerous() []string {
+	dangerous := make([]string, 0)
+
+	for _, rule := range p.Rules {
+		where := "in " + rule.Namespace
+
+		if rule.Namespace == allNamespaces {
+			where = "in all namespaces"
+		}
+
+		if slices.Contains(rule.Verbs, rbacv1.VerbAll) {
+			dangerous = append(dangerous, fmt.Sprintf("all verbs on %v %v", strings.Join(slices.Concat(rule.Resources, rule.NonResourceURLs), ","), where))
+		}
+
+		for _, verb := range dangerousVerbs {
+			if slices.Contains(rule.Verbs, verb) {
+				dangerous = append(dangerous, fmt.Sprintf("%v %v", verb, where))
+			}
+		}
+
+		if !ruleMatchesGroup(rule.PolicyRule, "") {
+			continue
+		}
+
+		if ruleMatchesResource(rule.PolicyRule, "secrets") && ruleMatchesVerb(rule.PolicyRule, "get", "list", "watch") {
+			dangerous = append(dangerous, "read secrets "+where)
+		}
+
+		if ruleMatchesResource(rule.PolicyRule, "pods/exec") && ruleMatchesVerb(rule.PolicyRule, "create", "get") {
+			dangerous = append(dangerous, "exec into pods "+where)
+		}
+	}
+
+	slices.Sort(dangerous)
+
+	return slices.Compact(dangerous)
+}
+
+func ruleMatchesGroup(rule rbacv1.PolicyRule, group string) bool {
+	return slices.Contains(rule.APIGroups, group) || slices.Contains(rule.APIGroups, rbacv1.APIGroupAll)
+}
+
+func ruleMatchesResource(rule rbacv1.PolicyRule, resource string) bool {
+	return slices.Contains(rule.Resources, resource) || slices.Contains(rule.Resources, rbacv1.ResourceAll)
+}
+
+func ruleMatchesVerb(rule rbacv1.PolicyRule, verbs ...string) bool {
+	for _, verb := range verbs {
+		if slices.Contains(rule.Verbs, verb) || slices.Contains(rule.Verbs, rbacv1.VerbAll) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// effectivePermissionsHealth Permissions are unhealthy if any of the grants
+// are dangerous
+func effectivePermissionsHealth(permissions *effectivePermissions) *sdp.Health {
+	if len(permissions.Dangerous()) > 0 {
+		return sdp.Health_HEALTH_WARNING.Enum()
+	}
+
+	return sdp.Health_HEALTH_OK.Enum()
+}
+
+// effectivePermissionsAdapter Serves the effective permissions of subjects. It
+// uses the Role and ClusterRole adapters to fetch the roles, and the same
+// index of bindings as the subjects do. Once the adapters are loaded these are
+// the ones for the Role, ClusterRole and binding types, so that roles and
+// bindings are only listed in the namespaces that are being discovered
+type effectivePermissionsAdapter struct {
+	ClusterName string
+
+	index        *rbacSubjectIndex
+	roles        *KubeTypeAdapter[*rbacv1.Role, *rbacv1.RoleList]
+	clusterRoles *KubeTypeAdapter[*rbacv1.ClusterRole, *rbacv1.ClusterRoleList]
+}
+
+func (s *effectivePermissionsAdapter) Type() string {
+	return "EffectivePermissions"
+}
+
+func (s *effectivePermissionsAdapter) Name() string {
+	return "k8s-EffectivePermissions"
+}
+
+func (s *effectivePermissionsAdapter) Metadata() *sdp.AdapterMetadata {
+	return effectivePermissionsAdapterMetadata
+}
+
+func (s *effectivePermissionsAdapter) Weight() int {
+	return 10
+}
+
+func (s *effectivePermissionsAdapter) Scopes() []string {
+	return s.clusterRoles.Scopes()
+}
+
+// GroupResource The permissions are worked out from the roles and bindings,
+// which are all in the same group
+func (s *effectivePermissionsAdapter) GroupResource() (schema.GroupResource, error) {
+	return rbacv1.Resource("clusterroles"), nil
+}
+
+func (s *effectivePermissionsAdapter) Get(ctx context.Context, scope string, query string, ignoreCache bool) (*sdp.Item, error) {
+	if err := s.checkScope(scope); err != nil {
+		return nil, err
+	}
+
+	subject, err := parseEffectivePermissionsQuery(query)
+	if err != nil {
+		return nil, &sdp.QueryError{
+			ErrorType:   sdp.QueryError_OTHER,
+			ErrorString: err.Error(),
+		}
+	}
+
+	roles, err := s.listRoles(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.permissions(ctx, subject, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.permissionsToItem(permissions)
+}
+
+func (s *effectivePermissionsAdapter) List(ctx context.Context, scope string, ignoreCache bool) ([]*sdp.Item, error) {
+	if err := s.checkScope(scope); err != nil {
+		return nil, err
+	}
+
+	roles, err := s.listRoles(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*sdp.Item, 0)
+
+	for _, kind := range []string{rbacv1.ServiceAccountKind, rbacv1.UserKind, rbacv1.GroupKind} {
+		subjects, err := s.index.Subjects(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, subject := range subjects {
+			permissions, err := s.permissions(ctx, subject, roles)
+			if err != nil {
+				return nil, err
+			}
+
+			item, err := s.permissionsToItem(permissions)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+func (s *effectivePermissionsAdapter) checkScope(scope string) error {
+	if !slices.Contains(s.Scopes(), scope) {
+		return &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOSCOPE,
+			ErrorString: fmt.Sprintf("scope %v is not served by this adapter", scope),
+		}
+	}
+
+	return nil
+}
+
+// rbacRoles The roles that bindings can refer to for a query. Roles are
+// listed the first time that a binding in their namespace needs them, so each
+// namespace is only listed once however many bindings there are in it
+type rbacRoles struct {
+	clusterRoles []*rbacv1.ClusterRole
+	roles        map[string][]*rbacv1.Role
+}
+
+// listRoles Lists the cluster roles, ready for the roles that the bindings
+// need to be looked up
+func (s *effectivePermissionsAdapter) listRoles(ctx context.Context, scope string) (*rbacRoles, error) {
+	clusterRoles, err := s.clusterRoles.listResources(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rbacRoles{
+		clusterRoles: clusterRoles,
+		roles:        make(map[string][]*rbacv1.Role),
+	}, nil
+}
+
+// role Returns a role from a namespace, or nil if it doesn't exist. Roles in
+// namespaces that aren't being discovered aren't looked up, so grant nothing
+func (s *effectivePermissionsAdapter) role(ctx context.Context, roles *rbacRoles, namespace string, name string) (*rbacv1.Role, error) {
+	if !slices.Contains(s.roles.currentNamespaces(), namespace) {
+		return nil, nil
+	}
+
+	namespaceRoles, ok := roles.roles[namespace]
+
+	if !ok {
+		var err error
+
+		namespaceRoles, err = s.roles.listResources(ctx, ScopeDetails{
+			ClusterName: s.ClusterName,
+			Namespace:   namespace,
+		}.String())
+		if err != nil {
+			return nil, err
+		}
+
+		roles.roles[namespace] = namespaceRoles
+	}
+
+	for _, role := range namespaceRoles {
+		if role.Name == name {
+			return role, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// permissions Works out the rules that apply to a subject from the bindings
+// that name it or one of its implicit groups
+func (s *effectivePermissionsAdapter) permissions(ctx context.Context, subject rbacSubject, roles *rbacRoles) (*effectivePermissions, error) {
+	permissions := &effectivePermissions{
+		Subject: subject,
+		Rules:   make([]grantedRule, 0),
+	}
+
+	for _, bound := range append([]rbacSubject{subject}, implicitGroups(subject)...) {
+		bindings, err := s.index.Bindings(ctx, bound)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, binding := range bindings {
+			rules, err := s.bindingRules(ctx, binding, roles)
+			if err != nil {
+				return nil, err
+			}
+
+			permissions.Rules = append(permissions.Rules, rules...)
+		}
+	}
+
+	return permissions, nil
+}
+
+// bindingRules Returns the rules that a binding grants. Bindings to roles that
+// don't exist grant nothing
+func (s *effectivePermissionsAdapter) bindingRules(ctx context.Context, binding rbacBinding, roles *rbacRoles) ([]grantedRule, error) {
+	namespace := binding.Namespace
+
+	if binding.Kind == "ClusterRoleBinding" {
+		namespace = allNamespaces
+	}
+
+	granted := make([]grantedRule, 0)
+
+	switch binding.RoleRef.Kind {
+	case "Role":
+		role, err := s.role(ctx, roles, binding.Namespace, binding.RoleRef.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if role == nil {
+			return granted, nil
+		}
+
+		for _, rule := range role.Rules {
+			granted = append(granted, grantedRule{
+				PolicyRule: rule,
+				Namespace:  namespace,
+				Binding:    binding,
+				Roles:      []rbacv1.RoleRef{binding.RoleRef},
+			})
+		}
+	case "ClusterRole":
+		for _, role := range roles.clusterRoles {
+			if role.Name != binding.RoleRef.Name {
+				continue
+			}
+
+			rules, sources := aggregatedClusterRoleRules(role, roles.clusterRoles)
+
+			for i, rule := range rules {
+				roles := []rbacv1.RoleRef{binding.RoleRef}
+
+				if sources[i] != role.Name {
+					roles = append(roles, rbacv1.RoleRef{
+						APIGroup: rbacv1.GroupName,
+						Kind:     "ClusterRole",
+						Name:     sources[i],
+					})
+				}
+
+				granted = append(granted, grantedRule{
+					PolicyRule: rule,
+					Namespace:  namespace,
+					Binding:    binding,
+					Roles:      roles,
+				})
+			}
+		}
+	}
+
+	return granted, nil
+}
+
+// effectivePermissionsRule How a rule is shown in the item attributes
+type effectivePermissionsRule struct {
+	Namespace       string   `json:"namespace"`
+	Verbs           []string `json:"verbs"`
+	APIGroups       []string `json:"apiGroups,omitempty"`
+	Resources       []string `json:"resources,omitempty"`
+	ResourceNames   []string `json:"resourceNames,omitempty"`
+	NonResourceURLs []string `json:"nonResourceURLs,omitempty"`
+	Binding         string   `json:"binding"`
+	Roles           []string `json:"roles"`
+}
+
+type effectivePermissionsAttributes struct {
+	Subject    string                     `json:"subject"`
+	Kind       string                     `json:"kind"`
+	Name       string                     `json:"name"`
+	Namespace  string                     `json:"namespace,omitempty"`
+	Verbs      []string                   `json:"verbs"`
+	Resources  []string                   `json:"resources"`
+	Namespaces []string                   `json:"namespaces"`
+	Dangerous  []string                   `json:"dangerous"`
+	Rules      []effectivePermissionsRule `json:"rules"`
+}
+
+func (s *effectivePermissionsAdapter) permissionsToItem(permissions *effectivePermissions) (*sdp.Item, error) {
+	attrs := effectivePermissionsAttributes{
+		Subject:    effectivePermissionsQuery(permissions.Subject),
+		Kind:       permissions.Subject.Kind,
+		Name:       permissions.Subject.Name,
+		Namespace:  permissions.Subject.Namespace,
+		Verbs:      permissions.Verbs(),
+		Resources:  permissions.Resources(),
+		Namespaces: permissions.Namespaces(),
+		Dangerous:  permissions.Dangerous(),
+		Rules:      make([]effectivePermissionsRule, 0, len(permissions.Rules)),
+	}
+
+	clusterScope := ScopeDetails{ClusterName: s.ClusterName}.String()
+
+	queries := make([]*sdp.LinkedItemQuery, 0)
+	seen := make(map[string]bool)
+
+	// The permissions come from the roles, through the bindings
+	addQuery := func(typ string, name string, scope string) {
+		key := typ + "/" + scope + "/" + name
+
+		if seen[key] {
+			return
+		}
+
+		seen[key] = true
+
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   typ,
+				Method: sdp.QueryMethod_GET,
+				Query:  name,
+				Scope:  scope,
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// Changes to the roles and bindings change the permissions
+				In: true,
+				// The permissions are worked out from them, so can't change
+				// them
+				Out: false,
+			},
+		})
+	}
+
+	for _, rule := range permissions.Rules {
+		bindingScope := ScopeDetails{ClusterName: s.ClusterName, Namespace: rule.Binding.Namespace}.String()
+		addQuery(rule.Binding.Kind, rule.Binding.Name, bindingScope)
+
+		roles := make([]string, 0, len(rule.Roles))
+
+		for _, role := range rule.Roles {
+			roles = append(roles, role.Kind+"/"+role.Name)
+
+			if role.Kind == "Role" {
+				addQuery(role.Kind, role.Name, bindingScope)
+			} else {
+				addQuery(role.Kind, role.Name, clusterScope)
+			}
+		}
+
+		attrs.Rules = append(attrs.Rules, effectivePermissionsRule{
+			Namespace:       rule.Namespace,
+			Verbs:           rule.Verbs,
+			APIGroups:       rule.APIGroups,
+			Resources:       rule.Resources,
+			ResourceNames:   rule.ResourceNames,
+			NonResourceURLs: rule.NonResourceURLs,
+			Binding:         rule.Binding.Kind + "/" + rule.Binding.Name,
+			Roles:           roles,
+		})
+	}
+
+	// Link to the subject that the permissions are for
+	subjectScope := ScopeDetails{ClusterName: s.ClusterName, Namespace: permissions.Subject.Namespace}.String()
+
+	queries = append(queries, &sdp.LinkedItemQuery{
+		Query: &sdp.Query{
+			Type:   permissions.Subject.Kind,
+			Method: sdp.QueryMethod_GET,
+			Query:  permissions.Subject.Name,
+			Scope:  subjectScope,
+		},
+		BlastPropagation: &sdp.BlastPropagation{
+			// The subject doesn't change the permissions
+			In: false,
+			// Changes to the permissions change what the subject can do
+			Out: true,
+		},
+	})
+
+	attributes, err := sdp.ToAttributesViaJson(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sdp.Item{
+		Type:              s.Type(),
+		UniqueAttribute:   "subject",
+		Scope:             clusterScope,
+		Attributes:        attributes,
+		LinkedItemQueries: queries,
+		Health:            effectivePermissionsHealth(permissions),
+	}, nil
+}
+
+func (s *effectivePermissionsAdapter) subjectIndex() *rbacSubjectIndex {
+	return s.index
+}
+
+// linkAdapters Uses the loaded Role and ClusterRole adapters, and the index of
+// the loaded binding adapters, rather than the adapter's own, so that they
+// share informers and follow namespace updates. Permissions can't be worked
+// out without all of them
+func (s *effectivePermissionsAdapter) linkAdapters(adapterList []discovery.Adapter) bool {
+	roles, ok := linkedAdapter[*KubeTypeAdapter[*rbacv1.Role, *rbacv1.RoleList]](adapterList, "Role")
+	if !ok {
+		return false
+	}
+
+	clusterRoles, ok := linkedAdapter[*KubeTypeAdapter[*rbacv1.ClusterRole, *rbacv1.ClusterRoleList]](adapterList, "ClusterRole")
+	if !ok {
+		return false
+	}
+
+	index, ok := linkedRBACSubjectIndex(adapterList)
+	if !ok {
+		return false
+	}
+
+	s.roles = roles
+	s.clusterRoles = clusterRoles
+	s.index = index
+
+	return true
+}
+
+func newEffectivePermissionsAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &effectivePermissionsAdapter{
+		ClusterName: cluster,
+		index: newRBACSubjectIndex(
+			newRoleBindingAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*rbacv1.RoleBinding, *rbacv1.RoleBindingList]),
+			newClusterRoleBindingAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*rbacv1.ClusterRoleBinding, *rbacv1.ClusterRoleBindingList]),
+		),
+		roles:        newRoleAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*rbacv1.Role, *rbacv1.RoleList]),
+		clusterRoles: newClusterRoleAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*rbacv1.ClusterRole, *rbacv1.ClusterRoleList]),
+	}
+}
+
+var effectivePermissionsAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:            "EffectivePermissions",
+	DescriptiveName: "Effective Permissions",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks: []string{
+		"ServiceAccount",
+		"User",
+		"Group",
+		"RoleBinding",
+		"ClusterRoleBinding",
+		"Role",
+		"ClusterRole",
+	},
+	SupportedQueryMethods: &sdp.AdapterSupportedQueryMethods{
+		Get:             true,
+		GetDescription:  "Get the Effective Permissions of a subject e.g. ServiceAccount/{namespace}/{name}, User/{name} or Group/{name}",
+		List:            true,
+		ListDescription: "List the Effective Permissions of every subject that is named by a RoleBinding or ClusterRoleBinding",
+	},
+})
+
+func init() {
+	registerAdapterLoader(newEffectivePermissionsAdapter)
+}
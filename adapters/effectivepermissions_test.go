@@ -0,0 +1,434 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newEffectivePermissionsClient() *fake.Clientset {
+	return fake.NewClientset(
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "team-a"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list"}},
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "monitoring"},
+			AggregationRule: &rbacv1.AggregationRule{
+				ClusterRoleSelectors: []metav1.LabelSelector{
+					{MatchLabels: map[string]string{"aggregate-to-monitoring": "true"}},
+				},
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "monitoring-pods",
+				Labels: map[string]string{"aggregate-to-monitoring": "true"},
+			},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list", "watch"}},
+				{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "discovery"},
+			Rules: []rbacv1.PolicyRule{
+				{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-reader", Namespace: "team-a"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "reader"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "app", Namespace: "team-a"},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing-role", Namespace: "team-a"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "deleted"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "app", Namespace: "team-a"},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "monitoring"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "monitoring"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.UserKind, Name: "alice"},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "discovery"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "discovery"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.GroupKind, Name: authenticatedGroup},
+			},
+		},
+	)
+}
+
+func TestParseEffectivePermissionsQuery(t *testing.T) {
+	valid := map[string]rbacSubject{
+		"ServiceAccount/team-a/app": {Kind: rbacv1.ServiceAccountKind, Namespace: "team-a", Name: "app"},
+		"User/alice":                {Kind: rbacv1.UserKind, Name: "alice"},
+		"Group/system:masters":      {Kind: rbacv1.GroupKind, Name: "system:masters"},
+	}
+
+	for query, expected := range valid {
+		subject, err := parseEffectivePermissionsQuery(query)
+
+		if err != nil {
+			t.Errorf("expected %v to be valid, got %v", query, err)
+		}
+
+		if subject != expected {
+			t.Errorf("expected %+v, got %+v", expected, subject)
+		}
+
+		if effectivePermissionsQuery(subject) != query {
+			t.Errorf("expected query %v, got %v", query, effectivePermissionsQuery(subject))
+		}
+	}
+
+	for _, query := range []string{"", "alice", "User/", "ServiceAccount/app", "Pod/team-a/app"} {
+		if _, err := parseEffectivePermissionsQuery(query); err == nil {
+			t.Errorf("expected %q to be invalid", query)
+		}
+	}
+}
+
+func TestAggregatedClusterRoleRules(t *testing.T) {
+	parent := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "parent"},
+		AggregationRule: &rbacv1.AggregationRule{
+			ClusterRoleSelectors: []metav1.LabelSelector{
+				{MatchLabels: map[string]string{"aggregate-to-parent": "true"}},
+			},
+		},
+	}
+
+	child := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "child",
+			Labels: map[string]string{
+				"aggregate-to-parent": "true",
+				// The child aggregates its parent, which mustn't loop forever
+				"aggregate-to-child": "true",
+			},
+		},
+		AggregationRule: &rbacv1.AggregationRule{
+			ClusterRoleSelectors: []metav1.LabelSelector{
+				{MatchLabels: map[string]string{"aggregate-to-child": "true"}},
+			},
+		},
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"services"}, Verbs: []string{"get"}},
+		},
+	}
+
+	unrelated := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "unrelated"},
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+		},
+	}
+
+	rules, sources := aggregatedClusterRoleRules(parent, []*rbacv1.ClusterRole{parent, child, unrelated})
+
+	if len(rules) != 1 || rules[0].Resources[0] != "services" {
+		t.Fatalf("expected only the child's rule, got %v", rules)
+	}
+
+	if !slices.Equal(sources, []string{"child"}) {
+		t.Errorf("expected the rule to come from child, got %v", sources)
+	}
+}
+
+func TestEffectivePermissionsDangerous(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      rbacv1.PolicyRule
+		dangerous []string
+	}{
+		{
+			name: "read configmaps",
+			rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list"}},
+		},
+		{
+			name:      "read secrets",
+			rule:      rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"list"}},
+			dangerous: []string{"read secrets in team-a"},
+		},
+		{
+			name: "read secrets in another group",
+			rule: rbacv1.PolicyRule{APIGroups: []string{"example.com"}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+		},
+		{
+			name: "create secrets",
+			rule: rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"create"}},
+		},
+		{
+			name:      "exec into pods",
+			rule:      rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+			dangerous: []string{"exec into pods in team-a"},
+		},
+		{
+			name:      "impersonate",
+			rule:      rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"users"}, Verbs: []string{"impersonate"}},
+			dangerous: []string{"impersonate in team-a"},
+		},
+		{
+			name: "everything",
+			rule: rbacv1.PolicyRule{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+			dangerous: []string{
+				"all verbs on * in team-a",
+				"exec into pods in team-a",
+				"read secrets in team-a",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions := &effectivePermissions{
+				Rules: []grantedRule{
+					{PolicyRule: tt.rule, Namespace: "team-a"},
+				},
+			}
+
+			dangerous := permissions.Dangerous()
+
+			if !slices.Equal(dangerous, tt.dangerous) && (len(dangerous) > 0 || len(tt.dangerous) > 0) {
+				t.Errorf("expected %v, got %v", tt.dangerous, dangerous)
+			}
+
+			expectedHealth := sdp.Health_HEALTH_OK
+
+			if len(tt.dangerous) > 0 {
+				expectedHealth = sdp.Health_HEALTH_WARNING
+			}
+
+			if health := effectivePermissionsHealth(permissions); health.String() != expectedHealth.String() {
+				t.Errorf("expected health %v, got %v", expectedHealth, health)
+			}
+		})
+	}
+}
+
+func TestEffectivePermissionsAdapter(t *testing.T) {
+	ctx := context.Background()
+	adapter := newEffectivePermissionsAdapter(newEffectivePermissionsClient(), "test-cluster", []string{"team-a"})
+
+	t.Run("Get a service account", func(t *testing.T) {
+		item, err := adapter.Get(ctx, "test-cluster", "ServiceAccount/team-a/app", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.UniqueAttributeValue() != "ServiceAccount/team-a/app" {
+			t.Errorf("expected unique attribute ServiceAccount/team-a/app, got %v", item.UniqueAttributeValue())
+		}
+
+		namespaces, err := item.GetAttributes().Get("namespaces")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The configmaps come from the Role in team-a, and /healthz from the
+		// system:authenticated group, which service accounts are in
+		if !slices.Equal(namespaces.([]interface{}), []interface{}{"*", "team-a"}) {
+			t.Errorf("expected namespaces [* team-a], got %v", namespaces)
+		}
+
+		resources, err := item.GetAttributes().Get("resources")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(resources.([]interface{}), []interface{}{"/healthz", "configmaps"}) {
+			t.Errorf("expected resources [/healthz configmaps], got %v", resources)
+		}
+
+		if item.GetHealth() != sdp.Health_HEALTH_OK {
+			t.Errorf("expected health OK, got %v", item.GetHealth())
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "RoleBinding",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "app-reader",
+				ExpectedScope:  "test-cluster.team-a",
+			},
+			{
+				ExpectedType:   "Role",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "reader",
+				ExpectedScope:  "test-cluster.team-a",
+			},
+			{
+				ExpectedType:   "ClusterRoleBinding",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "discovery",
+				ExpectedScope:  "test-cluster",
+			},
+			{
+				ExpectedType:   "ServiceAccount",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "app",
+				ExpectedScope:  "test-cluster.team-a",
+			},
+		}.Execute(t, item)
+	})
+
+	t.Run("Get a user with an aggregated role", func(t *testing.T) {
+		item, err := adapter.Get(ctx, "test-cluster", "User/alice", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dangerous, err := item.GetAttributes().Get("dangerous")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(dangerous.([]interface{}), []interface{}{"exec into pods in all namespaces"}) {
+			t.Errorf("expected exec into pods to be dangerous, got %v", dangerous)
+		}
+
+		if item.GetHealth() != sdp.Health_HEALTH_WARNING {
+			t.Errorf("expected health WARNING, got %v", item.GetHealth())
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "ClusterRole",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "monitoring",
+				ExpectedScope:  "test-cluster",
+			},
+			{
+				ExpectedType:   "ClusterRole",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "monitoring-pods",
+				ExpectedScope:  "test-cluster",
+			},
+			{
+				ExpectedType:   "User",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "alice",
+				ExpectedScope:  "test-cluster",
+			},
+		}.Execute(t, item)
+	})
+
+	t.Run("Get with a bad query", func(t *testing.T) {
+		if _, err := adapter.Get(ctx, "test-cluster", "alice", false); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		items, err := adapter.List(ctx, "test-cluster", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		subjects := make([]string, len(items))
+
+		for i, item := range items {
+			subjects[i] = item.UniqueAttributeValue()
+		}
+
+		expected := []string{"ServiceAccount/team-a/app", "User/alice", "Group/system:authenticated"}
+
+		if !slices.Equal(subjects, expected) {
+			t.Errorf("expected %v, got %v", expected, subjects)
+		}
+	})
+
+	t.Run("Bad scope", func(t *testing.T) {
+		if _, err := adapter.List(ctx, "test-cluster.team-a", false); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+}
+
+func TestEffectivePermissionsLinkAdapters(t *testing.T) {
+	ctx := context.Background()
+	cs := newEffectivePermissionsClient()
+
+	var adapter *effectivePermissionsAdapter
+	var roles *KubeTypeAdapter[*rbacv1.Role, *rbacv1.RoleList]
+
+	for _, a := range LoadAllAdapters(cs, "test-cluster", []string{"team-a"}, TypeFilter{}) {
+		switch a := a.(type) {
+		case *effectivePermissionsAdapter:
+			adapter = a
+		case *KubeTypeAdapter[*rbacv1.Role, *rbacv1.RoleList]:
+			roles = a
+		}
+	}
+
+	if adapter == nil || roles == nil {
+		t.Fatal("expected EffectivePermissions and Role adapters to be loaded")
+	}
+
+	if adapter.roles != roles {
+		t.Fatal("expected EffectivePermissions to use the loaded Role adapter")
+	}
+
+	t.Run("roles are listed once per namespace", func(t *testing.T) {
+		cs.ClearActions()
+
+		if _, err := adapter.Get(ctx, "test-cluster", "ServiceAccount/team-a/app", false); err != nil {
+			t.Fatal(err)
+		}
+
+		var lists int
+
+		for _, action := range cs.Actions() {
+			if action.Matches("list", "roles") {
+				lists++
+			}
+		}
+
+		// The service account has two bindings to roles in team-a
+		if lists != 1 {
+			t.Errorf("expected roles to be listed once, got %v", lists)
+		}
+	})
+
+	t.Run("roles follow namespace updates", func(t *testing.T) {
+		roles.RemoveNamespace("team-a")
+
+		item, err := adapter.Get(ctx, "test-cluster", "ServiceAccount/team-a/app", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resources, err := item.GetAttributes().Get("resources")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(resources.([]interface{}), []interface{}{"/healthz"}) {
+			t.Errorf("expected resources [/healthz], got %v", resources)
+		}
+	})
+}
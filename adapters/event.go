@@ -0,0 +1,126 @@
+package adapters
+
+import (
+	"time"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/events/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+func eventExtractor(resource *v1.Event, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	sd, err := ParseScope(scope, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Events are only a record of what happened to the objects, they don't
+	// change them
+	blastProp := &sdp.BlastPropagation{
+		In:  false,
+		Out: false,
+	}
+
+	for _, ref := range []*corev1.ObjectReference{&resource.Regarding, resource.Related} {
+		if ref == nil || ref.Kind == "" || ref.Name == "" {
+			continue
+		}
+
+		queries = append(queries, ObjectReferenceToQuery(ref, sd, blastProp))
+	}
+
+	return queries, nil
+}
+
+// eventHealthExtractor Warning events are reported when something has gone
+// wrong, e.g. failed scheduling or a crashing container
+func eventHealthExtractor(resource *v1.Event) *sdp.Health {
+	switch resource.Type {
+	case corev1.EventTypeWarning:
+		return sdp.Health_HEALTH_ERROR.Enum()
+	case corev1.EventTypeNormal:
+		return sdp.Health_HEALTH_OK.Enum()
+	default:
+		return nil
+	}
+}
+
+// eventSelectableFields The fields that the API server supports in field
+// selectors for events
+func eventSelectableFields(resource *v1.Event) fields.Set {
+	return fields.Set{
+		"regarding.kind":            resource.Regarding.Kind,
+		"regarding.namespace":       resource.Regarding.Namespace,
+		"regarding.name":            resource.Regarding.Name,
+		"regarding.uid":             string(resource.Regarding.UID),
+		"regarding.apiVersion":      resource.Regarding.APIVersion,
+		"regarding.resourceVersion": resource.Regarding.ResourceVersion,
+		"regarding.fieldPath":       resource.Regarding.FieldPath,
+		"reason":                    resource.Reason,
+		"reportingController":       resource.ReportingController,
+		"type":                      resource.Type,
+	}
+}
+
+func newEventAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &KubeTypeAdapter[*v1.Event, *v1.EventList]{
+		ClusterName:   cluster,
+		Namespaces:    namespaces,
+		TypeName:      "Event",
+		CacheDuration: 1 * time.Minute, // very low since new events are reported all the time
+		NamespacedInterfaceBuilder: func(namespace string) ItemInterface[*v1.Event, *v1.EventList] {
+			return cs.EventsV1().Events(namespace)
+		},
+		ListExtractor: func(list *v1.EventList) ([]*v1.Event, error) {
+			extracted := make([]*v1.Event, len(list.Items))
+
+			for i := range list.Items {
+				extracted[i] = &list.Items[i]
+			}
+
+			return extracted, nil
+		},
+		LinkedItemQueryExtractor: eventExtractor,
+		HealthExtractor:          eventHealthExtractor,
+		SelectableFields:         eventSelectableFields,
+		AdapterMetadata:          eventAdapterMetadata,
+	}
+}
+
+var eventAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:            "Event",
+	DescriptiveName: "Event",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_OBSERVABILITY,
+	PotentialLinks: []string{
+		"Pod",
+		"Node",
+		"Deployment",
+		"ReplicaSet",
+		"StatefulSet",
+		"DaemonSet",
+		"Job",
+		"CronJob",
+		"Service",
+		"PersistentVolumeClaim",
+		"PersistentVolume",
+		"HorizontalPodAutoscaler",
+	},
+	SupportedQueryMethods: &sdp.AdapterSupportedQueryMethods{
+		Get:               true,
+		GetDescription:    "Get an Event by name",
+		List:              true,
+		ListDescription:   "List all Events",
+		Search:            true,
+		SearchDescription: `Search for Events using the ListOptions JSON format. Events can be found by the object they are about, their reason and their type using a field selector e.g. {"fieldSelector": "regarding.kind=Pod,regarding.name=wordpress-0,reason=BackOff,type=Warning"}`,
+	},
+})
+
+func init() {
+	registerAdapterLoader(newEventAdapter)
+}
@@ -0,0 +1,95 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/events/v1"
+)
+
+var eventYAML = `
+apiVersion: events.k8s.io/v1
+kind: Event
+metadata:
+  name: event-test
+eventTime: "2024-01-01T00:00:00.000000Z"
+reportingController: example.com/test-controller
+reportingInstance: test-controller-0
+action: Pulling
+reason: BackOff
+type: Warning
+note: Back-off pulling image "nginx:does-not-exist"
+regarding:
+  apiVersion: v1
+  kind: Pod
+  name: event-test-pod
+  namespace: default
+related:
+  apiVersion: v1
+  kind: Node
+  name: local-tests-control-plane
+`
+
+func TestEventAdapter(t *testing.T) {
+	sd := ScopeDetails{
+		ClusterName: CurrentCluster.Name,
+		Namespace:   "default",
+	}
+
+	adapter := newEventAdapter(CurrentCluster.ClientSet, sd.ClusterName, []string{sd.Namespace})
+
+	st := AdapterTests{
+		Adapter:   adapter,
+		GetQuery:  "event-test",
+		GetScope:  sd.String(),
+		SetupYAML: eventYAML,
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "Pod",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "event-test-pod",
+				ExpectedScope:  sd.String(),
+			},
+			{
+				ExpectedType:   "Node",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "local-tests-control-plane",
+				ExpectedScope:  sd.ClusterName,
+			},
+		},
+	}
+
+	st.Execute(t)
+
+	t.Run("search by involved object", func(t *testing.T) {
+		items, err := adapter.Search(context.Background(), sd.String(), `{"fieldSelector":"regarding.kind=Pod,regarding.name=event-test-pod,type=Warning"}`, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 || items[0].UniqueAttributeValue() != "event-test" {
+			t.Errorf("expected only event-test, got %v items", len(items))
+		}
+	})
+}
+
+func TestEventHealthExtractor(t *testing.T) {
+	HealthTests[*v1.Event]{
+		{
+			Name:           "warning",
+			Resource:       &v1.Event{Type: corev1.EventTypeWarning},
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name:           "normal",
+			Resource:       &v1.Event{Type: corev1.EventTypeNormal},
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:     "no type",
+			Resource: &v1.Event{},
+		},
+	}.Execute(t, eventHealthExtractor)
+}
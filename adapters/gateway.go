@@ -0,0 +1,524 @@
+package adapters
+
+import (
+	"strings"
+
+	"github.com/overmindtech/sdp-go"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GatewayAPIGroup The API group of the Gateway API. These types are installed
+// as CRDs, so are discovered by the CustomResourceManager and the links and
+// health below are added to its adapters. This means that they are only
+// discovered with `--discover-custom-resources`, which has no effect when the
+// namespaces are set in the config since CRDs are cluster-wide
+const GatewayAPIGroup = "gateway.networking.k8s.io"
+
+// gatewayObjectReference A reference to another object as used throughout the
+// Gateway API, e.g. parentRefs and backendRefs. Group, kind and namespace are
+// defaulted differently depending on where the reference is
+type gatewayObjectReference struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+type gatewayAddress struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type gatewayCondition struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+}
+
+type gatewayClass struct {
+	Spec struct {
+		ParametersRef *gatewayObjectReference `json:"parametersRef"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []gatewayCondition `json:"conditions"`
+	} `json:"status"`
+}
+
+type gateway struct {
+	Spec struct {
+		GatewayClassName string `json:"gatewayClassName"`
+		Listeners        []struct {
+			Hostname string `json:"hostname"`
+			TLS      *struct {
+				CertificateRefs []gatewayObjectReference `json:"certificateRefs"`
+			} `json:"tls"`
+		} `json:"listeners"`
+		Addresses []gatewayAddress `json:"addresses"`
+	} `json:"spec"`
+	Status struct {
+		Addresses  []gatewayAddress   `json:"addresses"`
+		Conditions []gatewayCondition `json:"conditions"`
+	} `json:"status"`
+}
+
+// gatewayRoute The parts of HTTPRoute, GRPCRoute and TCPRoute that we need,
+// these all share the same structure for parents and backends
+type gatewayRoute struct {
+	Spec struct {
+		ParentRefs []gatewayObjectReference `json:"parentRefs"`
+		Hostnames  []string                 `json:"hostnames"`
+		Rules      []struct {
+			BackendRefs []gatewayObjectReference `json:"backendRefs"`
+		} `json:"rules"`
+	} `json:"spec"`
+	Status struct {
+		Parents []struct {
+			Conditions []gatewayCondition `json:"conditions"`
+		} `json:"parents"`
+	} `json:"status"`
+}
+
+type referenceGrant struct {
+	Spec struct {
+		From []gatewayObjectReference `json:"from"`
+		To   []gatewayObjectReference `json:"to"`
+	} `json:"spec"`
+}
+
+// fromUnstructured Converts a custom resource into one of the types above,
+// fields that we don't use are ignored
+func fromUnstructured[T any](resource *unstructured.Unstructured) (*T, error) {
+	obj := new(T)
+
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(resource.Object, obj)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// gatewayReferenceQuery Creates a GET query for a reference, filling in the
+// kind and namespace from the referencing object if they aren't set. If the
+// scope has no namespace the object is cluster-wide. References whose kind
+// can't be worked out have no type to link to, so nil is returned
+func gatewayReferenceQuery(ref gatewayObjectReference, defaultKind string, sd ScopeDetails, blastProp *sdp.BlastPropagation) *sdp.LinkedItemQuery {
+	if ref.Kind != "" {
+		defaultKind = ref.Kind
+	}
+
+	if defaultKind == "" || ref.Name == "" {
+		return nil
+	}
+
+	if ref.Namespace != "" {
+		sd.Namespace = ref.Namespace
+	}
+
+	return &sdp.LinkedItemQuery{
+		Query: &sdp.Query{
+			Type:   defaultKind,
+			Method: sdp.QueryMethod_GET,
+			Query:  ref.Name,
+			Scope:  sd.String(),
+		},
+		BlastPropagation: blastProp,
+	}
+}
+
+// gatewayHostnameQuery Links a hostname to DNS. Wildcard hostnames such as
+// `*.example.com` match many names so can't be looked up, and are skipped
+func gatewayHostnameQuery(hostname string) *sdp.LinkedItemQuery {
+	if hostname == "" || strings.HasPrefix(hostname, "*") {
+		return nil
+	}
+
+	return &sdp.LinkedItemQuery{
+		Query: &sdp.Query{
+			Type:   "dns",
+			Method: sdp.QueryMethod_SEARCH,
+			Query:  hostname,
+			Scope:  "global",
+		},
+		BlastPropagation: &sdp.BlastPropagation{
+			// DNS is always bidirectional
+			In:  true,
+			Out: true,
+		},
+	}
+}
+
+// gatewayConditionStatus Returns the status of a condition, or blank if it
+// hasn't been reported
+func gatewayConditionStatus(conditions []gatewayCondition, conditionType string) string {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return condition.Status
+		}
+	}
+
+	return ""
+}
+
+func gatewayClassExtractor(resource *unstructured.Unstructured, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	class, err := fromUnstructured[gatewayClass](resource)
+	if err != nil {
+		return nil, err
+	}
+
+	sd, err := ParseScope(scope, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if ref := class.Spec.ParametersRef; ref != nil {
+		// The kind is required by the API, but there is no default to fall
+		// back on if it's missing
+		query := gatewayReferenceQuery(*ref, "", sd, &sdp.BlastPropagation{
+			// The parameters configure the controller for every gateway of
+			// this class
+			In: true,
+			// Changing the class won't affect its parameters
+			Out: false,
+		})
+
+		if query != nil {
+			queries = append(queries, query)
+		}
+	}
+
+	return queries, nil
+}
+
+// gatewayClassHealthExtractor Classes that haven't been accepted by their
+// controller can't be used by any gateways
+func gatewayClassHealthExtractor(resource *unstructured.Unstructured) *sdp.Health {
+	class, err := fromUnstructured[gatewayClass](resource)
+	if err != nil {
+		return sdp.Health_HEALTH_UNKNOWN.Enum()
+	}
+
+	switch gatewayConditionStatus(class.Status.Conditions, "Accepted") {
+	case "True":
+		return sdp.Health_HEALTH_OK.Enum()
+	case "False":
+		return sdp.Health_HEALTH_ERROR.Enum()
+	default:
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+}
+
+func gatewayExtractor(resource *unstructured.Unstructured, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	gw, err := fromUnstructured[gateway](resource)
+	if err != nil {
+		return nil, err
+	}
+
+	sd, err := ParseScope(scope, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if gw.Spec.GatewayClassName != "" {
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "GatewayClass",
+				Method: sdp.QueryMethod_GET,
+				Query:  gw.Spec.GatewayClassName,
+				// Gateway classes are cluster-wide
+				Scope: sd.ClusterName,
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// Changes to the class can affect the gateways that use it
+				In: true,
+				// Changes to a gateway won't affect the class
+				Out: false,
+			},
+		})
+	}
+
+	for _, listener := range gw.Spec.Listeners {
+		if query := gatewayHostnameQuery(listener.Hostname); query != nil {
+			queries = append(queries, query)
+		}
+
+		if listener.TLS == nil {
+			continue
+		}
+
+		for _, ref := range listener.TLS.CertificateRefs {
+			if query := gatewayReferenceQuery(ref, "Secret", sd, &sdp.BlastPropagation{
+				// An invalid or expired certificate will break the listener
+				In: true,
+				// Changing the gateway won't affect the certificate
+				Out: false,
+			}); query != nil {
+				queries = append(queries, query)
+			}
+		}
+	}
+
+	// The requested addresses and the ones that were actually assigned are
+	// usually the same, so only link each once
+	seen := make(map[gatewayAddress]bool)
+
+	for _, address := range append(gw.Spec.Addresses, gw.Status.Addresses...) {
+		if seen[address] {
+			continue
+		}
+
+		seen[address] = true
+
+		switch address.Type {
+		case "", "IPAddress":
+			queries = append(queries, &sdp.LinkedItemQuery{
+				Query: &sdp.Query{
+					Type:   "ip",
+					Method: sdp.QueryMethod_GET,
+					Query:  address.Value,
+					Scope:  "global",
+				},
+				BlastPropagation: &sdp.BlastPropagation{
+					// IPs are always bidirectional
+					In:  true,
+					Out: true,
+				},
+			})
+		case "Hostname":
+			if query := gatewayHostnameQuery(address.Value); query != nil {
+				queries = append(queries, query)
+			}
+		}
+	}
+
+	return queries, nil
+}
+
+// gatewayHealthExtractor Gateways need to be accepted by their controller and
+// then programmed into the data plane before they will serve traffic
+func gatewayHealthExtractor(resource *unstructured.Unstructured) *sdp.Health {
+	gw, err := fromUnstructured[gateway](resource)
+	if err != nil {
+		return sdp.Health_HEALTH_UNKNOWN.Enum()
+	}
+
+	if gatewayConditionStatus(gw.Status.Conditions, "Accepted") == "False" {
+		return sdp.Health_HEALTH_ERROR.Enum()
+	}
+
+	switch gatewayConditionStatus(gw.Status.Conditions, "Programmed") {
+	case "True":
+		return sdp.Health_HEALTH_OK.Enum()
+	case "False":
+		return sdp.Health_HEALTH_ERROR.Enum()
+	default:
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+}
+
+func gatewayRouteExtractor(resource *unstructured.Unstructured, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	route, err := fromUnstructured[gatewayRoute](resource)
+	if err != nil {
+		return nil, err
+	}
+
+	sd, err := ParseScope(scope, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range route.Spec.ParentRefs {
+		if query := gatewayReferenceQuery(ref, "Gateway", sd, &sdp.BlastPropagation{
+			// Changes to the gateway affect all of its routes
+			In: true,
+			// Changes to a route change the traffic through the gateway
+			Out: true,
+		}); query != nil {
+			queries = append(queries, query)
+		}
+	}
+
+	for _, hostname := range route.Spec.Hostnames {
+		if query := gatewayHostnameQuery(hostname); query != nil {
+			queries = append(queries, query)
+		}
+	}
+
+	for _, rule := range route.Spec.Rules {
+		for _, ref := range rule.BackendRefs {
+			if query := gatewayReferenceQuery(ref, "Service", sd, &sdp.BlastPropagation{
+				// Changes to the service affects the route's endpoints
+				In: true,
+				// Changing a route does not affect the service
+				Out: false,
+			}); query != nil {
+				queries = append(queries, query)
+			}
+		}
+	}
+
+	return queries, nil
+}
+
+// gatewayRouteHealthExtractor Each parent gateway reports whether it has
+// accepted the route, and whether all of the route's backends could be found
+func gatewayRouteHealthExtractor(resource *unstructured.Unstructured) *sdp.Health {
+	route, err := fromUnstructured[gatewayRoute](resource)
+	if err != nil {
+		return sdp.Health_HEALTH_UNKNOWN.Enum()
+	}
+
+	if len(route.Status.Parents) == 0 {
+		// No gateway has processed the route yet
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	health := sdp.Health_HEALTH_OK
+
+	for _, parent := range route.Status.Parents {
+		if gatewayConditionStatus(parent.Conditions, "Accepted") == "False" {
+			return sdp.Health_HEALTH_ERROR.Enum()
+		}
+
+		// Some backends are missing or not allowed, so requests to them fail
+		if gatewayConditionStatus(parent.Conditions, "ResolvedRefs") == "False" {
+			health = sdp.Health_HEALTH_WARNING
+		}
+	}
+
+	return health.Enum()
+}
+
+func referenceGrantExtractor(resource *unstructured.Unstructured, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	grant, err := fromUnstructured[referenceGrant](resource)
+	if err != nil {
+		return nil, err
+	}
+
+	sd, err := ParseScope(scope, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, from := range grant.Spec.From {
+		fromScope := sd
+		fromScope.Namespace = from.Namespace
+
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   from.Kind,
+				Method: sdp.QueryMethod_LIST,
+				Scope:  fromScope.String(),
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// Routes don't affect the grant
+				In: false,
+				// Removing the grant breaks any routes that rely on it
+				Out: true,
+			},
+		})
+	}
+
+	for _, to := range grant.Spec.To {
+		// The objects are always in the same namespace as the grant. If there
+		// is no name then the grant covers all objects of that kind
+		query := &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   to.Kind,
+				Method: sdp.QueryMethod_LIST,
+				Scope:  sd.String(),
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// The grant only controls who can reference the objects, it
+				// doesn't change them, and they don't change the grant
+				In:  false,
+				Out: false,
+			},
+		}
+
+		if to.Name != "" {
+			query.Query.Method = sdp.QueryMethod_GET
+			query.Query.Query = to.Name
+		}
+
+		queries = append(queries, query)
+	}
+
+	return queries, nil
+}
+
+// gatewayRouteMetadata Creates the metadata for one of the route types, which
+// all link to the same things
+func gatewayRouteMetadata(itemType string, descriptiveName string) *sdp.AdapterMetadata {
+	return Metadata.Register(&sdp.AdapterMetadata{
+		Type:                  itemType,
+		DescriptiveName:       descriptiveName,
+		Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_NETWORK,
+		PotentialLinks:        []string{"Gateway", "Service", "dns"},
+		SupportedQueryMethods: DefaultSupportedQueryMethods(descriptiveName),
+	})
+}
+
+var gatewayClassAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:                  "GatewayClass",
+	DescriptiveName:       "Gateway Class",
+	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_NETWORK,
+	SupportedQueryMethods: DefaultSupportedQueryMethods("Gateway Class"),
+})
+
+var gatewayAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:                  "Gateway",
+	DescriptiveName:       "Gateway",
+	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_NETWORK,
+	PotentialLinks:        []string{"GatewayClass", "Secret", "dns", "ip"},
+	SupportedQueryMethods: DefaultSupportedQueryMethods("Gateway"),
+})
+
+var referenceGrantAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:                  "ReferenceGrant",
+	DescriptiveName:       "Reference Grant",
+	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks:        []string{"HTTPRoute", "GRPCRoute", "TCPRoute", "Gateway", "Service", "Secret"},
+	SupportedQueryMethods: DefaultSupportedQueryMethods("Reference Grant"),
+})
+
+func init() {
+	registerKnownCustomResource(schema.GroupKind{Group: GatewayAPIGroup, Kind: "GatewayClass"}, knownCustomResource{
+		LinkedItemQueryExtractor: gatewayClassExtractor,
+		HealthExtractor:          gatewayClassHealthExtractor,
+		AdapterMetadata:          gatewayClassAdapterMetadata,
+	})
+
+	registerKnownCustomResource(schema.GroupKind{Group: GatewayAPIGroup, Kind: "Gateway"}, knownCustomResource{
+		LinkedItemQueryExtractor: gatewayExtractor,
+		HealthExtractor:          gatewayHealthExtractor,
+		AdapterMetadata:          gatewayAdapterMetadata,
+	})
+
+	for _, route := range []struct{ Kind, Name string }{
+		{"HTTPRoute", "HTTP Route"},
+		{"GRPCRoute", "gRPC Route"},
+		{"TCPRoute", "TCP Route"},
+	} {
+		registerKnownCustomResource(schema.GroupKind{Group: GatewayAPIGroup, Kind: route.Kind}, knownCustomResource{
+			LinkedItemQueryExtractor: gatewayRouteExtractor,
+			HealthExtractor:          gatewayRouteHealthExtractor,
+			AdapterMetadata:          gatewayRouteMetadata(route.Kind, route.Name),
+		})
+	}
+
+	registerKnownCustomResource(schema.GroupKind{Group: GatewayAPIGroup, Kind: "ReferenceGrant"}, knownCustomResource{
+		LinkedItemQueryExtractor: referenceGrantExtractor,
+		AdapterMetadata:          referenceGrantAdapterMetadata,
+	})
+}
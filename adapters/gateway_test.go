@@ -0,0 +1,371 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGatewayExtractor(t *testing.T) {
+	gw := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "Gateway",
+			"metadata": map[string]interface{}{
+				"name":      "external",
+				"namespace": "infra",
+			},
+			"spec": map[string]interface{}{
+				"gatewayClassName": "istio",
+				"listeners": []interface{}{
+					map[string]interface{}{
+						"name":     "https",
+						"hostname": "shop.example.com",
+						"tls": map[string]interface{}{
+							"certificateRefs": []interface{}{
+								map[string]interface{}{
+									"name":      "shop-cert",
+									"namespace": "certs",
+								},
+							},
+						},
+					},
+					map[string]interface{}{
+						"name":     "wildcard",
+						"hostname": "*.example.com",
+					},
+				},
+			},
+			"status": map[string]interface{}{
+				"addresses": []interface{}{
+					map[string]interface{}{
+						"type":  "IPAddress",
+						"value": "203.0.113.10",
+					},
+					map[string]interface{}{
+						"type":  "Hostname",
+						"value": "lb.example.com",
+					},
+				},
+			},
+		},
+	}
+
+	queries, err := gatewayExtractor(gw, "test.infra")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	QueryTests{
+		{
+			ExpectedType:   "GatewayClass",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "istio",
+			ExpectedScope:  "test",
+		},
+		{
+			ExpectedType:   "Secret",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "shop-cert",
+			ExpectedScope:  "test.certs",
+		},
+		{
+			ExpectedType:   "dns",
+			ExpectedMethod: sdp.QueryMethod_SEARCH,
+			ExpectedQuery:  "shop.example.com",
+			ExpectedScope:  "global",
+		},
+		{
+			ExpectedType:   "ip",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "203.0.113.10",
+			ExpectedScope:  "global",
+		},
+		{
+			ExpectedType:   "dns",
+			ExpectedMethod: sdp.QueryMethod_SEARCH,
+			ExpectedQuery:  "lb.example.com",
+			ExpectedScope:  "global",
+		},
+	}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+
+	for _, query := range queries {
+		if query.GetQuery().GetQuery() == "*.example.com" {
+			t.Error("expected wildcard hostname to be skipped")
+		}
+	}
+}
+
+func TestGatewayClassExtractor(t *testing.T) {
+	class := func(parametersRef map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "gateway.networking.k8s.io/v1",
+				"kind":       "GatewayClass",
+				"metadata": map[string]interface{}{
+					"name": "istio",
+				},
+				"spec": map[string]interface{}{
+					"controllerName": "istio.io/gateway-controller",
+					"parametersRef":  parametersRef,
+				},
+			},
+		}
+	}
+
+	queries, err := gatewayClassExtractor(class(map[string]interface{}{
+		"group":     "",
+		"kind":      "ConfigMap",
+		"name":      "istio-options",
+		"namespace": "istio-system",
+	}), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	QueryTests{
+		{
+			ExpectedType:   "ConfigMap",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "istio-options",
+			ExpectedScope:  "test.istio-system",
+		},
+	}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+
+	t.Run("reference without a kind", func(t *testing.T) {
+		queries, err := gatewayClassExtractor(class(map[string]interface{}{
+			"name": "istio-options",
+		}), "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(queries) != 0 {
+			t.Errorf("expected a reference without a kind to be skipped, got %v", queries)
+		}
+	})
+}
+
+func TestGatewayRouteExtractor(t *testing.T) {
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "HTTPRoute",
+			"metadata": map[string]interface{}{
+				"name":      "shop",
+				"namespace": "shop",
+			},
+			"spec": map[string]interface{}{
+				"parentRefs": []interface{}{
+					map[string]interface{}{
+						"name":      "external",
+						"namespace": "infra",
+					},
+				},
+				"hostnames": []interface{}{"shop.example.com"},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"backendRefs": []interface{}{
+							map[string]interface{}{
+								"name": "shop-frontend",
+								"port": int64(80),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	queries, err := gatewayRouteExtractor(route, "test.shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	QueryTests{
+		{
+			ExpectedType:   "Gateway",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "external",
+			ExpectedScope:  "test.infra",
+		},
+		{
+			ExpectedType:   "Service",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "shop-frontend",
+			ExpectedScope:  "test.shop",
+		},
+		{
+			ExpectedType:   "dns",
+			ExpectedMethod: sdp.QueryMethod_SEARCH,
+			ExpectedQuery:  "shop.example.com",
+			ExpectedScope:  "global",
+		},
+	}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+}
+
+func TestReferenceGrantExtractor(t *testing.T) {
+	grant := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1beta1",
+			"kind":       "ReferenceGrant",
+			"metadata": map[string]interface{}{
+				"name":      "allow-routes",
+				"namespace": "backends",
+			},
+			"spec": map[string]interface{}{
+				"from": []interface{}{
+					map[string]interface{}{
+						"group":     "gateway.networking.k8s.io",
+						"kind":      "HTTPRoute",
+						"namespace": "shop",
+					},
+				},
+				"to": []interface{}{
+					map[string]interface{}{
+						"group": "",
+						"kind":  "Service",
+						"name":  "payments",
+					},
+					map[string]interface{}{
+						"group": "",
+						"kind":  "Secret",
+					},
+				},
+			},
+		},
+	}
+
+	queries, err := referenceGrantExtractor(grant, "test.backends")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	QueryTests{
+		{
+			ExpectedType:   "HTTPRoute",
+			ExpectedMethod: sdp.QueryMethod_LIST,
+			ExpectedScope:  "test.shop",
+		},
+		{
+			ExpectedType:   "Service",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "payments",
+			ExpectedScope:  "test.backends",
+		},
+		{
+			ExpectedType:   "Secret",
+			ExpectedMethod: sdp.QueryMethod_LIST,
+			ExpectedScope:  "test.backends",
+		},
+	}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+}
+
+func TestGatewayRouteHealthExtractor(t *testing.T) {
+	route := func(parentConditions ...[]interface{}) *unstructured.Unstructured {
+		parents := make([]interface{}, 0)
+
+		for _, conditions := range parentConditions {
+			parents = append(parents, map[string]interface{}{
+				"conditions": conditions,
+			})
+		}
+
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"status": map[string]interface{}{
+					"parents": parents,
+				},
+			},
+		}
+	}
+
+	condition := func(conditionType string, status string) interface{} {
+		return map[string]interface{}{
+			"type":   conditionType,
+			"status": status,
+		}
+	}
+
+	HealthTests[*unstructured.Unstructured]{
+		{
+			Name:           "not processed",
+			Resource:       route(),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name:           "accepted",
+			Resource:       route([]interface{}{condition("Accepted", "True"), condition("ResolvedRefs", "True")}),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "missing backend",
+			Resource:       route([]interface{}{condition("Accepted", "True"), condition("ResolvedRefs", "False")}),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+		{
+			Name: "rejected by one gateway",
+			Resource: route(
+				[]interface{}{condition("Accepted", "True")},
+				[]interface{}{condition("Accepted", "False")},
+			),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+	}.Execute(t, gatewayRouteHealthExtractor)
+}
+
+func TestGatewayCustomResources(t *testing.T) {
+	ctx := context.Background()
+
+	gw := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "Gateway",
+			"metadata": map[string]interface{}{
+				"name":      "external",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"gatewayClassName": "istio",
+			},
+		},
+	}
+
+	manager, _ := createCustomResourceManager(
+		newTestCRD("gateways.gateway.networking.k8s.io", GatewayAPIGroup, "gateways", "Gateway"),
+		gw,
+	)
+
+	created, _, err := manager.Sync(ctx, []string{"default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(created) != 1 {
+		t.Fatalf("expected 1 adapter, got %v", len(created))
+	}
+
+	if created[0].Metadata() != gatewayAdapterMetadata {
+		t.Error("expected Gateway adapter to use the Gateway metadata")
+	}
+
+	item, err := created[0].Get(ctx, "test-cluster.default", "external", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	QueryTests{
+		{
+			ExpectedType:   "GatewayClass",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "istio",
+			ExpectedScope:  "test-cluster",
+		},
+	}.Execute(t, item)
+
+	if item.GetHealth() != sdp.Health_HEALTH_PENDING {
+		t.Errorf("expected pending health, got %v", item.GetHealth())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -12,6 +13,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const DefaultCacheDuration = 30 * time.Minute
@@ -58,6 +61,30 @@ type KubeTypeAdapter[Resource metav1.Object, ResourceList any] struct {
 	// optional
 	Redact func(resource Resource) Resource
 
+	// A function that returns extra attributes to add to the item, for
+	// details that are useful to search on but are buried in the resource.
+	// This is optional
+	AttributeExtractor func(resource Resource) (map[string]interface{}, error)
+
+	// A function that handles search queries that aren't in the ListOptions
+	// JSON format, for searching on things that the API can't filter by. Every
+	// resource in the scope is passed to it along with the query, and it
+	// should return whether the resource matches. This is optional
+	SearchMatcher func(query string, resource Resource) bool
+
+	// A function that returns the fields of the resource that the API server
+	// supports in field selectors, other than `metadata.name` and
+	// `metadata.namespace` which every type supports. This is only used to
+	// evaluate field selectors against the informer store, and is optional
+	SelectableFields func(resource Resource) fields.Set
+
+	// Whether searches can be sent to the cluster scope of a namespaced type,
+	// to find the resources in every namespace that is being discovered with a
+	// single query, e.g. the pods on a node. Only searches in the ListOptions
+	// format are served in the cluster scope, since the items themselves are
+	// in their namespaces' scopes
+	ClusterSearch bool
+
 	// Whether to automatically extract the query from the item's attributes.
 	// This should be enabled for resources that are likely to include
 	// unstructured but interesting data like environment variables
@@ -66,8 +93,11 @@ type KubeTypeAdapter[Resource metav1.Object, ResourceList any] struct {
 	// The type of items that this adapter should return. This should be the
 	// "Kind" of the kubernetes resources, e.g. "Pod", "Node", "ServiceAccount"
 	TypeName string
-	// List of namespaces that this adapter should query
-	Namespaces []string
+	// List of namespaces that this adapter should query. Once the adapter is
+	// in use this should only be changed using `AddNamespace` and
+	// `RemoveNamespace`
+	Namespaces   []string
+	namespacesMu sync.RWMutex // Protects Namespaces
 	// The name of the cluster that this adapter is for. This is used to generate
 	// scopes
 	ClusterName string
@@ -78,6 +108,10 @@ type KubeTypeAdapter[Resource metav1.Object, ResourceList any] struct {
 	CacheDuration time.Duration   // How long to cache items for
 	cache         *sdpcache.Cache // The sdpcache of this adapter
 	cacheInitMu   sync.Mutex      // Mutex to ensure cache is only initialised once
+
+	informers            map[string]*adapterInformer // Informers by the namespace they watch, blank for all namespaces. Nil unless EnableInformers() has been called
+	informerPerNamespace bool                        // Set if all namespaces can't be watched, so there is an informer per namespace instead
+	informerMu           sync.Mutex                  // Protects the informer fields
 }
 
 func (s *KubeTypeAdapter[Resource, ResourceList]) cacheDuration() time.Duration {
@@ -116,6 +150,9 @@ func (s *KubeTypeAdapter[Resource, ResourceList]) Validate() error {
 		return errors.New("typeName must be specified")
 	}
 
+	s.namespacesMu.RLock()
+	defer s.namespacesMu.RUnlock()
+
 	if s.namespaced() && len(s.Namespaces) == 0 {
 		return errors.New("namespaces must be specified when NamespacedInterfaceBuilder is specified")
 	}
@@ -152,6 +189,9 @@ func (s *KubeTypeAdapter[Resource, ResourceList]) Scopes() []string {
 	namespaces := make([]string, 0)
 
 	if s.namespaced() {
+		s.namespacesMu.RLock()
+		defer s.namespacesMu.RUnlock()
+
 		for _, ns := range s.Namespaces {
 			sd := ScopeDetails{
 				ClusterName: s.ClusterName,
@@ -160,18 +200,104 @@ func (s *KubeTypeAdapter[Resource, ResourceList]) Scopes() []string {
 
 			namespaces = append(namespaces, sd.String())
 		}
-	} else {
-		sd := ScopeDetails{
-			ClusterName: s.ClusterName,
+
+		if !s.ClusterSearch {
+			return namespaces
 		}
+	}
 
-		namespaces = append(namespaces, sd.String())
+	sd := ScopeDetails{
+		ClusterName: s.ClusterName,
 	}
 
+	namespaces = append(namespaces, sd.String())
+
 	return namespaces
 }
 
+// isClusterSearchScope Returns whether a scope is the cluster scope of a
+// namespaced adapter with `ClusterSearch`, which only serves searches
+func (s *KubeTypeAdapter[Resource, ResourceList]) isClusterSearchScope(scope string) bool {
+	return s.ClusterSearch && s.namespaced() && scope == ScopeDetails{ClusterName: s.ClusterName}.String()
+}
+
+// clusterSearchScopeError The error for queries other than searches that are
+// sent to the cluster scope of a namespaced adapter
+func (s *KubeTypeAdapter[Resource, ResourceList]) clusterSearchScopeError(scope string) error {
+	return &sdp.QueryError{
+		ErrorType:   sdp.QueryError_NOSCOPE,
+		ErrorString: fmt.Sprintf("%v can only be searched for in scope %v", s.TypeName, scope),
+	}
+}
+
+// currentNamespaces Returns a copy of the namespaces that the adapter is
+// querying
+func (s *KubeTypeAdapter[Resource, ResourceList]) currentNamespaces() []string {
+	s.namespacesMu.RLock()
+	defer s.namespacesMu.RUnlock()
+
+	return slices.Clone(s.Namespaces)
+}
+
+// AddNamespace Adds a namespace to the list of namespaces that this adapter
+// queries, and therefore to its scopes. This is safe to call while the adapter
+// is in use
+func (s *KubeTypeAdapter[Resource, ResourceList]) AddNamespace(namespace string) {
+	s.namespacesMu.Lock()
+	defer s.namespacesMu.Unlock()
+
+	if slices.Contains(s.Namespaces, namespace) {
+		return
+	}
+
+	// The slice may be shared with other adapters, so make sure that
+	// appending copies it rather than writing to the shared array
+	s.Namespaces = append(slices.Clip(s.Namespaces), namespace)
+}
+
+// RemoveNamespace Removes a namespace from this adapter, purging anything
+// cached for that namespace and stopping its informer if there is one. This is
+// safe to call while the adapter is in use
+func (s *KubeTypeAdapter[Resource, ResourceList]) RemoveNamespace(namespace string) {
+	s.namespacesMu.Lock()
+	s.Namespaces = slices.DeleteFunc(slices.Clone(s.Namespaces), func(ns string) bool {
+		return ns == namespace
+	})
+	s.namespacesMu.Unlock()
+
+	if !s.namespaced() {
+		// Cluster-scoped adapters have a single scope that doesn't depend on
+		// namespaces, so there is nothing cached to remove
+		return
+	}
+
+	scope := ScopeDetails{
+		ClusterName: s.ClusterName,
+		Namespace:   namespace,
+	}.String()
+
+	s.Cache().Delete(sdpcache.CacheKey{
+		SST: sdpcache.SST{
+			SourceName: s.Name(),
+			Scope:      scope,
+			Type:       s.Type(),
+		},
+	})
+
+	s.stopInformer(namespace)
+}
+
 func (s *KubeTypeAdapter[Resource, ResourceList]) Get(ctx context.Context, scope string, query string, ignoreCache bool) (*sdp.Item, error) {
+	if s.isClusterSearchScope(scope) {
+		return nil, s.clusterSearchScopeError(scope)
+	}
+
+	if s.informersEnabled() {
+		// The informer store is always up to date so there is no need to
+		// cache
+		return s.getFromInformer(ctx, scope, query)
+	}
+
 	s.ensureCache()
 	cacheHit, ck, cachedItems, qErr := s.cache.Lookup(ctx, s.Name(), sdp.QueryMethod_GET, scope, s.Type(), query, ignoreCache)
 	if qErr != nil {
@@ -221,6 +347,14 @@ func (s *KubeTypeAdapter[Resource, ResourceList]) Get(ctx context.Context, scope
 }
 
 func (s *KubeTypeAdapter[Resource, ResourceList]) List(ctx context.Context, scope string, ignoreCache bool) ([]*sdp.Item, error) {
+	if s.isClusterSearchScope(scope) {
+		return nil, s.clusterSearchScopeError(scope)
+	}
+
+	if s.informersEnabled() {
+		return s.listFromInformer(ctx, scope, labels.Everything(), fields.Everything())
+	}
+
 	s.ensureCache()
 	cacheHit, ck, cachedItems, qErr := s.cache.Lookup(ctx, s.Name(), sdp.QueryMethod_LIST, scope, s.Type(), "", ignoreCache)
 	if qErr != nil {
@@ -274,9 +408,34 @@ func (s *KubeTypeAdapter[Resource, ResourceList]) listWithOptions(ctx context.Co
 func (s *KubeTypeAdapter[Resource, ResourceList]) Search(ctx context.Context, scope string, query string, ignoreCache bool) ([]*sdp.Item, error) {
 	opts, err := QueryToListOptions(query)
 	if err != nil {
+		if s.SearchMatcher != nil {
+			return s.searchMatching(ctx, scope, query, ignoreCache)
+		}
+
 		return nil, err
 	}
 
+	if s.isClusterSearchScope(scope) {
+		return s.searchAllNamespaces(ctx, scope, query, opts, ignoreCache)
+	}
+
+	// Selectors are evaluated against the informer store so that searches
+	// that are sent to every namespace, such as for the pods on a node, don't
+	// need to query the API for each one
+	if s.informersEnabled() {
+		selector, err := labels.Parse(opts.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.listFromInformer(ctx, scope, selector, fieldSelector)
+	}
+
 	ck := sdpcache.CacheKeyFromParts(s.Name(), sdp.QueryMethod_SEARCH, scope, s.Type(), query)
 
 	items, err := s.listWithOptions(ctx, scope, opts)
@@ -292,6 +451,162 @@ func (s *KubeTypeAdapter[Resource, ResourceList]) Search(ctx context.Context, sc
 	return items, nil
 }
 
+// searchAllNamespaces Runs a search that was sent to the cluster scope in every
+// namespace that is being discovered. Without informers this is a single list
+// across all namespaces, unless the source can only list the namespaces one at
+// a time
+func (s *KubeTypeAdapter[Resource, ResourceList]) searchAllNamespaces(ctx context.Context, scope string, query string, opts metav1.ListOptions, ignoreCache bool) ([]*sdp.Item, error) {
+	namespaces := s.currentNamespaces()
+
+	if s.informersEnabled() {
+		selector, err := labels.Parse(opts.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]*sdp.Item, 0)
+
+		for _, namespace := range namespaces {
+			namespaceItems, err := s.listFromInformer(ctx, ScopeDetails{
+				ClusterName: s.ClusterName,
+				Namespace:   namespace,
+			}.String(), selector, fieldSelector)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, namespaceItems...)
+		}
+
+		return items, nil
+	}
+
+	s.ensureCache()
+	cacheHit, ck, cachedItems, qErr := s.cache.Lookup(ctx, s.Name(), sdp.QueryMethod_SEARCH, scope, s.Type(), query, ignoreCache)
+	if qErr != nil {
+		return nil, qErr
+	}
+	if cacheHit {
+		return cachedItems, nil
+	}
+
+	items, err := s.listAllNamespaces(ctx, namespaces, opts)
+	if err != nil {
+		s.cache.StoreError(err, s.cacheDuration(), ck)
+		return nil, err
+	}
+
+	for _, item := range items {
+		s.cache.StoreItem(item, s.cacheDuration(), ck)
+	}
+
+	return items, nil
+}
+
+// listAllNamespaces Lists the items in the given namespaces, using a single
+// list across all namespaces if the source is allowed to
+func (s *KubeTypeAdapter[Resource, ResourceList]) listAllNamespaces(ctx context.Context, namespaces []string, opts metav1.ListOptions) ([]*sdp.Item, error) {
+	list, err := s.NamespacedInterfaceBuilder(metav1.NamespaceAll).List(ctx, opts)
+
+	if k8serr.IsForbidden(err) {
+		items := make([]*sdp.Item, 0)
+
+		for _, namespace := range namespaces {
+			namespaceItems, err := s.listWithOptions(ctx, ScopeDetails{
+				ClusterName: s.ClusterName,
+				Namespace:   namespace,
+			}.String(), opts)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, namespaceItems...)
+		}
+
+		return items, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.ListExtractor(list)
+	if err != nil {
+		return nil, err
+	}
+
+	// Namespaces that have been filtered out mustn't be served
+	resources = slices.DeleteFunc(resources, func(resource Resource) bool {
+		return !slices.Contains(namespaces, resource.GetNamespace())
+	})
+
+	return s.resourcesToItems(resources)
+}
+
+// searchMatching Runs a search that isn't in the ListOptions format by checking
+// every resource in the scope with `SearchMatcher`
+func (s *KubeTypeAdapter[Resource, ResourceList]) searchMatching(ctx context.Context, scope string, query string, ignoreCache bool) ([]*sdp.Item, error) {
+	s.ensureCache()
+	cacheHit, ck, cachedItems, qErr := s.cache.Lookup(ctx, s.Name(), sdp.QueryMethod_SEARCH, scope, s.Type(), query, ignoreCache)
+	if qErr != nil {
+		return nil, qErr
+	}
+	if cacheHit {
+		return cachedItems, nil
+	}
+
+	resources, err := s.listResources(ctx, scope)
+	if err != nil {
+		s.cache.StoreError(err, s.cacheDuration(), ck)
+		return nil, err
+	}
+
+	resources = slices.DeleteFunc(resources, func(resource Resource) bool {
+		return !s.SearchMatcher(query, resource)
+	})
+
+	items, err := s.resourcesToItems(resources)
+	if err != nil {
+		s.cache.StoreError(err, s.cacheDuration(), ck)
+		return nil, err
+	}
+
+	for _, item := range items {
+		s.cache.StoreItem(item, s.cacheDuration(), ck)
+	}
+
+	return items, nil
+}
+
+// listResources Lists the raw resources in a scope, from the informer store if
+// informers are enabled. This is for when the resources need to be filtered in
+// ways that the API doesn't support
+func (s *KubeTypeAdapter[Resource, ResourceList]) listResources(ctx context.Context, scope string) ([]Resource, error) {
+	if s.informersEnabled() {
+		return s.resourcesFromInformer(ctx, scope)
+	}
+
+	i, err := s.itemInterface(scope)
+	if err != nil {
+		return nil, &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOSCOPE,
+			ErrorString: err.Error(),
+		}
+	}
+
+	list, err := i.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.ListExtractor(list)
+}
+
 // itemInterface Returns the correct interface depending on whether the adapter
 // is namespaced or not
 func (s *KubeTypeAdapter[Resource, ResourceList]) itemInterface(scope string) (ItemInterface[Resource, ResourceList], error) {
@@ -379,6 +694,18 @@ func (s *KubeTypeAdapter[Resource, ResourceList]) resourceToItem(resource Resour
 		delete(attributes.GetAttrStruct().GetFields(), "metadata")
 	}
 
+	if s.AttributeExtractor != nil {
+		extra, err := s.AttributeExtractor(resource)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range extra {
+			attributes.Set(key, value)
+		}
+	}
+
 	// Make sure the name is set
 	attributes.Set("name", resource.GetName())
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
 	"testing"
 	"time"
 
@@ -12,8 +13,12 @@ import (
 	"github.com/overmindtech/discovery"
 	"github.com/overmindtech/sdp-go"
 	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 type PodClient struct {
@@ -320,6 +325,71 @@ func TestScopes(t *testing.T) {
 	})
 }
 
+func TestAddRemoveNamespace(t *testing.T) {
+	adapter := createAdapter(true)
+
+	t.Run("adding a namespace", func(t *testing.T) {
+		adapter.AddNamespace("app2")
+
+		if !slices.Contains(adapter.Scopes(), "minikube.app2") {
+			t.Errorf("expected scopes to contain minikube.app2, got %v", adapter.Scopes())
+		}
+
+		// Adding it again shouldn't duplicate it
+		adapter.AddNamespace("app2")
+
+		if len(adapter.Scopes()) != 3 {
+			t.Errorf("expected 3 scopes, got %v", adapter.Scopes())
+		}
+	})
+
+	t.Run("removing a namespace", func(t *testing.T) {
+		// Populate the cache for the namespace
+		_, err := adapter.List(context.Background(), "minikube.app1", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		adapter.RemoveNamespace("app1")
+
+		if slices.Contains(adapter.Scopes(), "minikube.app1") {
+			t.Errorf("expected scopes not to contain minikube.app1, got %v", adapter.Scopes())
+		}
+
+		hit, _, _, _ := adapter.Cache().Lookup(context.Background(), adapter.Name(), sdp.QueryMethod_LIST, "minikube.app1", adapter.Type(), "", false)
+
+		if hit {
+			t.Error("expected cache to be purged for removed namespace")
+		}
+	})
+
+	t.Run("removing a namespace that doesn't exist", func(t *testing.T) {
+		adapter.RemoveNamespace("not-real")
+
+		if len(adapter.Scopes()) != 2 {
+			t.Errorf("expected 2 scopes, got %v", adapter.Scopes())
+		}
+	})
+
+	t.Run("adapters sharing a namespace slice", func(t *testing.T) {
+		// Adapters are created with the same slice of namespaces, so changes
+		// to one mustn't affect the others
+		namespaces := []string{"app1", "app2"}
+		first := createAdapter(true)
+		second := createAdapter(true)
+		first.Namespaces = namespaces
+		second.Namespaces = namespaces
+
+		first.RemoveNamespace("app1")
+		first.AddNamespace("app3")
+
+		if !slices.Equal(second.Scopes(), []string{"minikube.app1", "minikube.app2"}) {
+			t.Errorf("expected second adapter to be unchanged, got %v", second.Scopes())
+		}
+	})
+}
+
 func TestAdapterGet(t *testing.T) {
 	t.Run("get existing item", func(t *testing.T) {
 		adapter := createAdapter(false)
@@ -475,6 +545,155 @@ func TestSearch(t *testing.T) {
 			t.Errorf("expected error, got none")
 		}
 	})
+
+	t.Run("with a search matcher", func(t *testing.T) {
+		adapter := createAdapter(false)
+		adapter.SearchMatcher = func(query string, resource *v1.Pod) bool {
+			return resource.GetName() == query
+		}
+
+		items, err := adapter.Search(context.Background(), "foo", "bar", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 || items[0].UniqueAttributeValue() != "bar" {
+			t.Errorf("expected only bar to match, got %v", items)
+		}
+	})
+}
+
+func TestClusterSearch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	newNodePod := func(name string, namespace string, node string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       v1.PodSpec{NodeName: node},
+		}
+	}
+
+	newClient := func() *fake.Clientset {
+		return fake.NewClientset(
+			newNodePod("web", "default", "node-1"),
+			newNodePod("worker", "other", "node-1"),
+			newNodePod("db", "other", "node-2"),
+			newNodePod("agent", "filtered", "node-1"),
+		)
+	}
+
+	query := `{"fieldSelector":"spec.nodeName=node-1"}`
+
+	names := func(items []*sdp.Item) []string {
+		names := make([]string, 0, len(items))
+
+		for _, item := range items {
+			names = append(names, item.GetScope()+"/"+item.UniqueAttributeValue())
+		}
+
+		slices.Sort(names)
+
+		return names
+	}
+
+	t.Run("scopes", func(t *testing.T) {
+		adapter := newPodAdapter(newClient(), "test-cluster", []string{"default", "other"})
+
+		if !slices.Contains(adapter.Scopes(), "test-cluster") {
+			t.Errorf("expected the cluster scope, got %v", adapter.Scopes())
+		}
+
+		if slices.Contains(itemScopes(adapter), "test-cluster") {
+			t.Errorf("expected the cluster scope not to be an item scope, got %v", itemScopes(adapter))
+		}
+
+		var qErr *sdp.QueryError
+
+		if _, err := adapter.Get(ctx, "test-cluster", "web", false); !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOSCOPE {
+			t.Errorf("expected NOSCOPE for a Get in the cluster scope, got %v", err)
+		}
+
+		if _, err := adapter.List(ctx, "test-cluster", false); !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOSCOPE {
+			t.Errorf("expected NOSCOPE for a List in the cluster scope, got %v", err)
+		}
+	})
+
+	t.Run("without informers", func(t *testing.T) {
+		cs := newClient()
+		adapter := newPodAdapter(cs, "test-cluster", []string{"default", "other"}).(*KubeTypeAdapter[*v1.Pod, *v1.PodList])
+
+		items, err := adapter.Search(ctx, "test-cluster", query, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The fake client doesn't filter on fields, but the API does. The
+		// pods in namespaces that aren't being discovered are removed
+		if slices.Contains(names(items), "test-cluster.filtered/agent") {
+			t.Errorf("expected pods in filtered namespaces to be removed, got %v", names(items))
+		}
+
+		var lists int
+
+		for _, action := range cs.Actions() {
+			if action.Matches("list", "pods") {
+				lists++
+
+				if action.GetNamespace() != metav1.NamespaceAll {
+					t.Errorf("expected a list across all namespaces, got %v", action.GetNamespace())
+				}
+			}
+		}
+
+		if lists != 1 {
+			t.Errorf("expected pods to be listed once, got %v", lists)
+		}
+	})
+
+	t.Run("without permission to list all namespaces", func(t *testing.T) {
+		cs := newClient()
+
+		cs.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetNamespace() == metav1.NamespaceAll {
+				return true, nil, k8serr.NewForbidden(action.GetResource().GroupResource(), "", errors.New("not allowed"))
+			}
+
+			return false, nil, nil
+		})
+
+		adapter := newPodAdapter(cs, "test-cluster", []string{"default", "other"}).(*KubeTypeAdapter[*v1.Pod, *v1.PodList])
+
+		items, err := adapter.Search(ctx, "test-cluster", query, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if slices.Contains(names(items), "test-cluster.filtered/agent") || !slices.Contains(names(items), "test-cluster.other/worker") {
+			t.Errorf("expected the pods in each namespace, got %v", names(items))
+		}
+	})
+
+	t.Run("with informers", func(t *testing.T) {
+		adapter := newPodAdapter(newClient(), "test-cluster", []string{"default", "other"}).(*KubeTypeAdapter[*v1.Pod, *v1.PodList])
+		adapter.EnableInformers()
+		defer adapter.StopInformers()
+
+		items, err := adapter.Search(ctx, "test-cluster", query, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []string{"test-cluster.default/web", "test-cluster.other/worker"}
+
+		if !slices.Equal(names(items), expected) {
+			t.Errorf("expected %v, got %v", expected, names(items))
+		}
+	})
 }
 
 func TestRedact(t *testing.T) {
@@ -502,6 +721,31 @@ func TestRedact(t *testing.T) {
 	}
 }
 
+func TestAttributeExtractor(t *testing.T) {
+	adapter := createAdapter(true)
+	adapter.AttributeExtractor = func(resource *v1.Pod) (map[string]interface{}, error) {
+		return map[string]interface{}{
+			"serviceAccount": resource.Spec.ServiceAccountName,
+		}, nil
+	}
+
+	item, err := adapter.Get(context.Background(), "cluster.namespace", "test", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serviceAccount, err := item.GetAttributes().Get("serviceAccount")
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if serviceAccount != "default" {
+		t.Errorf("expected serviceAccount to be default, got %v", serviceAccount)
+	}
+}
+
 type QueryTest struct {
 	ExpectedType   string
 	ExpectedMethod sdp.QueryMethod
@@ -562,6 +806,42 @@ func lirMatches(test QueryTest, req *sdp.LinkedItemQuery) bool {
 	return true
 }
 
+type HealthTest[Resource any] struct {
+	Name     string
+	Resource Resource
+
+	// The expected health, nil if the extractor shouldn't return a health
+	ExpectedHealth *sdp.Health
+}
+
+type HealthTests[Resource any] []HealthTest[Resource]
+
+func (h HealthTests[Resource]) Execute(t *testing.T, extractor func(Resource) *sdp.Health) {
+	t.Helper()
+
+	for _, test := range h {
+		t.Run(test.Name, func(t *testing.T) {
+			health := extractor(test.Resource)
+
+			if test.ExpectedHealth == nil {
+				if health != nil {
+					t.Errorf("expected no health, got %v", health)
+				}
+
+				return
+			}
+
+			if health == nil {
+				t.Fatalf("expected health %v, got nil", test.ExpectedHealth)
+			}
+
+			if *health != *test.ExpectedHealth {
+				t.Errorf("expected health %v, got %v", test.ExpectedHealth, health)
+			}
+		})
+	}
+}
+
 type AdapterTests struct {
 	// The adapter under test
 	Adapter discovery.ListableAdapter
@@ -2,6 +2,7 @@ package adapters
 
 import (
 	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/overmindtech/discovery"
 	"github.com/overmindtech/sdp-go"
@@ -29,7 +30,43 @@ func horizontalPodAutoscalerExtractor(resource *v2.HorizontalPodAutoscaler, scop
 	return queries, nil
 }
 
-func newHorizontalPodAutoscalerAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func horizontalPodAutoscalerHealthExtractor(resource *v2.HorizontalPodAutoscaler) *sdp.Health {
+	if len(resource.Status.Conditions) == 0 {
+		// The controller hasn't processed the autoscaler yet
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	health := sdp.Health_HEALTH_OK
+
+	for _, condition := range resource.Status.Conditions {
+		switch condition.Type {
+		case v2.AbleToScale:
+			// The autoscaler can't fetch or update the scale of its target
+			if condition.Status == corev1.ConditionFalse {
+				return sdp.Health_HEALTH_ERROR.Enum()
+			}
+		case v2.ScalingActive:
+			if condition.Status == corev1.ConditionFalse {
+				// Scaling is intentionally disabled when the target has been
+				// scaled to zero, otherwise the metrics couldn't be fetched
+				if condition.Reason == "ScalingDisabled" {
+					health = sdp.Health_HEALTH_WARNING
+				} else {
+					return sdp.Health_HEALTH_ERROR.Enum()
+				}
+			}
+		case v2.ScalingLimited:
+			// The autoscaler wants more replicas than it is allowed
+			if condition.Status == corev1.ConditionTrue && condition.Reason == "TooManyReplicas" {
+				health = sdp.Health_HEALTH_WARNING
+			}
+		}
+	}
+
+	return health.Enum()
+}
+
+func newHorizontalPodAutoscalerAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v2.HorizontalPodAutoscaler, *v2.HorizontalPodAutoscalerList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -47,6 +84,7 @@ func newHorizontalPodAutoscalerAdapter(cs *kubernetes.Clientset, cluster string,
 			return extracted, nil
 		},
 		LinkedItemQueryExtractor: horizontalPodAutoscalerExtractor,
+		HealthExtractor:          horizontalPodAutoscalerHealthExtractor,
 		AdapterMetadata:          horizontalPodAutoscalerAdapterMetadata,
 	}
 }
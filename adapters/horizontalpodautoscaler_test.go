@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/overmindtech/sdp-go"
+	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
 )
 
 var horizontalPodAutoscalerYAML = `
@@ -72,3 +74,71 @@ func TestHorizontalPodAutoscalerAdapter(t *testing.T) {
 
 	st.Execute(t)
 }
+
+func TestHorizontalPodAutoscalerHealthExtractor(t *testing.T) {
+	hpa := func(conditions ...v2.HorizontalPodAutoscalerCondition) *v2.HorizontalPodAutoscaler {
+		return &v2.HorizontalPodAutoscaler{
+			Status: v2.HorizontalPodAutoscalerStatus{
+				Conditions: conditions,
+			},
+		}
+	}
+
+	ableToScale := v2.HorizontalPodAutoscalerCondition{Type: v2.AbleToScale, Status: corev1.ConditionTrue}
+	scalingActive := v2.HorizontalPodAutoscalerCondition{Type: v2.ScalingActive, Status: corev1.ConditionTrue}
+
+	HealthTests[*v2.HorizontalPodAutoscaler]{
+		{
+			Name:           "scaling",
+			Resource:       hpa(ableToScale, scalingActive),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "not processed",
+			Resource:       hpa(),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name: "unable to scale",
+			Resource: hpa(
+				v2.HorizontalPodAutoscalerCondition{Type: v2.AbleToScale, Status: corev1.ConditionFalse, Reason: "FailedGetScale"},
+				scalingActive,
+			),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name: "missing metrics",
+			Resource: hpa(
+				ableToScale,
+				v2.HorizontalPodAutoscalerCondition{Type: v2.ScalingActive, Status: corev1.ConditionFalse, Reason: "FailedGetResourceMetric"},
+			),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name: "scaled to zero",
+			Resource: hpa(
+				ableToScale,
+				v2.HorizontalPodAutoscalerCondition{Type: v2.ScalingActive, Status: corev1.ConditionFalse, Reason: "ScalingDisabled"},
+			),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+		{
+			Name: "at max replicas",
+			Resource: hpa(
+				ableToScale,
+				scalingActive,
+				v2.HorizontalPodAutoscalerCondition{Type: v2.ScalingLimited, Status: corev1.ConditionTrue, Reason: "TooManyReplicas"},
+			),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+		{
+			Name: "at min replicas",
+			Resource: hpa(
+				ableToScale,
+				scalingActive,
+				v2.HorizontalPodAutoscalerCondition{Type: v2.ScalingLimited, Status: corev1.ConditionTrue, Reason: "TooFewReplicas"},
+			),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+	}.Execute(t, horizontalPodAutoscalerHealthExtractor)
+}
@@ -0,0 +1,487 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/overmindtech/sdp-go"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WatchableItemInterface An ItemInterface that can also watch for changes.
+// All of the typed clients in client-go implement this, and it is required for
+// an adapter to be backed by informers
+type WatchableItemInterface[Resource metav1.Object, ResourceList any] interface {
+	ItemInterface[Resource, ResourceList]
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+}
+
+// adapterInformer An informer and the means to stop it
+type adapterInformer struct {
+	informer cache.SharedIndexInformer
+	// Cancels the context used by the informer's list and watch calls and
+	// stops the informer
+	cancel context.CancelFunc
+	// Closed when the informer's list or watch fails before it has synced,
+	// so that queries waiting for it can fail rather than waiting until their
+	// deadline. `err` is set before this is closed
+	failed   chan struct{}
+	failOnce sync.Once
+	err      error
+}
+
+func (i *adapterInformer) stop() {
+	i.cancel()
+}
+
+// EnableInformers Switches the adapter to serve Get, List and Search from a
+// local store that is kept in sync by watching the API, rather than querying
+// the API and caching the results for `CacheDuration`. A single informer that
+// watches all namespaces is started lazily the first time the adapter is
+// queried, and every scope is served from it
+func (s *KubeTypeAdapter[Resource, ResourceList]) EnableInformers() {
+	s.informerMu.Lock()
+	defer s.informerMu.Unlock()
+
+	if s.informers != nil {
+		return
+	}
+
+	s.informers = make(map[string]*adapterInformer)
+}
+
+// StopInformers Stops all running informers and returns the adapter to
+// querying the API directly
+func (s *KubeTypeAdapter[Resource, ResourceList]) StopInformers() {
+	s.informerMu.Lock()
+	defer s.informerMu.Unlock()
+
+	for _, informer := range s.informers {
+		informer.stop()
+	}
+
+	s.informers = nil
+}
+
+// stopInformer Stops the informer for a single namespace, if there is one.
+// These only exist if the adapter can't watch all namespaces
+func (s *KubeTypeAdapter[Resource, ResourceList]) stopInformer(namespace string) {
+	s.informerMu.Lock()
+	defer s.informerMu.Unlock()
+
+	if informer, ok := s.informers[namespace]; ok {
+		informer.stop()
+		delete(s.informers, namespace)
+	}
+}
+
+// removeInformer Stops an informer and removes it, if it is still the one that
+// is running for the namespace, so that the next query starts a new one
+func (s *KubeTypeAdapter[Resource, ResourceList]) removeInformer(namespace string, informer *adapterInformer) {
+	s.informerMu.Lock()
+	defer s.informerMu.Unlock()
+
+	informer.stop()
+
+	if s.informers[namespace] == informer {
+		delete(s.informers, namespace)
+	}
+}
+
+// informersEnabled Returns whether queries should be served from informers
+func (s *KubeTypeAdapter[Resource, ResourceList]) informersEnabled() bool {
+	s.informerMu.Lock()
+	defer s.informerMu.Unlock()
+
+	return s.informers != nil
+}
+
+// watchedNamespaces Returns the namespaces that informers are running for,
+// sorted. A blank namespace is an informer that watches all namespaces
+func (s *KubeTypeAdapter[Resource, ResourceList]) watchedNamespaces() []string {
+	s.informerMu.Lock()
+	defer s.informerMu.Unlock()
+
+	return slices.Sorted(maps.Keys(s.informers))
+}
+
+// informerNamespace Returns the namespace that a scope's items are in, and
+// checks that the adapter is querying it. This is blank for cluster-scoped
+// adapters
+func (s *KubeTypeAdapter[Resource, ResourceList]) informerNamespace(scope string) (string, error) {
+	details, err := ParseScope(scope, s.namespaced())
+	if err != nil {
+		return "", &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOSCOPE,
+			ErrorString: err.Error(),
+		}
+	}
+
+	if details.ClusterName != s.ClusterName {
+		return "", &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOSCOPE,
+			ErrorString: fmt.Sprintf("scope %v is not in cluster %v", scope, s.ClusterName),
+		}
+	}
+
+	if s.namespaced() && !slices.Contains(s.currentNamespaces(), details.Namespace) {
+		// The informer sees every namespace, including those that have been
+		// filtered out, so these mustn't be served
+		return "", &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOSCOPE,
+			ErrorString: fmt.Sprintf("namespace %v is not being discovered", details.Namespace),
+		}
+	}
+
+	return details.Namespace, nil
+}
+
+// informerIndexer Returns the store for a scope, starting an informer and
+// waiting for it to sync if one isn't already running. The informer watches
+// all namespaces and indexes objects by namespace. If the source doesn't have
+// permission to watch all namespaces, it falls back to an informer per
+// namespace
+func (s *KubeTypeAdapter[Resource, ResourceList]) informerIndexer(ctx context.Context, scope string) (cache.Indexer, error) {
+	namespace, err := s.informerNamespace(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	s.informerMu.Lock()
+
+	if s.informers == nil {
+		// Informers were stopped while this query was in flight
+		s.informerMu.Unlock()
+		return nil, fmt.Errorf("informers for %v have been stopped", s.TypeName)
+	}
+
+	watched := metav1.NamespaceAll
+
+	if s.informerPerNamespace {
+		watched = namespace
+	}
+
+	informer, ok := s.informers[watched]
+
+	if !ok {
+		informer, err = s.newInformer(watched)
+
+		if err != nil {
+			s.informerMu.Unlock()
+			return nil, err
+		}
+
+		s.informers[watched] = informer
+	}
+
+	s.informerMu.Unlock()
+
+	err = informer.waitForSync(ctx)
+
+	if err == nil {
+		return informer.informer.GetIndexer(), nil
+	}
+
+	// Remove the informer so that the next query tries again, rather than
+	// waiting for one that may never sync e.g. because the CRD was removed
+	s.removeInformer(watched, informer)
+
+	if k8serr.IsForbidden(err) && s.namespaced() && watched == metav1.NamespaceAll {
+		s.informerMu.Lock()
+		s.informerPerNamespace = true
+		s.informerMu.Unlock()
+
+		return s.informerIndexer(ctx, scope)
+	}
+
+	return nil, fmt.Errorf("informer for %v in scope %v did not sync: %w", s.TypeName, scope, err)
+}
+
+// newInformer Creates and starts an informer that watches a namespace, or all
+// namespaces if `namespace` is blank. The lock must be held
+func (s *KubeTypeAdapter[Resource, ResourceList]) newInformer(namespace string) (*adapterInformer, error) {
+	var i ItemInterface[Resource, ResourceList]
+
+	if s.namespaced() {
+		i = s.NamespacedInterfaceBuilder(namespace)
+	} else {
+		i = s.ClusterInterfaceBuilder()
+	}
+
+	watchable, ok := i.(WatchableItemInterface[Resource, ResourceList])
+
+	if !ok {
+		return nil, fmt.Errorf("the %v client does not support watching", s.TypeName)
+	}
+
+	// The informer only uses the example object to work out the type that it
+	// is expecting, so a typed nil pointer is fine
+	var example Resource
+
+	exampleObject, ok := any(example).(runtime.Object)
+
+	if !ok {
+		return nil, fmt.Errorf("%T is not a runtime.Object", example)
+	}
+
+	// Except for unstructured resources, which the informer inspects to work
+	// out the GVK, so these need to be a real object
+	if _, ok := exampleObject.(*unstructured.Unstructured); ok {
+		exampleObject = &unstructured.Unstructured{}
+	}
+
+	// Stopping the informer cancels this, which also cancels any list or
+	// watch that is in flight
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lw := &cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			list, err := watchable.List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+
+			listObject, ok := any(list).(runtime.Object)
+
+			if !ok {
+				return nil, fmt.Errorf("%T is not a runtime.Object", list)
+			}
+
+			return listObject, nil
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return watchable.Watch(ctx, opts)
+		},
+	}
+
+	informer := &adapterInformer{
+		informer: cache.NewSharedIndexInformer(lw, exampleObject, 0, cache.Indexers{
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+		}),
+		cancel: cancel,
+		failed: make(chan struct{}),
+	}
+
+	err := informer.informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		// Errors after the informer has synced are retried by the reflector
+		// and don't affect queries, which are served from the store
+		if !informer.informer.HasSynced() {
+			informer.failOnce.Do(func() {
+				informer.err = err
+				close(informer.failed)
+			})
+		}
+
+		cache.DefaultWatchErrorHandler(r, err)
+	})
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go informer.informer.Run(ctx.Done())
+
+	return informer, nil
+}
+
+// waitForSync Waits for the informer to sync, failing if the context is done
+// or the informer couldn't list the resources
+func (i *adapterInformer) waitForSync(ctx context.Context) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !i.informer.HasSynced() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-i.failed:
+			return i.err
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// informerObjects Returns the objects in the informer store for a scope
+func (s *KubeTypeAdapter[Resource, ResourceList]) informerObjects(ctx context.Context, scope string) ([]interface{}, error) {
+	indexer, err := s.informerIndexer(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.namespaced() {
+		return indexer.List(), nil
+	}
+
+	details, err := ParseScope(scope, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexer.ByIndex(cache.NamespaceIndex, details.Namespace)
+}
+
+// storedResource Converts an object from an informer store into a resource.
+// Objects in the store are shared so they are copied first, this means that
+// things like `Redact` can safely modify them
+func (s *KubeTypeAdapter[Resource, ResourceList]) storedResource(obj interface{}) (Resource, error) {
+	var resource Resource
+
+	object, ok := obj.(runtime.Object)
+
+	if !ok {
+		return resource, fmt.Errorf("unexpected object in %v store: %T", s.TypeName, obj)
+	}
+
+	resource, ok = object.DeepCopyObject().(Resource)
+
+	if !ok {
+		return resource, fmt.Errorf("unexpected object in %v store: %T", s.TypeName, obj)
+	}
+
+	return resource, nil
+}
+
+// getFromInformer Gets a single item from the informer store
+func (s *KubeTypeAdapter[Resource, ResourceList]) getFromInformer(ctx context.Context, scope string, query string) (*sdp.Item, error) {
+	store, err := s.informerIndexer(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	key := query
+
+	if s.namespaced() {
+		details, err := ParseScope(scope, true)
+		if err != nil {
+			return nil, err
+		}
+
+		key = details.Namespace + "/" + query
+	}
+
+	obj, exists, err := store.GetByKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOTFOUND,
+			ErrorString: fmt.Sprintf("%v %v not found", s.TypeName, query),
+		}
+	}
+
+	resource, err := s.storedResource(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.resourceToItem(resource)
+}
+
+// listFromInformer Lists items from the informer store that match the given
+// label and field selectors. Field selectors can only use the fields that the
+// API server supports for the type, the same as when they are sent to the API
+func (s *KubeTypeAdapter[Resource, ResourceList]) listFromInformer(ctx context.Context, scope string, selector labels.Selector, fieldSelector fields.Selector) ([]*sdp.Item, error) {
+	if err := s.checkFieldSelector(fieldSelector); err != nil {
+		return nil, err
+	}
+
+	resources, err := s.resourcesFromInformer(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	resources = slices.DeleteFunc(resources, func(resource Resource) bool {
+		return !selector.Matches(labels.Set(resource.GetLabels())) || !fieldSelector.Matches(s.selectableFields(resource))
+	})
+
+	return s.resourcesToItems(resources)
+}
+
+// selectableFields Returns the values of the fields of a resource that can be
+// used in field selectors
+func (s *KubeTypeAdapter[Resource, ResourceList]) selectableFields(resource Resource) fields.Set {
+	set := fields.Set{
+		"metadata.name": resource.GetName(),
+	}
+
+	if s.namespaced() {
+		set["metadata.namespace"] = resource.GetNamespace()
+	}
+
+	if s.SelectableFields != nil {
+		maps.Copy(set, s.SelectableFields(resource))
+	}
+
+	return set
+}
+
+// checkFieldSelector Returns an error if a field selector uses a field that
+// the API server doesn't support for the type
+func (s *KubeTypeAdapter[Resource, ResourceList]) checkFieldSelector(fieldSelector fields.Selector) error {
+	var resource Resource
+
+	t := reflect.TypeOf(resource)
+
+	if t == nil || t.Kind() != reflect.Pointer {
+		return fmt.Errorf("%v is not a pointer", t)
+	}
+
+	// Only the names of the fields are needed, so an empty resource will do
+	supported := s.selectableFields(reflect.New(t.Elem()).Interface().(Resource))
+
+	for _, requirement := range fieldSelector.Requirements() {
+		if _, ok := supported[requirement.Field]; !ok {
+			return &sdp.QueryError{
+				ErrorType:   sdp.QueryError_OTHER,
+				ErrorString: fmt.Sprintf("field label not supported: %v", requirement.Field),
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourcesFromInformer Returns all of the resources in the informer store for
+// a scope, sorted by name
+func (s *KubeTypeAdapter[Resource, ResourceList]) resourcesFromInformer(ctx context.Context, scope string) ([]Resource, error) {
+	objects, err := s.informerObjects(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]Resource, 0, len(objects))
+
+	for _, obj := range objects {
+		resource, err := s.storedResource(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, resource)
+	}
+
+	// The store is unordered, sort by name so that results are stable
+	slices.SortFunc(resources, func(a, b Resource) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+
+	return resources, nil
+}
@@ -0,0 +1,391 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// createInformerAdapter Creates a pod adapter backed by a fake clientset, and
+// returns a channel that is closed once the informer has started watching
+func createInformerAdapter(objects ...*v1.Pod) (*KubeTypeAdapter[*v1.Pod, *v1.PodList], *fake.Clientset, chan struct{}) {
+	cs := fake.NewClientset()
+
+	for _, pod := range objects {
+		_ = cs.Tracker().Add(pod)
+	}
+
+	// The fake clientset doesn't support resource versions, so anything
+	// created between the initial list and the watch starting would be
+	// missed. Signal when the watch has started so tests can wait for it
+	watcherStarted := make(chan struct{})
+	var once sync.Once
+
+	cs.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		gvr := action.GetResource()
+		ns := action.GetNamespace()
+
+		w, err := cs.Tracker().Watch(gvr, ns)
+		if err != nil {
+			return false, nil, err
+		}
+
+		once.Do(func() { close(watcherStarted) })
+
+		return true, w, nil
+	})
+
+	adapter := &KubeTypeAdapter[*v1.Pod, *v1.PodList]{
+		NamespacedInterfaceBuilder: func(namespace string) ItemInterface[*v1.Pod, *v1.PodList] {
+			return cs.CoreV1().Pods(namespace)
+		},
+		ListExtractor: func(list *v1.PodList) ([]*v1.Pod, error) {
+			extracted := make([]*v1.Pod, len(list.Items))
+
+			for i := range list.Items {
+				extracted[i] = &list.Items[i]
+			}
+
+			return extracted, nil
+		},
+		LinkedItemQueryExtractor: PodExtractor,
+		SelectableFields:         podSelectableFields,
+		TypeName:                 "Pod",
+		ClusterName:              "test-cluster",
+		Namespaces:               []string{"default"},
+	}
+
+	adapter.EnableInformers()
+
+	return adapter, cs, watcherStarted
+}
+
+func newTestPod(name string, labels map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    labels,
+		},
+		Spec: v1.PodSpec{
+			ServiceAccountName: "default",
+		},
+	}
+}
+
+func TestInformerAdapter(t *testing.T) {
+	adapter, cs, watcherStarted := createInformerAdapter(
+		newTestPod("foo", map[string]string{"app": "foo"}),
+		newTestPod("bar", map[string]string{"app": "bar"}),
+	)
+	defer adapter.StopInformers()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	scope := "test-cluster.default"
+
+	t.Run("Get", func(t *testing.T) {
+		item, err := adapter.Get(ctx, scope, "foo", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.UniqueAttributeValue() != "foo" {
+			t.Errorf("expected foo, got %v", item.UniqueAttributeValue())
+		}
+
+		if item.GetScope() != scope {
+			t.Errorf("expected scope %v, got %v", scope, item.GetScope())
+		}
+	})
+
+	t.Run("Get non-existent item", func(t *testing.T) {
+		_, err := adapter.Get(ctx, scope, "baz", false)
+
+		var qErr *sdp.QueryError
+
+		if !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOTFOUND {
+			t.Errorf("expected NOTFOUND error, got %v", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		items, err := adapter.List(ctx, scope, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 2 {
+			t.Fatalf("expected 2 items, got %v", len(items))
+		}
+
+		// Results should be sorted by name
+		if items[0].UniqueAttributeValue() != "bar" {
+			t.Errorf("expected first item to be bar, got %v", items[0].UniqueAttributeValue())
+		}
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		items, err := adapter.Search(ctx, scope, `{"labelSelector":"app=foo"}`, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 {
+			t.Fatalf("expected 1 item, got %v", len(items))
+		}
+
+		if items[0].UniqueAttributeValue() != "foo" {
+			t.Errorf("expected foo, got %v", items[0].UniqueAttributeValue())
+		}
+	})
+
+	t.Run("Search with invalid selector", func(t *testing.T) {
+		_, err := adapter.Search(ctx, scope, `{"labelSelector":"app in in"}`, false)
+
+		if err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+
+	t.Run("Search with field selector", func(t *testing.T) {
+		items, err := adapter.Search(ctx, scope, `{"fieldSelector":"metadata.name=bar,spec.serviceAccountName=default"}`, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 || items[0].UniqueAttributeValue() != "bar" {
+			t.Errorf("expected only bar, got %v", items)
+		}
+
+		items, err = adapter.Search(ctx, scope, `{"fieldSelector":"spec.nodeName=node-1"}`, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 0 {
+			t.Errorf("expected no items, got %v", len(items))
+		}
+
+		// The API doesn't support selecting on any field, so neither does
+		// the informer
+		_, err = adapter.Search(ctx, scope, `{"fieldSelector":"spec.priorityClassName=high"}`, false)
+
+		if err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+
+	t.Run("Watch updates", func(t *testing.T) {
+		select {
+		case <-watcherStarted:
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for watch to start")
+		}
+
+		_, err := cs.CoreV1().Pods("default").Create(ctx, newTestPod("baz", nil), metav1.CreateOptions{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = WaitFor(5*time.Second, func() bool {
+			_, err := adapter.Get(ctx, scope, "baz", false)
+			return err == nil
+		})
+
+		if err != nil {
+			t.Error("created pod was never seen by the informer")
+		}
+
+		err = cs.CoreV1().Pods("default").Delete(ctx, "foo", metav1.DeleteOptions{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = WaitFor(5*time.Second, func() bool {
+			_, err := adapter.Get(ctx, scope, "foo", false)
+			return err != nil
+		})
+
+		if err != nil {
+			t.Error("deleted pod was never removed from the informer")
+		}
+	})
+
+	t.Run("Bad scope", func(t *testing.T) {
+		_, err := adapter.List(ctx, "test-cluster", false)
+
+		if err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+}
+
+func TestStopInformers(t *testing.T) {
+	adapter, _, _ := createInformerAdapter(newTestPod("foo", nil))
+
+	if !adapter.informersEnabled() {
+		t.Fatal("expected informers to be enabled")
+	}
+
+	_, err := adapter.List(context.Background(), "test-cluster.default", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adapter.StopInformers()
+
+	if adapter.informersEnabled() {
+		t.Error("expected informers to be disabled")
+	}
+
+	// Stopping twice should be safe
+	adapter.StopInformers()
+}
+
+func TestInformerNamespaces(t *testing.T) {
+	other := newTestPod("other", nil)
+	other.Namespace = "other"
+
+	adapter, _, _ := createInformerAdapter(newTestPod("foo", nil), other)
+	defer adapter.StopInformers()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	items, err := adapter.List(ctx, "test-cluster.default", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 1 || items[0].UniqueAttributeValue() != "foo" {
+		t.Errorf("expected only foo, got %v", items)
+	}
+
+	t.Run("Namespace that isn't being discovered", func(t *testing.T) {
+		_, err := adapter.List(ctx, "test-cluster.other", false)
+
+		var qErr *sdp.QueryError
+
+		if !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOSCOPE {
+			t.Errorf("expected NOSCOPE error, got %v", err)
+		}
+	})
+
+	t.Run("Added namespace", func(t *testing.T) {
+		adapter.AddNamespace("other")
+
+		items, err := adapter.List(ctx, "test-cluster.other", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 || items[0].UniqueAttributeValue() != "other" {
+			t.Errorf("expected only other, got %v", items)
+		}
+
+		if watched := adapter.watchedNamespaces(); len(watched) != 1 {
+			t.Errorf("expected a single informer for all namespaces, got %v", len(watched))
+		}
+	})
+}
+
+func TestInformerFailedSync(t *testing.T) {
+	adapter, cs, _ := createInformerAdapter(newTestPod("foo", nil))
+	defer adapter.StopInformers()
+
+	var failures int
+
+	cs.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failures < 1 {
+			failures++
+			return true, nil, errors.New("connection refused")
+		}
+
+		return false, nil, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := adapter.List(ctx, "test-cluster.default", false)
+
+	if err == nil {
+		t.Fatal("expected error, got none")
+	}
+
+	if watched := adapter.watchedNamespaces(); len(watched) != 0 {
+		t.Errorf("expected the failed informer to be removed, got %v", len(watched))
+	}
+
+	// The next query should start a new informer rather than waiting on the
+	// one that failed
+	items, err := adapter.List(ctx, "test-cluster.default", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 1 {
+		t.Errorf("expected 1 item, got %v", len(items))
+	}
+}
+
+func TestInformerForbiddenClusterWide(t *testing.T) {
+	adapter, cs, _ := createInformerAdapter(newTestPod("foo", nil))
+	defer adapter.StopInformers()
+
+	cs.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == metav1.NamespaceAll {
+			return true, nil, k8serr.NewForbidden(action.GetResource().GroupResource(), "", errors.New("not allowed"))
+		}
+
+		return false, nil, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	items, err := adapter.List(ctx, "test-cluster.default", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 1 {
+		t.Errorf("expected 1 item, got %v", len(items))
+	}
+
+	if watched := adapter.watchedNamespaces(); !slices.Contains(watched, "default") {
+		t.Errorf("expected an informer for the default namespace, got %v", watched)
+	}
+
+	adapter.RemoveNamespace("default")
+
+	if watched := adapter.watchedNamespaces(); len(watched) != 0 {
+		t.Errorf("expected the informer to be stopped when the namespace was removed, got %v", len(watched))
+	}
+}
@@ -11,13 +11,20 @@ import (
 func ingressExtractor(resource *v1.Ingress, scope string) ([]*sdp.LinkedItemQuery, error) {
 	queries := make([]*sdp.LinkedItemQuery, 0)
 
+	sd, err := ParseScope(scope, true)
+
+	if err != nil {
+		return nil, err
+	}
+
 	if resource.Spec.IngressClassName != nil {
 		queries = append(queries, &sdp.LinkedItemQuery{
 			Query: &sdp.Query{
 				Type:   "IngressClass",
 				Method: sdp.QueryMethod_GET,
 				Query:  *resource.Spec.IngressClassName,
-				Scope:  scope,
+				// Ingress classes are cluster-wide
+				Scope: sd.ClusterName,
 			},
 			BlastPropagation: &sdp.BlastPropagation{
 				// Changes to the ingress (e.g. nginx) class can affect the
@@ -124,10 +131,50 @@ func ingressExtractor(resource *v1.Ingress, scope string) ([]*sdp.LinkedItemQuer
 		}
 	}
 
+	hostnames := make([]string, 0)
+
+	for _, ingress := range resource.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			queries = append(queries, &sdp.LinkedItemQuery{
+				Query: &sdp.Query{
+					Type:   "ip",
+					Method: sdp.QueryMethod_GET,
+					Query:  ingress.IP,
+					Scope:  "global",
+				},
+				BlastPropagation: &sdp.BlastPropagation{
+					// IPs are always bidirectional
+					In:  true,
+					Out: true,
+				},
+			})
+		}
+
+		if ingress.Hostname != "" {
+			hostnames = append(hostnames, ingress.Hostname)
+
+			queries = append(queries, &sdp.LinkedItemQuery{
+				Query: &sdp.Query{
+					Type:   "dns",
+					Method: sdp.QueryMethod_SEARCH,
+					Query:  ingress.Hostname,
+					Scope:  "global",
+				},
+				BlastPropagation: &sdp.BlastPropagation{
+					// DNS is always bidirectional
+					In:  true,
+					Out: true,
+				},
+			})
+		}
+	}
+
+	queries = append(queries, ingressLoadBalancerQueries(resource.Annotations, hostnames)...)
+
 	return queries, nil
 }
 
-func newIngressAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func newIngressAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.Ingress, *v1.IngressList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -150,10 +197,27 @@ func newIngressAdapter(cs *kubernetes.Clientset, cluster string, namespaces []st
 }
 
 var ingressAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "Ingress",
-	DescriptiveName:       "Ingress",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_NETWORK,
-	PotentialLinks:        []string{"Service", "IngressClass", "dns"},
+	Type:            "Ingress",
+	DescriptiveName: "Ingress",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_NETWORK,
+	PotentialLinks: []string{
+		"Service",
+		"IngressClass",
+		"dns",
+		"ip",
+		"elbv2-load-balancer",
+		"elbv2-target-group",
+		"acm-certificate",
+		"ec2-security-group",
+		"ec2-subnet",
+		"gcp-compute-global-forwarding-rule",
+		"gcp-compute-target-http-proxy",
+		"gcp-compute-target-https-proxy",
+		"gcp-compute-url-map",
+		"gcp-compute-ssl-certificate",
+		"gcp-compute-global-address",
+		"gcp-compute-backend-service",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Ingress"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
@@ -0,0 +1,87 @@
+package adapters
+
+import (
+	v1 "k8s.io/api/networking/v1"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	"k8s.io/client-go/kubernetes"
+)
+
+func ingressClassExtractor(resource *v1.IngressClass, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	if params := resource.Spec.Parameters; params != nil {
+		sd, err := ParseScope(scope, false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Parameters are cluster-wide unless the scope says otherwise
+		if params.Scope != nil && *params.Scope == v1.IngressClassParametersReferenceScopeNamespace && params.Namespace != nil {
+			sd.Namespace = *params.Namespace
+		}
+
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   params.Kind,
+				Method: sdp.QueryMethod_GET,
+				Query:  params.Name,
+				Scope:  sd.String(),
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// The parameters configure the controller for every ingress
+				// of this class
+				In: true,
+				// Changing the class won't affect its parameters
+				Out: false,
+			},
+		})
+	}
+
+	return queries, nil
+}
+
+func newIngressClassAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &KubeTypeAdapter[*v1.IngressClass, *v1.IngressClassList]{
+		ClusterName: cluster,
+		Namespaces:  namespaces,
+		TypeName:    "IngressClass",
+		ClusterInterfaceBuilder: func() ItemInterface[*v1.IngressClass, *v1.IngressClassList] {
+			return cs.NetworkingV1().IngressClasses()
+		},
+		ListExtractor: func(list *v1.IngressClassList) ([]*v1.IngressClass, error) {
+			extracted := make([]*v1.IngressClass, len(list.Items))
+
+			for i := range list.Items {
+				extracted[i] = &list.Items[i]
+			}
+
+			return extracted, nil
+		},
+		LinkedItemQueryExtractor: ingressClassExtractor,
+		AdapterMetadata:          ingressClassAdapterMetadata,
+	}
+}
+
+var ingressClassAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:                  "IngressClass",
+	DescriptiveName:       "Ingress Class",
+	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_NETWORK,
+	SupportedQueryMethods: DefaultSupportedQueryMethods("Ingress Class"),
+	TerraformMappings: []*sdp.TerraformMapping{
+		{
+			TerraformMethod:   sdp.QueryMethod_GET,
+			TerraformQueryMap: "kubernetes_ingress_class_v1.metadata[0].name",
+		},
+		{
+			TerraformMethod:   sdp.QueryMethod_GET,
+			TerraformQueryMap: "kubernetes_ingress_class.metadata[0].name",
+		},
+	},
+})
+
+func init() {
+	registerAdapterLoader(newIngressClassAdapter)
+}
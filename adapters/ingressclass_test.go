@@ -0,0 +1,47 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+)
+
+var ingressClassYAML = `
+apiVersion: networking.k8s.io/v1
+kind: IngressClass
+metadata:
+  name: ingress-class-test
+spec:
+  controller: example.com/ingress-controller
+  parameters:
+    apiGroup: k8s.example.com
+    kind: IngressParameters
+    name: external-lb
+    namespace: default
+    scope: Namespace
+`
+
+func TestIngressClassAdapter(t *testing.T) {
+	sd := ScopeDetails{
+		ClusterName: CurrentCluster.Name,
+	}
+
+	adapter := newIngressClassAdapter(CurrentCluster.ClientSet, sd.ClusterName, []string{"default"})
+
+	st := AdapterTests{
+		Adapter:   adapter,
+		GetQuery:  "ingress-class-test",
+		GetScope:  sd.String(),
+		SetupYAML: ingressClassYAML,
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "IngressParameters",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "external-lb",
+				ExpectedScope:  sd.String() + ".default",
+			},
+		},
+	}
+
+	st.Execute(t)
+}
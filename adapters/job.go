@@ -2,6 +2,7 @@ package adapters
 
 import (
 	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/overmindtech/discovery"
 	"github.com/overmindtech/sdp-go"
@@ -28,10 +29,37 @@ func jobExtractor(resource *v1.Job, scope string) ([]*sdp.LinkedItemQuery, error
 		})
 	}
 
+	// Link to everything that the pods will use, even if none are running
+	podQueries, err := PodSpecExtractor(&resource.Spec.Template.Spec, scope)
+
+	if err != nil {
+		return nil, err
+	}
+
+	queries = append(queries, podQueries...)
+
 	return queries, nil
 }
 
-func newJobAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func jobHealthExtractor(resource *v1.Job) *sdp.Health {
+	for _, condition := range resource.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		switch condition.Type {
+		case v1.JobFailed, v1.JobFailureTarget:
+			return sdp.Health_HEALTH_ERROR.Enum()
+		case v1.JobComplete, v1.JobSuccessCriteriaMet:
+			return sdp.Health_HEALTH_OK.Enum()
+		}
+	}
+
+	// The job is either running or waiting to run
+	return sdp.Health_HEALTH_PENDING.Enum()
+}
+
+func newJobAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.Job, *v1.JobList]{
 		ClusterName:      cluster,
 		Namespaces:       namespaces,
@@ -50,15 +78,27 @@ func newJobAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string
 			return extracted, nil
 		},
 		LinkedItemQueryExtractor: jobExtractor,
+		HealthExtractor:          jobHealthExtractor,
 		AdapterMetadata:          jobAdapterMetadata,
 	}
 }
 
 var jobAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "Job",
-	DescriptiveName:       "Job",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
-	PotentialLinks:        []string{"Pod"},
+	Type:            "Job",
+	DescriptiveName: "Job",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
+	PotentialLinks: []string{
+		"Pod",
+		"ConfigMap",
+		"ContainerImage",
+		"dns",
+		"ec2-volume",
+		"ip",
+		"PersistentVolumeClaim",
+		"PriorityClass",
+		"Secret",
+		"ServiceAccount",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Job"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
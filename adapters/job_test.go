@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 var jobYAML = `
@@ -66,3 +68,36 @@ func TestJobAdapter(t *testing.T) {
 
 	st.Execute(t)
 }
+
+func TestJobHealthExtractor(t *testing.T) {
+	job := func(conditions ...v1.JobCondition) *v1.Job {
+		return &v1.Job{
+			Status: v1.JobStatus{
+				Conditions: conditions,
+			},
+		}
+	}
+
+	HealthTests[*v1.Job]{
+		{
+			Name:           "complete",
+			Resource:       job(v1.JobCondition{Type: v1.JobComplete, Status: corev1.ConditionTrue}),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "failed",
+			Resource:       job(v1.JobCondition{Type: v1.JobFailed, Status: corev1.ConditionTrue}),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name:           "running",
+			Resource:       job(),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name:           "not failed",
+			Resource:       job(v1.JobCondition{Type: v1.JobFailed, Status: corev1.ConditionFalse}),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+	}.Execute(t, jobHealthExtractor)
+}
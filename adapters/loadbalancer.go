@@ -0,0 +1,310 @@
+package adapters
+
+import (
+	"encoding/json"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/overmindtech/sdp-go"
+)
+
+// This file contains the logic for linking LoadBalancer services and ingresses
+// to the cloud load balancers that are created for them. The cloud
+// controllers don't store the ID of the load balancer anywhere, so we have to
+// rely on the format of the hostnames that they are given and the annotations
+// that are used to configure them
+
+var (
+	// Classic and application load balancers e.g.
+	// internal-my-lb-1234567890.eu-west-2.elb.amazonaws.com
+	awsELBHostnameRegex = regexp.MustCompile(`^(?:internal-)?(.+)-[0-9a-zA-Z]+\.[a-z0-9-]+\.elb\.amazonaws\.com(?:\.cn)?$`)
+	// Network load balancers e.g.
+	// my-lb-0123456789abcdef.elb.eu-west-2.amazonaws.com
+	awsNLBHostnameRegex = regexp.MustCompile(`^(?:internal-)?(.+)-[0-9a-zA-Z]+\.elb\.[a-z0-9-]+\.amazonaws\.com(?:\.cn)?$`)
+)
+
+// Annotations used by the in-tree AWS cloud provider and the AWS Load Balancer
+// Controller to configure load balancers for services
+const (
+	awsServiceLBNameAnnotation              = "service.beta.kubernetes.io/aws-load-balancer-name"
+	awsServiceSSLCertAnnotation             = "service.beta.kubernetes.io/aws-load-balancer-ssl-cert"
+	awsServiceSecurityGroupsAnnotation      = "service.beta.kubernetes.io/aws-load-balancer-security-groups"
+	awsServiceExtraSecurityGroupsAnnotation = "service.beta.kubernetes.io/aws-load-balancer-extra-security-groups"
+	awsServiceSubnetsAnnotation             = "service.beta.kubernetes.io/aws-load-balancer-subnets"
+)
+
+// Annotations used by the AWS Load Balancer Controller to configure
+// application load balancers for ingresses
+const (
+	albLoadBalancerNameAnnotation = "alb.ingress.kubernetes.io/load-balancer-name"
+	albCertificateARNAnnotation   = "alb.ingress.kubernetes.io/certificate-arn"
+	albSecurityGroupsAnnotation   = "alb.ingress.kubernetes.io/security-groups"
+	albSubnetsAnnotation          = "alb.ingress.kubernetes.io/subnets"
+	albActionsAnnotationPrefix    = "alb.ingress.kubernetes.io/actions."
+)
+
+// Annotations that GKE sets on services once it has created the load balancer
+// resources for them
+var gcpServiceAnnotationTypes = map[string]string{
+	"service.kubernetes.io/tcp-forwarding-rule":  "gcp-compute-forwarding-rule",
+	"service.kubernetes.io/udp-forwarding-rule":  "gcp-compute-forwarding-rule",
+	"service.kubernetes.io/backend-service":      "gcp-compute-backend-service",
+	"service.kubernetes.io/healthcheck":          "gcp-compute-health-check",
+	"service.kubernetes.io/firewall-rule":        "gcp-compute-firewall",
+	"service.kubernetes.io/firewall-rule-for-hc": "gcp-compute-firewall",
+}
+
+// Annotations that the GCE ingress controller sets on ingresses once it has
+// created the load balancer resources for them, plus the ones that users set
+// to reference existing resources. Some of these can contain a comma-separated
+// list
+var gcpIngressAnnotationTypes = map[string]string{
+	"ingress.kubernetes.io/forwarding-rule":       "gcp-compute-global-forwarding-rule",
+	"ingress.kubernetes.io/https-forwarding-rule": "gcp-compute-global-forwarding-rule",
+	"ingress.kubernetes.io/target-proxy":          "gcp-compute-target-http-proxy",
+	"ingress.kubernetes.io/https-target-proxy":    "gcp-compute-target-https-proxy",
+	"ingress.kubernetes.io/url-map":               "gcp-compute-url-map",
+	"ingress.kubernetes.io/ssl-cert":              "gcp-compute-ssl-certificate",
+	"ingress.gcp.kubernetes.io/pre-shared-cert":   "gcp-compute-ssl-certificate",
+	"kubernetes.io/ingress.global-static-ip-name": "gcp-compute-global-address",
+}
+
+// Contains a JSON map of backend service name to health status
+const gcpIngressBackendsAnnotation = "ingress.kubernetes.io/backends"
+
+// Annotations used by the Azure cloud provider to configure load balancers
+// for services
+const (
+	azureServiceAnnotationPrefix   = "service.beta.kubernetes.io/azure-"
+	azureServiceInternalAnnotation = "service.beta.kubernetes.io/azure-load-balancer-internal"
+	azureServicePIPNameAnnotation  = "service.beta.kubernetes.io/azure-pip-name"
+)
+
+// loadBalancerQueries Collects queries for cloud load balancer resources,
+// ignoring duplicates since the same load balancer can be found through both
+// its hostname and annotations
+type loadBalancerQueries struct {
+	queries []*sdp.LinkedItemQuery
+	seen    map[string]bool
+}
+
+func (l *loadBalancerQueries) add(typ string, method sdp.QueryMethod, query string, blastPropagation *sdp.BlastPropagation) {
+	query = strings.TrimSpace(query)
+
+	if query == "" {
+		return
+	}
+
+	if l.seen == nil {
+		l.seen = make(map[string]bool)
+	}
+
+	key := typ + "/" + query
+
+	if l.seen[key] {
+		return
+	}
+
+	l.seen[key] = true
+
+	l.queries = append(l.queries, &sdp.LinkedItemQuery{
+		Query: &sdp.Query{
+			Type:   typ,
+			Method: method,
+			Query:  query,
+			Scope:  "*",
+		},
+		BlastPropagation: blastPropagation,
+	})
+}
+
+// addLoadBalancer Links to the load balancer itself. The load balancer is
+// managed by kubernetes, and is how traffic gets to the service, so changes
+// go both ways
+func (l *loadBalancerQueries) addLoadBalancer(typ string, name string) {
+	l.add(typ, sdp.QueryMethod_GET, name, &sdp.BlastPropagation{
+		In:  true,
+		Out: true,
+	})
+}
+
+// addDependency Links to something that the load balancer uses but that
+// kubernetes doesn't manage e.g. a certificate or security group
+func (l *loadBalancerQueries) addDependency(typ string, method sdp.QueryMethod, query string) {
+	l.add(typ, method, query, &sdp.BlastPropagation{
+		// Changes to the dependency will affect the load balancer
+		In: true,
+		// Changes to the load balancer won't affect the dependency
+		Out: false,
+	})
+}
+
+// addAWSCertificates Links to ACM certificates from a comma-separated list of
+// ARNs. IAM server certificates can also be used, but aren't linked
+func (l *loadBalancerQueries) addAWSCertificates(list string) {
+	for _, arn := range strings.Split(list, ",") {
+		if strings.Contains(arn, ":acm:") {
+			l.addDependency("acm-certificate", sdp.QueryMethod_SEARCH, arn)
+		}
+	}
+}
+
+// addAWSIDs Links to AWS resources from a comma-separated list that can
+// contain either IDs or names. Only IDs are linked since names aren't unique
+func (l *loadBalancerQueries) addAWSIDs(typ string, prefix string, list string) {
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+
+		if strings.HasPrefix(id, prefix) {
+			l.addDependency(typ, sdp.QueryMethod_GET, id)
+		}
+	}
+}
+
+// awsLoadBalancerName Parses the name of an AWS load balancer from its
+// hostname. Classic and application load balancers use the same format, so
+// the caller needs to know which one to expect
+func awsLoadBalancerName(hostname string) (name string, network bool, ok bool) {
+	if matches := awsNLBHostnameRegex.FindStringSubmatch(hostname); matches != nil {
+		return matches[1], true, true
+	}
+
+	if matches := awsELBHostnameRegex.FindStringSubmatch(hostname); matches != nil {
+		return matches[1], false, true
+	}
+
+	return "", false, false
+}
+
+// serviceLoadBalancerQueries Links a LoadBalancer service to the cloud
+// resources that make up its load balancer
+func serviceLoadBalancerQueries(annotations map[string]string, hostnames []string) []*sdp.LinkedItemQuery {
+	var l loadBalancerQueries
+
+	// AWS
+	for _, hostname := range hostnames {
+		if name, network, ok := awsLoadBalancerName(hostname); ok {
+			if network {
+				l.addLoadBalancer("elbv2-load-balancer", name)
+			} else {
+				// Services that aren't NLBs get a classic load balancer
+				l.addLoadBalancer("elb-load-balancer", name)
+			}
+		}
+	}
+
+	if name := annotations[awsServiceLBNameAnnotation]; name != "" {
+		// Only the AWS Load Balancer Controller supports setting the name,
+		// and it only creates NLBs
+		l.addLoadBalancer("elbv2-load-balancer", name)
+	}
+
+	l.addAWSCertificates(annotations[awsServiceSSLCertAnnotation])
+	l.addAWSIDs("ec2-security-group", "sg-", annotations[awsServiceSecurityGroupsAnnotation])
+	l.addAWSIDs("ec2-security-group", "sg-", annotations[awsServiceExtraSecurityGroupsAnnotation])
+	l.addAWSIDs("ec2-subnet", "subnet-", annotations[awsServiceSubnetsAnnotation])
+
+	// GCP
+	// Maps are sorted so that the order of the queries is stable
+	for _, annotation := range slices.Sorted(maps.Keys(gcpServiceAnnotationTypes)) {
+		l.addLoadBalancer(gcpServiceAnnotationTypes[annotation], annotations[annotation])
+	}
+
+	// Azure
+	for annotation := range annotations {
+		if strings.HasPrefix(annotation, azureServiceAnnotationPrefix) {
+			// AKS puts all services into one of two shared load balancers,
+			// depending on whether they are internal
+			if annotations[azureServiceInternalAnnotation] == "true" {
+				l.addLoadBalancer("azure-network-load-balancer", "kubernetes-internal")
+			} else {
+				l.addLoadBalancer("azure-network-load-balancer", "kubernetes")
+			}
+
+			break
+		}
+	}
+
+	l.addLoadBalancer("azure-network-public-ip-address", annotations[azureServicePIPNameAnnotation])
+
+	return l.queries
+}
+
+// albAction The parts of an AWS Load Balancer Controller action that
+// reference target groups. Actions are configured with the annotation
+// alb.ingress.kubernetes.io/actions.${action-name}
+type albAction struct {
+	Type           string `json:"type"`
+	TargetGroupARN string `json:"targetGroupARN"`
+	ForwardConfig  *struct {
+		TargetGroups []struct {
+			TargetGroupARN string `json:"targetGroupARN"`
+		} `json:"targetGroups"`
+	} `json:"forwardConfig"`
+}
+
+// ingressLoadBalancerQueries Links an ingress to the cloud resources that
+// make up its load balancer
+func ingressLoadBalancerQueries(annotations map[string]string, hostnames []string) []*sdp.LinkedItemQuery {
+	var l loadBalancerQueries
+
+	// AWS
+	for _, hostname := range hostnames {
+		if name, _, ok := awsLoadBalancerName(hostname); ok {
+			// Ingresses always get an application load balancer
+			l.addLoadBalancer("elbv2-load-balancer", name)
+		}
+	}
+
+	l.addLoadBalancer("elbv2-load-balancer", annotations[albLoadBalancerNameAnnotation])
+	l.addAWSCertificates(annotations[albCertificateARNAnnotation])
+	l.addAWSIDs("ec2-security-group", "sg-", annotations[albSecurityGroupsAnnotation])
+	l.addAWSIDs("ec2-subnet", "subnet-", annotations[albSubnetsAnnotation])
+
+	for _, annotation := range slices.Sorted(maps.Keys(annotations)) {
+		if !strings.HasPrefix(annotation, albActionsAnnotationPrefix) {
+			continue
+		}
+
+		var action albAction
+
+		// Ignore actions that we can't parse, the controller will report
+		// these as errors
+		if err := json.Unmarshal([]byte(annotations[annotation]), &action); err != nil {
+			continue
+		}
+
+		if action.Type != "forward" {
+			continue
+		}
+
+		l.addDependency("elbv2-target-group", sdp.QueryMethod_SEARCH, action.TargetGroupARN)
+
+		if action.ForwardConfig != nil {
+			for _, targetGroup := range action.ForwardConfig.TargetGroups {
+				l.addDependency("elbv2-target-group", sdp.QueryMethod_SEARCH, targetGroup.TargetGroupARN)
+			}
+		}
+	}
+
+	// GCP
+	for _, annotation := range slices.Sorted(maps.Keys(gcpIngressAnnotationTypes)) {
+		for _, name := range strings.Split(annotations[annotation], ",") {
+			l.addLoadBalancer(gcpIngressAnnotationTypes[annotation], name)
+		}
+	}
+
+	if backends := annotations[gcpIngressBackendsAnnotation]; backends != "" {
+		var statuses map[string]string
+
+		if err := json.Unmarshal([]byte(backends), &statuses); err == nil {
+			for _, name := range slices.Sorted(maps.Keys(statuses)) {
+				l.addLoadBalancer("gcp-compute-backend-service", name)
+			}
+		}
+	}
+
+	return l.queries
+}
@@ -0,0 +1,275 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+)
+
+func TestAWSLoadBalancerName(t *testing.T) {
+	tests := []struct {
+		hostname string
+		name     string
+		network  bool
+		ok       bool
+	}{
+		{
+			hostname: "my-lb-1234567890.eu-west-2.elb.amazonaws.com",
+			name:     "my-lb",
+			ok:       true,
+		},
+		{
+			hostname: "internal-k8s-default-myingres-abc123def4-1234567890.eu-west-2.elb.amazonaws.com",
+			name:     "k8s-default-myingres-abc123def4",
+			ok:       true,
+		},
+		{
+			hostname: "a1b2c3d4e5f6-0123456789abcdef.elb.eu-west-2.amazonaws.com",
+			name:     "a1b2c3d4e5f6",
+			network:  true,
+			ok:       true,
+		},
+		{
+			hostname: "my-lb-1234567890.cn-north-1.elb.amazonaws.com.cn",
+			name:     "my-lb",
+			ok:       true,
+		},
+		{
+			hostname: "my-app.example.com",
+		},
+		{
+			hostname: "my-lb.eu-west-2.cloudapp.azure.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hostname, func(t *testing.T) {
+			name, network, ok := awsLoadBalancerName(tt.hostname)
+
+			if ok != tt.ok {
+				t.Fatalf("expected ok to be %v, got %v", tt.ok, ok)
+			}
+
+			if name != tt.name {
+				t.Errorf("expected name %v, got %v", tt.name, name)
+			}
+
+			if network != tt.network {
+				t.Errorf("expected network to be %v, got %v", tt.network, network)
+			}
+		})
+	}
+}
+
+func TestServiceLoadBalancerQueries(t *testing.T) {
+	t.Run("AWS classic", func(t *testing.T) {
+		queries := serviceLoadBalancerQueries(map[string]string{
+			"service.beta.kubernetes.io/aws-load-balancer-ssl-cert":              "arn:aws:acm:eu-west-2:123456789012:certificate/abc,arn:aws:iam::123456789012:server-certificate/legacy",
+			"service.beta.kubernetes.io/aws-load-balancer-extra-security-groups": "sg-0123, my-named-group",
+		}, []string{"my-lb-1234567890.eu-west-2.elb.amazonaws.com"})
+
+		if len(queries) != 3 {
+			t.Fatalf("expected 3 queries, got %v", len(queries))
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "elb-load-balancer",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "my-lb",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "acm-certificate",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  "arn:aws:acm:eu-west-2:123456789012:certificate/abc",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "ec2-security-group",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "sg-0123",
+				ExpectedScope:  "*",
+			},
+		}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+	})
+
+	t.Run("AWS network", func(t *testing.T) {
+		queries := serviceLoadBalancerQueries(map[string]string{
+			"service.beta.kubernetes.io/aws-load-balancer-name":    "my-nlb",
+			"service.beta.kubernetes.io/aws-load-balancer-subnets": "subnet-0123,subnet-4567",
+		}, []string{"my-nlb-0123456789abcdef.elb.eu-west-2.amazonaws.com"})
+
+		// The name from the hostname and the annotation are the same so
+		// should only be linked once
+		if len(queries) != 3 {
+			t.Fatalf("expected 3 queries, got %v", len(queries))
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "elbv2-load-balancer",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "my-nlb",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "ec2-subnet",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "subnet-4567",
+				ExpectedScope:  "*",
+			},
+		}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+	})
+
+	t.Run("GCP", func(t *testing.T) {
+		queries := serviceLoadBalancerQueries(map[string]string{
+			"service.kubernetes.io/tcp-forwarding-rule": "k8s2-tcp-abc",
+			"service.kubernetes.io/backend-service":     "k8s2-abc",
+		}, nil)
+
+		if len(queries) != 2 {
+			t.Fatalf("expected 2 queries, got %v", len(queries))
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "gcp-compute-forwarding-rule",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "k8s2-tcp-abc",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "gcp-compute-backend-service",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "k8s2-abc",
+				ExpectedScope:  "*",
+			},
+		}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+	})
+
+	t.Run("Azure", func(t *testing.T) {
+		queries := serviceLoadBalancerQueries(map[string]string{
+			"service.beta.kubernetes.io/azure-load-balancer-internal": "true",
+			"service.beta.kubernetes.io/azure-pip-name":               "my-pip",
+		}, nil)
+
+		if len(queries) != 2 {
+			t.Fatalf("expected 2 queries, got %v", len(queries))
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "azure-network-load-balancer",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "kubernetes-internal",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "azure-network-public-ip-address",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "my-pip",
+				ExpectedScope:  "*",
+			},
+		}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		queries := serviceLoadBalancerQueries(map[string]string{
+			"app": "foo",
+		}, []string{"my-lb.example.com"})
+
+		if len(queries) != 0 {
+			t.Errorf("expected no queries, got %v", queries)
+		}
+	})
+}
+
+func TestIngressLoadBalancerQueries(t *testing.T) {
+	t.Run("AWS", func(t *testing.T) {
+		queries := ingressLoadBalancerQueries(map[string]string{
+			"alb.ingress.kubernetes.io/certificate-arn": "arn:aws:acm:eu-west-2:123456789012:certificate/abc",
+			"alb.ingress.kubernetes.io/security-groups": "sg-0123",
+			"alb.ingress.kubernetes.io/actions.forward": `{"type":"forward","forwardConfig":{"targetGroups":[{"targetGroupARN":"arn:aws:elasticloadbalancing:eu-west-2:123456789012:targetgroup/tg1/abc"}]}}`,
+			"alb.ingress.kubernetes.io/actions.legacy":  `{"type":"forward","targetGroupARN":"arn:aws:elasticloadbalancing:eu-west-2:123456789012:targetgroup/tg2/def"}`,
+			"alb.ingress.kubernetes.io/actions.broken":  `{`,
+		}, []string{"k8s-default-myingres-abc123def4-1234567890.eu-west-2.elb.amazonaws.com"})
+
+		if len(queries) != 5 {
+			t.Fatalf("expected 5 queries, got %v", len(queries))
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "elbv2-load-balancer",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "k8s-default-myingres-abc123def4",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "acm-certificate",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  "arn:aws:acm:eu-west-2:123456789012:certificate/abc",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "ec2-security-group",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "sg-0123",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "elbv2-target-group",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  "arn:aws:elasticloadbalancing:eu-west-2:123456789012:targetgroup/tg1/abc",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "elbv2-target-group",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  "arn:aws:elasticloadbalancing:eu-west-2:123456789012:targetgroup/tg2/def",
+				ExpectedScope:  "*",
+			},
+		}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+	})
+
+	t.Run("GCP", func(t *testing.T) {
+		queries := ingressLoadBalancerQueries(map[string]string{
+			"ingress.kubernetes.io/https-forwarding-rule": "k8s2-fs-abc",
+			"ingress.kubernetes.io/url-map":               "k8s2-um-abc",
+			"ingress.gcp.kubernetes.io/pre-shared-cert":   "cert-a,cert-b",
+			"ingress.kubernetes.io/backends":              `{"k8s1-abc-default-my-svc-80-def":"HEALTHY"}`,
+		}, nil)
+
+		if len(queries) != 5 {
+			t.Fatalf("expected 5 queries, got %v", len(queries))
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "gcp-compute-global-forwarding-rule",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "k8s2-fs-abc",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "gcp-compute-url-map",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "k8s2-um-abc",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "gcp-compute-ssl-certificate",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "cert-b",
+				ExpectedScope:  "*",
+			},
+			{
+				ExpectedType:   "gcp-compute-backend-service",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "k8s1-abc-default-my-svc-80-def",
+				ExpectedScope:  "*",
+			},
+		}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+	})
+}
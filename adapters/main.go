@@ -1,11 +1,14 @@
 package adapters
 
 import (
+	"slices"
+	"strings"
+
 	"github.com/overmindtech/discovery"
 	"k8s.io/client-go/kubernetes"
 )
 
-type AdapterLoader func(clientSet *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter
+type AdapterLoader func(clientSet kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter
 
 var adapterLoaders []AdapterLoader
 
@@ -13,12 +16,164 @@ func registerAdapterLoader(loader AdapterLoader) {
 	adapterLoaders = append(adapterLoaders, loader)
 }
 
-func LoadAllAdapters(cs *kubernetes.Clientset, cluster string, namespaces []string) []discovery.Adapter {
-	adapters := make([]discovery.Adapter, len(adapterLoaders))
+// LoadAllAdapters Creates all of the registered adapters whose types are
+// allowed by the filter. The filter is applied before the adapters that are
+// built on other types are linked to them, so that these never query a type
+// that has been filtered out
+func LoadAllAdapters(cs kubernetes.Interface, cluster string, namespaces []string, types TypeFilter) []discovery.Adapter {
+	return linkAdapters(types.Adapters(newAdapters(cs, cluster, namespaces)))
+}
+
+// BuiltInTypes Returns the types of all of the registered adapters, including
+// the ones that would be filtered out. Custom resources can't use these types
+func BuiltInTypes(cs kubernetes.Interface) []string {
+	adapterList := newAdapters(cs, "", nil)
+	types := make([]string, 0, len(adapterList))
+
+	for _, adapter := range adapterList {
+		types = append(types, adapter.Type())
+	}
+
+	return types
+}
+
+func newAdapters(cs kubernetes.Interface, cluster string, namespaces []string) []discovery.Adapter {
+	adapters := make([]discovery.Adapter, 0, len(adapterLoaders))
 
-	for i, loader := range adapterLoaders {
-		adapters[i] = loader(cs, cluster, namespaces)
+	for _, loader := range adapterLoaders {
+		adapters = append(adapters, loader(cs, cluster, namespaces))
 	}
 
 	return adapters
 }
+
+// adapterLinker An adapter that is built on the adapters for other types, e.g.
+// EffectivePermissions uses the Role and ClusterRole adapters
+type adapterLinker interface {
+	// linkAdapters Returns false if an adapter that it can't work without
+	// isn't in the list, e.g. because its type was filtered out
+	linkAdapters(adapterList []discovery.Adapter) bool
+}
+
+// linkAdapters Gives the adapters that are built on other types the adapters
+// that were loaded for those types, so that they use the same caches and
+// informers, and follow the same namespace updates. Adapters that can't be
+// linked are removed, since they would have to query types that aren't loaded
+func linkAdapters(adapterList []discovery.Adapter) []discovery.Adapter {
+	linked := make([]discovery.Adapter, 0, len(adapterList))
+
+	for _, adapter := range adapterList {
+		if linker, ok := adapter.(adapterLinker); ok && !linker.linkAdapters(adapterList) {
+			continue
+		}
+
+		linked = append(linked, adapter)
+	}
+
+	return linked
+}
+
+// linkedAdapter Returns the adapter for a type from a list, if it is there
+// and has the expected type
+func linkedAdapter[T discovery.Adapter](adapterList []discovery.Adapter, typeName string) (T, bool) {
+	for _, adapter := range adapterList {
+		if adapter.Type() != typeName {
+			continue
+		}
+
+		linked, ok := adapter.(T)
+
+		return linked, ok
+	}
+
+	var none T
+
+	return none, false
+}
+
+// itemScopes Returns the scopes that an adapter's items are in. This is all of
+// its scopes except for the cluster scope of namespaced adapters that have
+// `ClusterSearch`, which only serves searches
+func itemScopes(adapter discovery.Adapter) []string {
+	searchable, ok := adapter.(interface{ isClusterSearchScope(scope string) bool })
+
+	if !ok {
+		return adapter.Scopes()
+	}
+
+	return slices.DeleteFunc(adapter.Scopes(), searchable.isClusterSearchScope)
+}
+
+// NamespacedAdapters Returns only the adapters for namespaced types. This is
+// used when the source doesn't have permission to query anything cluster-wide
+func NamespacedAdapters(adapterList []discovery.Adapter) []discovery.Adapter {
+	namespaced := make([]discovery.Adapter, 0, len(adapterList))
+
+	for _, adapter := range adapterList {
+		if isNamespaced(adapter) {
+			namespaced = append(namespaced, adapter)
+		}
+	}
+
+	return namespaced
+}
+
+// TypeFilter Decides which types of items are discovered. This allows
+// sensitive types such as Secret to be kept out of discovery entirely
+type TypeFilter struct {
+	// If this is not empty, only these types will be discovered
+	Allow []string
+	// These types will never be discovered, even if they are allowed
+	Deny []string
+}
+
+// Allowed Returns whether items of the given type should be discovered
+func (f TypeFilter) Allowed(itemType string) bool {
+	if slices.Contains(f.Deny, itemType) {
+		return false
+	}
+
+	return len(f.Allow) == 0 || slices.Contains(f.Allow, itemType)
+}
+
+// Adapters Returns the adapters whose types are allowed
+func (f TypeFilter) Adapters(adapterList []discovery.Adapter) []discovery.Adapter {
+	allowed := make([]discovery.Adapter, 0, len(adapterList))
+
+	for _, adapter := range adapterList {
+		if f.Allowed(adapter.Type()) {
+			allowed = append(allowed, adapter)
+		}
+	}
+
+	return allowed
+}
+
+// String Describes the filter, this is blank if everything is allowed
+func (f TypeFilter) String() string {
+	var parts []string
+
+	if len(f.Allow) > 0 {
+		parts = append(parts, "allow="+strings.Join(f.Allow, ","))
+	}
+
+	if len(f.Deny) > 0 {
+		parts = append(parts, "deny="+strings.Join(f.Deny, ","))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// InformerAdapter An adapter that can serve queries from a local store that is
+// kept in sync by watching the API, rather than polling it
+type InformerAdapter interface {
+	EnableInformers()
+	StopInformers()
+}
+
+// NamespaceUpdater An adapter whose namespaces can be changed while it is
+// running, without needing to be recreated
+type NamespaceUpdater interface {
+	AddNamespace(namespace string)
+	RemoveNamespace(namespace string)
+}
@@ -0,0 +1,131 @@
+package adapters
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/overmindtech/discovery"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTypeFilter(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Filter  TypeFilter
+		Type    string
+		Allowed bool
+	}{
+		{
+			Name:    "empty",
+			Type:    "Secret",
+			Allowed: true,
+		},
+		{
+			Name:    "denied",
+			Filter:  TypeFilter{Deny: []string{"Secret"}},
+			Type:    "Secret",
+			Allowed: false,
+		},
+		{
+			Name:    "not denied",
+			Filter:  TypeFilter{Deny: []string{"Secret"}},
+			Type:    "Pod",
+			Allowed: true,
+		},
+		{
+			Name:    "allowed",
+			Filter:  TypeFilter{Allow: []string{"Pod", "Deployment"}},
+			Type:    "Deployment",
+			Allowed: true,
+		},
+		{
+			Name:    "not allowed",
+			Filter:  TypeFilter{Allow: []string{"Pod", "Deployment"}},
+			Type:    "Secret",
+			Allowed: false,
+		},
+		{
+			Name:    "deny takes precedence",
+			Filter:  TypeFilter{Allow: []string{"Secret"}, Deny: []string{"Secret"}},
+			Type:    "Secret",
+			Allowed: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if allowed := test.Filter.Allowed(test.Type); allowed != test.Allowed {
+				t.Errorf("expected %v, got %v", test.Allowed, allowed)
+			}
+		})
+	}
+}
+
+func TestLoadAllAdapters(t *testing.T) {
+	cs := fake.NewClientset()
+
+	all := LoadAllAdapters(cs, "test", []string{"default"}, TypeFilter{})
+
+	if len(all) != len(adapterLoaders) {
+		t.Errorf("expected %v adapters, got %v", len(adapterLoaders), len(all))
+	}
+
+	filtered := LoadAllAdapters(cs, "test", []string{"default"}, TypeFilter{Deny: []string{"Secret"}})
+
+	if len(filtered) != len(all)-1 {
+		t.Errorf("expected %v adapters, got %v", len(all)-1, len(filtered))
+	}
+
+	for _, adapter := range filtered {
+		if adapter.Type() == "Secret" {
+			t.Error("expected Secret adapter to be filtered out")
+		}
+	}
+}
+
+func TestLoadAllAdaptersLinking(t *testing.T) {
+	cs := fake.NewClientset()
+
+	types := func(adapterList []discovery.Adapter) []string {
+		names := make([]string, 0, len(adapterList))
+
+		for _, adapter := range adapterList {
+			names = append(names, adapter.Type())
+		}
+
+		return names
+	}
+
+	// EffectivePermissions can't be worked out without roles, so it mustn't
+	// query them itself
+	withoutRoles := types(LoadAllAdapters(cs, "test", []string{"default"}, TypeFilter{Deny: []string{"Role"}}))
+
+	if slices.Contains(withoutRoles, "EffectivePermissions") {
+		t.Error("expected EffectivePermissions to be removed when Role is filtered out")
+	}
+
+	// ContainerImage is worked out from pods, so it mustn't list them when the
+	// Pod type is filtered out
+	withoutPods := types(LoadAllAdapters(cs, "test", []string{"default"}, TypeFilter{Deny: []string{"Pod"}}))
+
+	if slices.Contains(withoutPods, "ContainerImage") {
+		t.Error("expected ContainerImage to be removed when Pod is filtered out")
+	}
+
+	// NetworkPath can do without namespaces
+	withoutNamespaces := LoadAllAdapters(cs, "test", []string{"default"}, TypeFilter{Deny: []string{"Namespace"}})
+
+	path, ok := linkedAdapter[*networkPathAdapter](withoutNamespaces, "NetworkPath")
+
+	if !ok {
+		t.Fatal("expected NetworkPath to be loaded when Namespace is filtered out")
+	}
+
+	if path.namespaces != nil {
+		t.Error("expected NetworkPath not to use a Namespace adapter when Namespace is filtered out")
+	}
+
+	if !slices.Contains(BuiltInTypes(cs), "Role") {
+		t.Error("expected filtered types to still be built-in types")
+	}
+}
@@ -0,0 +1,526 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// ManifestAdapter An adapter that can convert objects from manifests into
+// items, using the same logic as it does for objects from the API
+type ManifestAdapter interface {
+	ManifestItem(obj *unstructured.Unstructured, namespace string) (*sdp.Item, error)
+	// The API group and kind of the objects that the adapter converts
+	GroupKind() (schema.GroupKind, error)
+}
+
+// ChangeType What would happen to an item if a set of manifests was applied
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeChanged ChangeType = "changed"
+	ChangeRemoved ChangeType = "removed"
+)
+
+// ManifestChange A single item that would be changed by applying a set of
+// manifests
+type ManifestChange struct {
+	Type ChangeType
+	// The live item, this is nil if the item would be added
+	Before *sdp.Item
+	// The item from the manifest, this is nil if the item would be removed
+	After *sdp.Item
+}
+
+// LinkedItemQueries Returns the queries for everything that is linked to the
+// item either before or after the change. Items that would no longer be
+// linked are included since they are affected by the change too
+func (c *ManifestChange) LinkedItemQueries() []*sdp.LinkedItemQuery {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+	seen := make(map[string]bool)
+
+	for _, item := range []*sdp.Item{c.After, c.Before} {
+		if item == nil {
+			continue
+		}
+
+		for _, q := range item.GetLinkedItemQueries() {
+			key := fmt.Sprintf("%v/%v/%v/%v", q.GetQuery().GetType(), q.GetQuery().GetMethod(), q.GetQuery().GetScope(), q.GetQuery().GetQuery())
+
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			queries = append(queries, q)
+		}
+	}
+
+	return queries
+}
+
+// ManifestDiff The result of comparing a set of manifests with a cluster
+type ManifestDiff struct {
+	Changes []*ManifestChange
+	// Objects from the manifests that there is no adapter for, so the effect
+	// of applying them is unknown
+	Unsupported []*unstructured.Unstructured
+	// Scopes that couldn't be searched for items to prune, e.g. because the
+	// source doesn't have permission. Anything in these that would be removed
+	// is missing from the changes
+	Unpruned []error
+}
+
+// SimulateOptions Options for `SimulateManifests`
+type SimulateOptions struct {
+	// The namespace to use for namespaced objects that don't specify one,
+	// like the `--namespace` flag for `kubectl apply`
+	Namespace string
+	// If this is set, live items that match this label selector but aren't
+	// in the manifests will be removed, like `kubectl apply --prune`. Only
+	// cluster-wide items and items in the namespaces of the manifests are
+	// considered. As with kubectl, items that weren't created with `kubectl
+	// apply` or that are owned by another object are never removed
+	PruneSelector string
+}
+
+// ReadManifests Reads objects from YAML or JSON manifests, such as the output
+// of `helm template` or `kustomize build`. Documents can be separated with
+// `---` and lists of objects are expanded
+func ReadManifests(r io.Reader) ([]*unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	objects := make([]*unstructured.Unstructured, 0)
+
+	for {
+		var raw runtime.RawExtension
+
+		err := decoder.Decode(&raw)
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not parse manifest: %w", err)
+		}
+
+		raw.Raw = bytes.TrimSpace(raw.Raw)
+
+		// Empty documents are common in generated manifests
+		if len(raw.Raw) == 0 || bytes.Equal(raw.Raw, []byte("null")) {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{}
+
+		err = obj.UnmarshalJSON(raw.Raw)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not parse manifest: %w", err)
+		}
+
+		if !obj.IsList() {
+			objects = append(objects, obj)
+			continue
+		}
+
+		err = obj.EachListItem(func(item runtime.Object) error {
+			u, ok := item.(*unstructured.Unstructured)
+
+			if !ok {
+				return fmt.Errorf("unexpected list item %T", item)
+			}
+
+			objects = append(objects, u)
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return objects, nil
+}
+
+// ManifestItem Converts an object from a manifest into an item. Namespaced
+// objects that don't have a namespace are put in the given namespace
+func (s *KubeTypeAdapter[Resource, ResourceList]) ManifestItem(obj *unstructured.Unstructured, namespace string) (*sdp.Item, error) {
+	obj = obj.DeepCopy()
+
+	if s.namespaced() && obj.GetNamespace() == "" {
+		obj.SetNamespace(namespace)
+	}
+
+	if !s.namespaced() {
+		obj.SetNamespace("")
+	}
+
+	err := applyStringData(obj)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Custom resources are already unstructured, everything else needs to be
+	// converted to its type so that the extractors can be run
+	resource, ok := any(obj).(Resource)
+
+	if !ok {
+		typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+
+		if err != nil {
+			return nil, err
+		}
+
+		resource, ok = typed.(Resource)
+
+		if !ok {
+			return nil, fmt.Errorf("%v is not a %v", obj.GroupVersionKind(), s.TypeName)
+		}
+
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not convert %v %v: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+
+	return s.resourceToItem(resource)
+}
+
+// GroupKind Returns the API group and kind of the objects that the adapter
+// converts, e.g. `Deployment.apps`. This is worked out from the type of the
+// resource
+func (s *KubeTypeAdapter[Resource, ResourceList]) GroupKind() (schema.GroupKind, error) {
+	gvk, err := s.groupVersionKind()
+
+	if err != nil {
+		return schema.GroupKind{}, err
+	}
+
+	return gvk.GroupKind(), nil
+}
+
+// GroupKind Returns the API group and kind of the custom resource
+func (s *customResourceAdapter) GroupKind() (schema.GroupKind, error) {
+	return schema.GroupKind{
+		Group: s.Resource.GVR.Group,
+		Kind:  s.Resource.Kind,
+	}, nil
+}
+
+// applyStringData Merges the `stringData` of a secret into its `data` in the
+// same way as the API server does, so that it can be compared with the live
+// secret
+func applyStringData(obj *unstructured.Unstructured) error {
+	if obj.GetAPIVersion() != "v1" || obj.GetKind() != "Secret" {
+		return nil
+	}
+
+	stringData, found, err := unstructured.NestedStringMap(obj.Object, "stringData")
+
+	if err != nil || !found {
+		return err
+	}
+
+	data, _, err := unstructured.NestedStringMap(obj.Object, "data")
+
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		data = make(map[string]string)
+	}
+
+	for k, v := range stringData {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+
+	unstructured.RemoveNestedField(obj.Object, "stringData")
+
+	return unstructured.SetNestedStringMap(obj.Object, data, "data")
+}
+
+// SimulateManifests Works out what would change if the given objects were
+// applied, by converting them to items and comparing them with the live items
+// from the adapters. Since the items are created by the same adapters as the
+// live ones, their linked item queries can be used to work out the blast
+// radius of the change before it is applied
+func SimulateManifests(ctx context.Context, adapterList []discovery.Adapter, objects []*unstructured.Unstructured, opts SimulateOptions) (*ManifestDiff, error) {
+	adaptersByKind := manifestAdapters(adapterList)
+
+	diff := &ManifestDiff{
+		Changes:     make([]*ManifestChange, 0),
+		Unsupported: make([]*unstructured.Unstructured, 0),
+		Unpruned:    make([]error, 0),
+	}
+	applied := make(map[string]bool)
+	scopes := make(map[string]bool)
+
+	for _, obj := range objects {
+		adapter, ok := adaptersByKind[obj.GroupVersionKind().GroupKind()]
+
+		if !ok {
+			diff.Unsupported = append(diff.Unsupported, obj)
+			continue
+		}
+
+		after, err := adapter.(ManifestAdapter).ManifestItem(obj, opts.Namespace)
+
+		if err != nil {
+			return nil, err
+		}
+
+		applied[manifestItemKey(after)] = true
+		scopes[after.GetScope()] = true
+
+		before, err := adapter.Get(ctx, after.GetScope(), after.UniqueAttributeValue(), true)
+
+		if err != nil {
+			var qErr *sdp.QueryError
+
+			// The namespace may not exist yet if it is being created by the
+			// same manifests
+			if errors.As(err, &qErr) && (qErr.GetErrorType() == sdp.QueryError_NOTFOUND || qErr.GetErrorType() == sdp.QueryError_NOSCOPE) {
+				diff.Changes = append(diff.Changes, &ManifestChange{
+					Type:  ChangeAdded,
+					After: after,
+				})
+
+				continue
+			}
+
+			return nil, err
+		}
+
+		if !attributesDiffer(comparableAttributes(after), comparableAttributes(before)) {
+			continue
+		}
+
+		diff.Changes = append(diff.Changes, &ManifestChange{
+			Type:   ChangeChanged,
+			Before: before,
+			After:  after,
+		})
+	}
+
+	if opts.PruneSelector == "" {
+		return diff, nil
+	}
+
+	query := ListOptionsToQuery(&metav1.ListOptions{
+		LabelSelector: opts.PruneSelector,
+	})
+
+	for _, adapter := range adapterList {
+		// Only adapters for real API types are searched, derived types like
+		// ContainerImage aren't applied so can't be pruned
+		if _, ok := manifestGroupKind(adapter); !ok {
+			continue
+		}
+
+		searchable, ok := adapter.(discovery.SearchableAdapter)
+
+		if !ok {
+			continue
+		}
+
+		namespacedAdapter, ok := adapter.(interface{ namespaced() bool })
+
+		if !ok {
+			continue
+		}
+
+		for _, scope := range itemScopes(adapter) {
+			if namespacedAdapter.namespaced() && !scopes[scope] {
+				continue
+			}
+
+			items, err := searchable.Search(ctx, scope, query, true)
+
+			if err != nil {
+				var qErr *sdp.QueryError
+
+				// Not being able to see one type shouldn't stop the rest of
+				// the simulation
+				if k8serr.IsForbidden(err) || (errors.As(err, &qErr) && qErr.GetErrorType() == sdp.QueryError_NOSCOPE) {
+					diff.Unpruned = append(diff.Unpruned, fmt.Errorf("could not search %v in scope %v: %w", adapter.Type(), scope, err))
+					continue
+				}
+
+				return nil, err
+			}
+
+			for _, item := range items {
+				if applied[manifestItemKey(item)] || !prunable(item) {
+					continue
+				}
+
+				diff.Changes = append(diff.Changes, &ManifestChange{
+					Type:   ChangeRemoved,
+					Before: item,
+				})
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+// manifestAdapters Returns the adapters that can convert manifests, by the
+// group and kind of the objects that they convert. The group is needed since
+// kinds aren't unique, e.g. core/v1 and events.k8s.io both have an Event
+func manifestAdapters(adapterList []discovery.Adapter) map[schema.GroupKind]discovery.Adapter {
+	adaptersByKind := make(map[schema.GroupKind]discovery.Adapter)
+
+	for _, adapter := range adapterList {
+		if groupKind, ok := manifestGroupKind(adapter); ok {
+			adaptersByKind[groupKind] = adapter
+		}
+	}
+
+	return adaptersByKind
+}
+
+// manifestGroupKind Returns the group and kind of the objects that an adapter
+// converts from manifests. Adapters for derived types, whose type isn't the
+// kind of the objects they are built from, don't count
+func manifestGroupKind(adapter discovery.Adapter) (schema.GroupKind, bool) {
+	manifestAdapter, ok := adapter.(ManifestAdapter)
+
+	if !ok {
+		return schema.GroupKind{}, false
+	}
+
+	groupKind, err := manifestAdapter.GroupKind()
+
+	if err != nil || groupKind.Kind != adapter.Type() {
+		return schema.GroupKind{}, false
+	}
+
+	return groupKind, true
+}
+
+// prunable Returns whether `kubectl apply --prune` would remove a live item
+// that isn't in the manifests. Only items that were created by `kubectl apply`
+// are removed, and items that are owned by another object are left for their
+// owner to manage
+func prunable(item *sdp.Item) bool {
+	if refs, err := item.GetAttributes().Get("ownerReferences"); err == nil {
+		if refList, ok := refs.([]interface{}); ok && len(refList) > 0 {
+			return false
+		}
+	}
+
+	annotations, err := item.GetAttributes().Get("annotations")
+
+	if err != nil {
+		return false
+	}
+
+	annotationMap, ok := annotations.(map[string]interface{})
+
+	if !ok {
+		return false
+	}
+
+	_, applied := annotationMap[v1.LastAppliedConfigAnnotation]
+
+	return applied
+}
+
+func manifestItemKey(item *sdp.Item) string {
+	return fmt.Sprintf("%v/%v/%v", item.GetType(), item.GetScope(), item.UniqueAttributeValue())
+}
+
+// comparableAttributes Returns the attributes of an item that should be
+// compared. The kind and API version are removed since they are only set on
+// objects from manifests, not on typed objects from the API
+func comparableAttributes(item *sdp.Item) map[string]interface{} {
+	attributes := item.GetAttributes().GetAttrStruct().AsMap()
+
+	delete(attributes, "kind")
+	delete(attributes, "apiVersion")
+
+	return attributes
+}
+
+// attributesDiffer Returns whether applying the attributes from a manifest
+// would change the live attributes. Only the fields that are set in the
+// manifest are compared, since the live item will also have fields that are
+// set by the API server such as the status, defaults and the UID
+func attributesDiffer(manifest, live interface{}) bool {
+	switch m := manifest.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+
+		if !ok {
+			return !isEmpty(manifest)
+		}
+
+		for key, value := range m {
+			if isEmpty(value) {
+				continue
+			}
+
+			if attributesDiffer(value, l[key]) {
+				return true
+			}
+		}
+
+		return false
+	case []interface{}:
+		l, ok := live.([]interface{})
+
+		if !ok || len(m) != len(l) {
+			return !isEmpty(manifest) || !isEmpty(live)
+		}
+
+		for i := range m {
+			if attributesDiffer(m[i], l[i]) {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return !reflect.DeepEqual(manifest, live)
+	}
+}
+
+// isEmpty Returns whether a value is empty. Converting a manifest to its type
+// adds empty values for fields that aren't set, such as `creationTimestamp`,
+// so these are treated as not being set
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,399 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var testManifests = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unchanged
+  labels:
+    app: web
+data:
+  colour: blue
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: changed
+  labels:
+    app: web
+data:
+  colour: green
+---
+# Empty documents should be ignored
+---
+apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Secret
+    metadata:
+      name: password
+      labels:
+        app: web
+    stringData:
+      password: hunter2
+  - apiVersion: v1
+    kind: Pod
+    metadata:
+      name: web
+      namespace: other
+    spec:
+      serviceAccountName: web
+      containers:
+        - name: web
+          image: nginx
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-widget
+`
+
+func TestReadManifests(t *testing.T) {
+	objects, err := ReadManifests(strings.NewReader(testManifests))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"ConfigMap", "ConfigMap", "Secret", "Pod", "Widget"}
+
+	if len(objects) != len(expected) {
+		t.Fatalf("expected %v objects, got %v", len(expected), len(objects))
+	}
+
+	for i, kind := range expected {
+		if objects[i].GetKind() != kind {
+			t.Errorf("expected object %v to be a %v, got %v", i, kind, objects[i].GetKind())
+		}
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ReadManifests(strings.NewReader("metadata:\n  name: no-kind\n"))
+
+		if err == nil {
+			t.Error("expected an error for a manifest without a kind")
+		}
+	})
+}
+
+func TestSimulateManifests(t *testing.T) {
+	ctx := context.Background()
+
+	cs := fake.NewClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "unchanged",
+				Namespace:       "default",
+				ResourceVersion: "123",
+				Labels: map[string]string{
+					"app": "web",
+				},
+			},
+			Data: map[string]string{
+				"colour": "blue",
+			},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "changed",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app": "web",
+				},
+			},
+			Data: map[string]string{
+				"colour": "blue",
+			},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "password",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app": "web",
+				},
+			},
+			Data: map[string][]byte{
+				"password": []byte("hunter2"),
+			},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "old",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app": "web",
+				},
+				Annotations: map[string]string{
+					v1.LastAppliedConfigAnnotation: "{}",
+				},
+			},
+		},
+		// Owned by something else, so shouldn't be pruned
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "generated",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app": "web",
+				},
+				Annotations: map[string]string{
+					v1.LastAppliedConfigAnnotation: "{}",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "web",
+						UID:        "1234",
+					},
+				},
+			},
+		},
+		// Not created by kubectl apply, so shouldn't be pruned
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "created",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app": "web",
+				},
+			},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other-app",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app": "api",
+				},
+			},
+		},
+	)
+
+	// Searching secrets isn't allowed, this shouldn't stop the simulation
+	cs.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8serr.NewForbidden(action.GetResource().GroupResource(), "", errors.New("not allowed"))
+	})
+
+	namespaces := []string{"default", "other"}
+	adapterList := []discovery.Adapter{
+		newConfigMapAdapter(cs, "test", namespaces),
+		newSecretAdapter(cs, "test", namespaces),
+		newPodAdapter(cs, "test", namespaces),
+		newContainerImageAdapter(cs, "test", namespaces),
+		newEventAdapter(cs, "test", namespaces),
+	}
+
+	objects, err := ReadManifests(strings.NewReader(testManifests))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := SimulateManifests(ctx, adapterList, objects, SimulateOptions{
+		Namespace:     "default",
+		PruneSelector: "app=web",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diff.Unsupported) != 1 || diff.Unsupported[0].GetKind() != "Widget" {
+		t.Errorf("expected the Widget to be unsupported, got %v", diff.Unsupported)
+	}
+
+	if len(diff.Unpruned) != 2 {
+		t.Errorf("expected secrets in both scopes to be unpruned, got %v", diff.Unpruned)
+	}
+
+	expected := []struct {
+		Type  ChangeType
+		Kind  string
+		Name  string
+		Scope string
+	}{
+		{ChangeChanged, "ConfigMap", "changed", "test.default"},
+		{ChangeAdded, "Pod", "web", "test.other"},
+		{ChangeRemoved, "ConfigMap", "old", "test.default"},
+	}
+
+	if len(diff.Changes) != len(expected) {
+		for _, change := range diff.Changes {
+			t.Log(change.Type, change.After, change.Before)
+		}
+
+		t.Fatalf("expected %v changes, got %v", len(expected), len(diff.Changes))
+	}
+
+	for i, e := range expected {
+		change := diff.Changes[i]
+		item := change.After
+
+		if item == nil {
+			item = change.Before
+		}
+
+		if change.Type != e.Type || item.GetType() != e.Kind || item.UniqueAttributeValue() != e.Name || item.GetScope() != e.Scope {
+			t.Errorf("expected %v %v %v in %v, got %v %v %v in %v", e.Type, e.Kind, e.Name, e.Scope, change.Type, item.GetType(), item.UniqueAttributeValue(), item.GetScope())
+		}
+	}
+
+	t.Run("kinds in different groups", func(t *testing.T) {
+		// There is only an adapter for events.k8s.io Events, so a core Event
+		// can't be simulated
+		objects, err := ReadManifests(strings.NewReader(`
+apiVersion: v1
+kind: Event
+metadata:
+  name: core-event
+`))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		diff, err := SimulateManifests(ctx, adapterList, objects, SimulateOptions{Namespace: "default"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(diff.Unsupported) != 1 || len(diff.Changes) != 0 {
+			t.Errorf("expected the core Event to be unsupported, got %v unsupported and %v changes", diff.Unsupported, diff.Changes)
+		}
+	})
+
+	t.Run("linked queries from the manifest", func(t *testing.T) {
+		QueryTests{
+			{
+				ExpectedType:   "ServiceAccount",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "web",
+				ExpectedScope:  "test.other",
+			},
+		}.Execute(t, &sdp.Item{LinkedItemQueries: diff.Changes[1].LinkedItemQueries()})
+	})
+}
+
+func TestManifestChangeLinkedItemQueries(t *testing.T) {
+	query := func(name string) *sdp.LinkedItemQuery {
+		return &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "Secret",
+				Method: sdp.QueryMethod_GET,
+				Query:  name,
+				Scope:  "test.default",
+			},
+		}
+	}
+
+	change := &ManifestChange{
+		Type: ChangeChanged,
+		Before: &sdp.Item{
+			LinkedItemQueries: []*sdp.LinkedItemQuery{query("old"), query("kept")},
+		},
+		After: &sdp.Item{
+			LinkedItemQueries: []*sdp.LinkedItemQuery{query("new"), query("kept")},
+		},
+	}
+
+	queries := change.LinkedItemQueries()
+
+	if len(queries) != 3 {
+		t.Fatalf("expected 3 queries, got %v", len(queries))
+	}
+
+	QueryTests{
+		{
+			ExpectedType:   "Secret",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "old",
+			ExpectedScope:  "test.default",
+		},
+		{
+			ExpectedType:   "Secret",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "new",
+			ExpectedScope:  "test.default",
+		},
+	}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+}
+
+func TestAttributesDiffer(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Manifest interface{}
+		Live     interface{}
+		Differ   bool
+	}{
+		{
+			Name:     "fields set by the server are ignored",
+			Manifest: map[string]interface{}{"name": "foo"},
+			Live:     map[string]interface{}{"name": "foo", "uid": "123"},
+		},
+		{
+			Name:     "empty values are ignored",
+			Manifest: map[string]interface{}{"name": "foo", "creationTimestamp": nil, "status": map[string]interface{}{}},
+			Live:     map[string]interface{}{"name": "foo", "creationTimestamp": "2024-01-01T00:00:00Z"},
+		},
+		{
+			Name:     "changed value",
+			Manifest: map[string]interface{}{"replicas": float64(3)},
+			Live:     map[string]interface{}{"replicas": float64(2)},
+			Differ:   true,
+		},
+		{
+			Name:     "new field",
+			Manifest: map[string]interface{}{"data": map[string]interface{}{"foo": "bar"}},
+			Live:     map[string]interface{}{},
+			Differ:   true,
+		},
+		{
+			Name: "defaults in lists are ignored",
+			Manifest: map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "web"},
+			}},
+			Live: map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "web", "imagePullPolicy": "Always"},
+			}},
+		},
+		{
+			Name: "list length changed",
+			Manifest: map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "web"},
+				map[string]interface{}{"name": "sidecar"},
+			}},
+			Live: map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "web"},
+			}},
+			Differ: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if differ := attributesDiffer(test.Manifest, test.Live); differ != test.Differ {
+				t.Errorf("expected %v, got %v", test.Differ, differ)
+			}
+		})
+	}
+}
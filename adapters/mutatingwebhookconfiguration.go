@@ -0,0 +1,68 @@
+package adapters
+
+import (
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func mutatingWebhookConfigurationExtractor(resource *v1.MutatingWebhookConfiguration, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	sd, err := ParseScope(scope, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, webhook := range resource.Webhooks {
+		queries = append(queries, webhookQueries(webhook.ClientConfig, webhook.NamespaceSelector, sd.ClusterName)...)
+	}
+
+	return queries, nil
+}
+
+func newMutatingWebhookConfigurationAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &KubeTypeAdapter[*v1.MutatingWebhookConfiguration, *v1.MutatingWebhookConfigurationList]{
+		ClusterName: cluster,
+		Namespaces:  namespaces,
+		TypeName:    "MutatingWebhookConfiguration",
+		ClusterInterfaceBuilder: func() ItemInterface[*v1.MutatingWebhookConfiguration, *v1.MutatingWebhookConfigurationList] {
+			return cs.AdmissionregistrationV1().MutatingWebhookConfigurations()
+		},
+		ListExtractor: func(list *v1.MutatingWebhookConfigurationList) ([]*v1.MutatingWebhookConfiguration, error) {
+			extracted := make([]*v1.MutatingWebhookConfiguration, len(list.Items))
+
+			for i := range list.Items {
+				extracted[i] = &list.Items[i]
+			}
+
+			return extracted, nil
+		},
+		LinkedItemQueryExtractor: mutatingWebhookConfigurationExtractor,
+		AdapterMetadata:          mutatingWebhookConfigurationAdapterMetadata,
+	}
+}
+
+var mutatingWebhookConfigurationAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:                  "MutatingWebhookConfiguration",
+	DescriptiveName:       "Mutating Webhook Configuration",
+	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks:        []string{"Service", "Namespace", "dns", "ip"},
+	SupportedQueryMethods: DefaultSupportedQueryMethods("Mutating Webhook Configuration"),
+	TerraformMappings: []*sdp.TerraformMapping{
+		{
+			TerraformMethod:   sdp.QueryMethod_GET,
+			TerraformQueryMap: "kubernetes_mutating_webhook_configuration_v1.metadata[0].name",
+		},
+		{
+			TerraformMethod:   sdp.QueryMethod_GET,
+			TerraformQueryMap: "kubernetes_mutating_webhook_configuration.metadata[0].name",
+		},
+	},
+})
+
+func init() {
+	registerAdapterLoader(newMutatingWebhookConfigurationAdapter)
+}
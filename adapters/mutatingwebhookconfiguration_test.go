@@ -0,0 +1,65 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+)
+
+// The webhook ignores failures and only matches a namespace that doesn't
+// exist, so it won't interfere with the other tests
+var mutatingWebhookConfigurationYAML = `
+apiVersion: admissionregistration.k8s.io/v1
+kind: MutatingWebhookConfiguration
+metadata:
+  name: mutating-webhook-test
+webhooks:
+- name: inject.example.com
+  admissionReviewVersions: ["v1"]
+  sideEffects: None
+  failurePolicy: Ignore
+  clientConfig:
+    service:
+      name: sidecar-injector
+      namespace: k8s-source-testing
+      path: /inject
+  namespaceSelector:
+    matchLabels:
+      sidecar-injection: enabled
+  rules:
+  - apiGroups: [""]
+    apiVersions: ["v1"]
+    operations: ["CREATE"]
+    resources: ["pods"]
+`
+
+func TestMutatingWebhookConfigurationAdapter(t *testing.T) {
+	sd := ScopeDetails{
+		ClusterName: CurrentCluster.Name,
+	}
+
+	adapter := newMutatingWebhookConfigurationAdapter(CurrentCluster.ClientSet, sd.ClusterName, []string{"default"})
+
+	st := AdapterTests{
+		Adapter:   adapter,
+		GetQuery:  "mutating-webhook-test",
+		GetScope:  sd.String(),
+		SetupYAML: mutatingWebhookConfigurationYAML,
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "Service",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "sidecar-injector",
+				ExpectedScope:  sd.String() + "." + TestNamespace,
+			},
+			{
+				ExpectedType:   "Namespace",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  `{"labelSelector":"sidecar-injection=enabled"}`,
+				ExpectedScope:  sd.String(),
+			},
+		},
+	}
+
+	st.Execute(t)
+}
@@ -0,0 +1,139 @@
+package adapters
+
+import (
+	"slices"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// namespaceContentTypes The types inside a namespace that control what can run
+// in it and how, these are linked from the namespace so that a change to one
+// of them shows the namespace it applies to
+var namespaceContentTypes = []string{
+	"ResourceQuota",
+	"LimitRange",
+	"NetworkPolicy",
+	"ServiceAccount",
+}
+
+// namespaceExtractor Links a namespace to the things inside it. These are only
+// linked if the namespace is being discovered, since namespaces that have been
+// filtered out don't have any scopes
+func namespaceExtractor(resource *v1.Namespace, scope string, discovered []string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0, len(namespaceContentTypes))
+
+	sd, err := ParseScope(scope, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(discovered, resource.Name) {
+		return queries, nil
+	}
+
+	// The items are in the namespace's own scope rather than the cluster's
+	sd.Namespace = resource.Name
+
+	for _, itemType := range namespaceContentTypes {
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   itemType,
+				Method: sdp.QueryMethod_LIST,
+				Scope:  sd.String(),
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// Deleting the namespace deletes everything in it
+				In: false,
+				// Quotas, limits and policies change what can run in the
+				// namespace
+				Out: true,
+			},
+		})
+	}
+
+	return queries, nil
+}
+
+// namespaceAttributeExtractor Surfaces the phase of the namespace as a
+// top-level attribute so that it can be searched on
+func namespaceAttributeExtractor(resource *v1.Namespace) (map[string]interface{}, error) {
+	attributes := make(map[string]interface{})
+
+	if resource.Status.Phase != "" {
+		attributes["phase"] = string(resource.Status.Phase)
+	}
+
+	return attributes, nil
+}
+
+// namespaceHealthExtractor Namespaces that are terminating are being deleted
+// along with everything in them. These can also get stuck if finalizers can't
+// be run
+func namespaceHealthExtractor(resource *v1.Namespace) *sdp.Health {
+	switch resource.Status.Phase {
+	case v1.NamespaceActive:
+		return sdp.Health_HEALTH_OK.Enum()
+	case v1.NamespaceTerminating:
+		return sdp.Health_HEALTH_ERROR.Enum()
+	default:
+		return sdp.Health_HEALTH_UNKNOWN.Enum()
+	}
+}
+
+func newNamespaceAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	adapter := &KubeTypeAdapter[*v1.Namespace, *v1.NamespaceList]{
+		ClusterName: cluster,
+		Namespaces:  namespaces,
+		TypeName:    "Namespace",
+		ClusterInterfaceBuilder: func() ItemInterface[*v1.Namespace, *v1.NamespaceList] {
+			return cs.CoreV1().Namespaces()
+		},
+		ListExtractor: func(list *v1.NamespaceList) ([]*v1.Namespace, error) {
+			extracted := make([]*v1.Namespace, len(list.Items))
+
+			for i := range list.Items {
+				extracted[i] = &list.Items[i]
+			}
+
+			return extracted, nil
+		},
+		AttributeExtractor: namespaceAttributeExtractor,
+		HealthExtractor:    namespaceHealthExtractor,
+		AdapterMetadata:    namespaceAdapterMetadata,
+	}
+
+	// The adapter's namespaces follow the namespace filter, so this is used
+	// to tell which namespaces are being discovered
+	adapter.LinkedItemQueryExtractor = func(resource *v1.Namespace, scope string) ([]*sdp.LinkedItemQuery, error) {
+		return namespaceExtractor(resource, scope, adapter.currentNamespaces())
+	}
+
+	return adapter
+}
+
+var namespaceAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:                  "Namespace",
+	DescriptiveName:       "Namespace",
+	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_CONFIGURATION,
+	PotentialLinks:        namespaceContentTypes,
+	SupportedQueryMethods: DefaultSupportedQueryMethods("Namespace"),
+	TerraformMappings: []*sdp.TerraformMapping{
+		{
+			TerraformMethod:   sdp.QueryMethod_GET,
+			TerraformQueryMap: "kubernetes_namespace.metadata[0].name",
+		},
+		{
+			TerraformMethod:   sdp.QueryMethod_GET,
+			TerraformQueryMap: "kubernetes_namespace_v1.metadata[0].name",
+		},
+	},
+})
+
+func init() {
+	registerAdapterLoader(newNamespaceAdapter)
+}
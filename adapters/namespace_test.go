@@ -0,0 +1,94 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceAdapter(t *testing.T) {
+	sd := ScopeDetails{
+		ClusterName: CurrentCluster.Name,
+	}
+
+	adapter := newNamespaceAdapter(CurrentCluster.ClientSet, sd.ClusterName, []string{"default", TestNamespace})
+
+	st := AdapterTests{
+		Adapter:  adapter,
+		GetQuery: TestNamespace,
+		GetScope: sd.String(),
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "ResourceQuota",
+				ExpectedMethod: sdp.QueryMethod_LIST,
+				ExpectedScope:  sd.String() + "." + TestNamespace,
+			},
+			{
+				ExpectedType:   "ServiceAccount",
+				ExpectedMethod: sdp.QueryMethod_LIST,
+				ExpectedScope:  sd.String() + "." + TestNamespace,
+			},
+		},
+	}
+
+	st.Execute(t)
+}
+
+func TestNamespaceExtractor(t *testing.T) {
+	namespace := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "excluded",
+		},
+	}
+
+	queries, err := namespaceExtractor(namespace, "test-cluster", []string{"default"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Namespaces that aren't being discovered have no scopes to link to
+	if len(queries) != 0 {
+		t.Errorf("expected no links for a namespace that isn't being discovered, got %v", queries)
+	}
+
+	queries, err = namespaceExtractor(namespace, "test-cluster", []string{"default", "excluded"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(queries) != len(namespaceContentTypes) {
+		t.Errorf("expected %v links, got %v", len(namespaceContentTypes), len(queries))
+	}
+}
+
+func TestNamespaceHealthExtractor(t *testing.T) {
+	namespace := func(phase v1.NamespacePhase) *v1.Namespace {
+		return &v1.Namespace{
+			Status: v1.NamespaceStatus{
+				Phase: phase,
+			},
+		}
+	}
+
+	HealthTests[*v1.Namespace]{
+		{
+			Name:           "active",
+			Resource:       namespace(v1.NamespaceActive),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "terminating",
+			Resource:       namespace(v1.NamespaceTerminating),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name:           "no phase",
+			Resource:       namespace(""),
+			ExpectedHealth: sdp.Health_HEALTH_UNKNOWN.Enum(),
+		},
+	}.Execute(t, namespaceHealthExtractor)
+}
@@ -0,0 +1,679 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// This file contains the NetworkPath type, which answers whether one pod can
+// reach another on a port. It is worked out from the NetworkPolicies that
+// select the pods, following the same rules as kubernetes:
+//
+//   - Pods that aren't selected by any policy of a direction aren't isolated
+//     in that direction, and allow all traffic
+//   - Pods that are selected are isolated, and only allow the traffic that at
+//     least one of the policies that select them allows
+//   - Traffic is only allowed if the source allows it out, and the destination
+//     allows it in
+//   - Pods on the host network aren't isolated, since policies don't apply to
+//     them
+
+// networkPathQuery A search for the path between two pods, in the format
+// `{"source":"{namespace}/{pod}","destination":"{namespace}/{pod}","port":80}`.
+// The protocol is TCP unless set
+type networkPathQuery struct {
+	Source      string          `json:"source"`
+	Destination string          `json:"destination"`
+	Port        int32           `json:"port"`
+	Protocol    corev1.Protocol `json:"protocol,omitempty"`
+}
+
+// parseNetworkPathQuery Parses and validates a NetworkPath search
+func parseNetworkPathQuery(query string) (networkPathQuery, error) {
+	var q networkPathQuery
+
+	if err := json.Unmarshal([]byte(query), &q); err != nil {
+		return q, fmt.Errorf(`query must be in the format {"source":"{namespace}/{pod}","destination":"{namespace}/{pod}","port":80}: %w`, err)
+	}
+
+	for _, ref := range []string{q.Source, q.Destination} {
+		if _, _, err := parsePodReference(ref); err != nil {
+			return q, err
+		}
+	}
+
+	if q.Port < 1 || q.Port > 65535 {
+		return q, fmt.Errorf("port %v must be between 1 and 65535", q.Port)
+	}
+
+	if q.Protocol == "" {
+		q.Protocol = corev1.ProtocolTCP
+	}
+
+	switch q.Protocol {
+	case corev1.ProtocolTCP, corev1.ProtocolUDP, corev1.ProtocolSCTP:
+	default:
+		return q, fmt.Errorf("protocol %v must be TCP, UDP or SCTP", q.Protocol)
+	}
+
+	return q, nil
+}
+
+// parsePodReference Parses a reference to a pod in the format
+// `{namespace}/{name}`
+func parsePodReference(ref string) (namespace string, name string, err error) {
+	namespace, name, _ = strings.Cut(ref, "/")
+
+	if namespace == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("pod %q must be in the format {namespace}/{name}", ref)
+	}
+
+	return namespace, name, nil
+}
+
+// networkPathEndpoint A pod at one end of a path, and its namespace, whose
+// labels are needed to evaluate namespace selectors
+type networkPathEndpoint struct {
+	Pod       *corev1.Pod
+	Namespace *corev1.Namespace
+}
+
+// networkPathVerdict Whether traffic is allowed along a path, and which
+// policies decided it
+type networkPathVerdict struct {
+	Allowed bool
+	Reason  string
+	// The policies that isolate the source for egress
+	EgressPolicies []*v1.NetworkPolicy
+	// The policies that isolate the destination for ingress
+	IngressPolicies []*v1.NetworkPolicy
+	// The policies that have a rule that allows the traffic
+	AllowedBy []*v1.NetworkPolicy
+}
+
+// evaluateNetworkPath Works out whether the source can reach the destination
+// on a port. `policies` can contain any policies, only the ones that select
+// the pods are used
+func evaluateNetworkPath(source networkPathEndpoint, destination networkPathEndpoint, port int32, protocol corev1.Protocol, policies []*v1.NetworkPolicy) networkPathVerdict {
+	verdict := networkPathVerdict{
+		EgressPolicies:  make([]*v1.NetworkPolicy, 0),
+		IngressPolicies: make([]*v1.NetworkPolicy, 0),
+		AllowedBy:       make([]*v1.NetworkPolicy, 0),
+	}
+
+	var egressAllowed, ingressAllowed bool
+
+	for _, policy := range policies {
+		ingress, egress := networkPolicyTypes(policy)
+
+		if egress && !source.Pod.Spec.HostNetwork && networkPolicySelects(policy, source.Pod) {
+			verdict.EgressPolicies = append(verdict.EgressPolicies, policy)
+
+			for _, rule := range policy.Spec.Egress {
+				if networkPolicyRuleAllows(policy, rule.To, rule.Ports, destination, destination.Pod, port, protocol) {
+					egressAllowed = true
+					verdict.AllowedBy = append(verdict.AllowedBy, policy)
+
+					break
+				}
+			}
+		}
+
+		if ingress && !destination.Pod.Spec.HostNetwork && networkPolicySelects(policy, destination.Pod) {
+			verdict.IngressPolicies = append(verdict.IngressPolicies, policy)
+
+			for _, rule := range policy.Spec.Ingress {
+				if networkPolicyRuleAllows(policy, rule.From, rule.Ports, source, destination.Pod, port, protocol) {
+					ingressAllowed = true
+
+					if !slices.Contains(verdict.AllowedBy, policy) {
+						verdict.AllowedBy = append(verdict.AllowedBy, policy)
+					}
+
+					break
+				}
+			}
+		}
+	}
+
+	// Pods that no policy selects, or that are on the host network, aren't
+	// isolated
+	egressAllowed = egressAllowed || len(verdict.EgressPolicies) == 0
+	ingressAllowed = ingressAllowed || len(verdict.IngressPolicies) == 0
+
+	switch {
+	case !egressAllowed:
+		verdict.Reason = "no policy that isolates the source allows egress to the destination"
+	case !ingressAllowed:
+		verdict.Reason = "no policy that isolates the destination allows ingress from the source"
+	case len(verdict.EgressPolicies) == 0 && len(verdict.IngressPolicies) == 0:
+		verdict.Reason = "neither pod is isolated by a policy"
+	default:
+		verdict.Reason = "allowed by the policies that isolate the pods"
+	}
+
+	verdict.Allowed = egressAllowed && ingressAllowed
+
+	return verdict
+}
+
+// networkPolicySelects Returns whether a policy applies to a pod
+func networkPolicySelects(policy *v1.NetworkPolicy, pod *corev1.Pod) bool {
+	if policy.Namespace != pod.Namespace {
+		return false
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+
+	// An invalid selector is rejected by the API so can't select anything
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(labels.Set(pod.Labels))
+}
+
+// networkPolicyRuleAllows Returns whether a rule allows traffic with a peer.
+// Named ports are looked up on the destination pod for both directions, since
+// that is where the traffic is going
+func networkPolicyRuleAllows(policy *v1.NetworkPolicy, peers []v1.NetworkPolicyPeer, ports []v1.NetworkPolicyPort, peer networkPathEndpoint, destination *corev1.Pod, port int32, protocol corev1.Protocol) bool {
+	if !networkPolicyPortsAllow(ports, destination, port, protocol) {
+		return false
+	}
+
+	// A rule without peers allows traffic with anything
+	if len(peers) == 0 {
+		return true
+	}
+
+	for _, p := range peers {
+		if networkPolicyPeerMatches(policy, p, peer) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// networkPolicyPeerMatches Returns whether a pod is one of a rule's peers
+func networkPolicyPeerMatches(policy *v1.NetworkPolicy, peer v1.NetworkPolicyPeer, endpoint networkPathEndpoint) bool {
+	if peer.IPBlock != nil {
+		return ipBlockMatches(peer.IPBlock, endpoint.Pod)
+	}
+
+	if peer.NamespaceSelector == nil {
+		// Without a namespace selector the pod selector is in the policy's
+		// own namespace
+		if endpoint.Pod.Namespace != policy.Namespace {
+			return false
+		}
+	} else {
+		selector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+
+		if err != nil || endpoint.Namespace == nil || !selector.Matches(labels.Set(endpoint.Namespace.Labels)) {
+			return false
+		}
+	}
+
+	if peer.PodSelector == nil {
+		return peer.NamespaceSelector != nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(peer.PodSelector)
+
+	return err == nil && selector.Matches(labels.Set(endpoint.Pod.Labels))
+}
+
+// ipBlockMatches Returns whether any of a pod's IPs are in a block
+func ipBlockMatches(block *v1.IPBlock, pod *corev1.Pod) bool {
+	cidr, err := netip.ParsePrefix(block.CIDR)
+
+	if err != nil {
+		return false
+	}
+
+	for _, podIP := range pod.Status.PodIPs {
+		addr, err := netip.ParseAddr(podIP.IP)
+
+		if err != nil || !cidr.Contains(addr) {
+			continue
+		}
+
+		excepted := slices.ContainsFunc(block.Except, func(except string) bool {
+			prefix, err := netip.ParsePrefix(except)
+
+			return err == nil && prefix.Contains(addr)
+		})
+
+		if !excepted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// networkPolicyPortsAllow Returns whether a port is in a rule's ports. A rule
+// without ports allows every port
+func networkPolicyPortsAllow(ports []v1.NetworkPolicyPort, destination *corev1.Pod, port int32, protocol corev1.Protocol) bool {
+	if len(ports) == 0 {
+		return true
+	}
+
+	for _, p := range ports {
+		portProtocol := corev1.ProtocolTCP
+
+		if p.Protocol != nil {
+			portProtocol = *p.Protocol
+		}
+
+		if portProtocol != protocol {
+			continue
+		}
+
+		switch {
+		case p.Port == nil:
+			return true
+		case p.Port.Type == intstr.String:
+			if containerPortNamed(destination, p.Port.StrVal, port, protocol) {
+				return true
+			}
+		case p.EndPort != nil:
+			if port >= p.Port.IntVal && port <= *p.EndPort {
+				return true
+			}
+		case p.Port.IntVal == port:
+			return true
+		}
+	}
+
+	return false
+}
+
+// containerPortNamed Returns whether a pod has a container port with a name
+// and number
+func containerPortNamed(pod *corev1.Pod, name string, port int32, protocol corev1.Protocol) bool {
+	for _, container := range pod.Spec.Containers {
+		for _, containerPort := range container.Ports {
+			containerProtocol := containerPort.Protocol
+
+			if containerProtocol == "" {
+				containerProtocol = corev1.ProtocolTCP
+			}
+
+			if containerPort.Name == name && containerPort.ContainerPort == port && containerProtocol == protocol {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// networkPathAdapter Serves NetworkPaths. These aren't stored anywhere, they
+// are worked out from the pods, namespaces and policies when searched for
+type networkPathAdapter struct {
+	ClusterName string
+
+	pods       *KubeTypeAdapter[*corev1.Pod, *corev1.PodList]
+	namespaces *KubeTypeAdapter[*corev1.Namespace, *corev1.NamespaceList]
+	policies   *KubeTypeAdapter[*v1.NetworkPolicy, *v1.NetworkPolicyList]
+}
+
+func (s *networkPathAdapter) Type() string {
+	return "NetworkPath"
+}
+
+func (s *networkPathAdapter) Name() string {
+	return "k8s-NetworkPath"
+}
+
+func (s *networkPathAdapter) Metadata() *sdp.AdapterMetadata {
+	return networkPathAdapterMetadata
+}
+
+func (s *networkPathAdapter) Weight() int {
+	return 10
+}
+
+// Scopes Paths can be between pods in different namespaces, so are in the
+// cluster's scope
+func (s *networkPathAdapter) Scopes() []string {
+	return []string{
+		ScopeDetails{
+			ClusterName: s.ClusterName,
+		}.String(),
+	}
+}
+
+// GroupResource Paths are worked out from the network policies
+func (s *networkPathAdapter) GroupResource() (schema.GroupResource, error) {
+	return v1.Resource("networkpolicies"), nil
+}
+
+func (s *networkPathAdapter) Get(ctx context.Context, scope string, query string, ignoreCache bool) (*sdp.Item, error) {
+	return nil, &sdp.QueryError{
+		ErrorType:   sdp.QueryError_NOTFOUND,
+		ErrorString: "NetworkPaths are worked out when searched for, so can't be fetched",
+	}
+}
+
+// List Paths aren't stored, and there are too many pairs of pods to list them
+// all, so nothing is returned
+func (s *networkPathAdapter) List(ctx context.Context, scope string, ignoreCache bool) ([]*sdp.Item, error) {
+	if err := s.checkScope(scope); err != nil {
+		return nil, err
+	}
+
+	return []*sdp.Item{}, nil
+}
+
+func (s *networkPathAdapter) Search(ctx context.Context, scope string, query string, ignoreCache bool) ([]*sdp.Item, error) {
+	if err := s.checkScope(scope); err != nil {
+		return nil, err
+	}
+
+	q, err := parseNetworkPathQuery(query)
+	if err != nil {
+		return nil, &sdp.QueryError{
+			ErrorType:   sdp.QueryError_OTHER,
+			ErrorString: err.Error(),
+		}
+	}
+
+	source, err := s.endpoint(ctx, q.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := s.endpoint(ctx, q.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := s.policies.listResources(ctx, s.namespaceScope(source.Pod.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	if destination.Pod.Namespace != source.Pod.Namespace {
+		destinationPolicies, err := s.policies.listResources(ctx, s.namespaceScope(destination.Pod.Namespace))
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, destinationPolicies...)
+	}
+
+	verdict := evaluateNetworkPath(source, destination, q.Port, q.Protocol, policies)
+
+	item, err := s.pathToItem(q, verdict)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*sdp.Item{item}, nil
+}
+
+// endpoint Fetches a pod and its namespace. Sources that are limited to a set
+// of namespaces, or that don't discover namespaces, can't get them, so only
+// the label that kubernetes sets on every namespace is known
+func (s *networkPathAdapter) endpoint(ctx context.Context, ref string) (networkPathEndpoint, error) {
+	var endpoint networkPathEndpoint
+
+	namespace, name, err := parsePodReference(ref)
+	if err != nil {
+		return endpoint, err
+	}
+
+	if err := s.checkNamespace(namespace); err != nil {
+		return endpoint, err
+	}
+
+	pods, err := s.pods.itemInterface(s.namespaceScope(namespace))
+	if err != nil {
+		return endpoint, err
+	}
+
+	endpoint.Pod, err = pods.Get(ctx, name, metav1.GetOptions{})
+	if k8serr.IsNotFound(err) {
+		return endpoint, &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOTFOUND,
+			ErrorString: fmt.Sprintf("pod %v not found", ref),
+		}
+	}
+
+	if err != nil {
+		return endpoint, err
+	}
+
+	// Namespaces aren't discovered if their type has been filtered out
+	if s.namespaces == nil {
+		endpoint.Namespace = unknownNamespace(namespace)
+
+		return endpoint, nil
+	}
+
+	namespaces, err := s.namespaces.itemInterface(s.Scopes()[0])
+	if err != nil {
+		return endpoint, err
+	}
+
+	endpoint.Namespace, err = namespaces.Get(ctx, namespace, metav1.GetOptions{})
+	if k8serr.IsForbidden(err) {
+		endpoint.Namespace = unknownNamespace(namespace)
+
+		return endpoint, nil
+	}
+
+	return endpoint, err
+}
+
+// unknownNamespace Returns a namespace with only the label that kubernetes sets
+// on every namespace, for when the namespace itself can't be fetched
+func unknownNamespace(name string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				corev1.LabelMetadataName: name,
+			},
+		},
+	}
+}
+
+func (s *networkPathAdapter) namespaceScope(namespace string) string {
+	return ScopeDetails{
+		ClusterName: s.ClusterName,
+		Namespace:   namespace,
+	}.String()
+}
+
+// checkNamespace Returns an error unless the pods and policies in a namespace
+// are being discovered, since the path can't be worked out without both
+func (s *networkPathAdapter) checkNamespace(namespace string) error {
+	if !slices.Contains(s.pods.currentNamespaces(), namespace) || !slices.Contains(s.policies.currentNamespaces(), namespace) {
+		return &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOSCOPE,
+			ErrorString: fmt.Sprintf("namespace %v is not being discovered", namespace),
+		}
+	}
+
+	return nil
+}
+
+func (s *networkPathAdapter) checkScope(scope string) error {
+	if !slices.Contains(s.Scopes(), scope) {
+		return &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOSCOPE,
+			ErrorString: fmt.Sprintf("scope %v is not served by this adapter", scope),
+		}
+	}
+
+	return nil
+}
+
+func policyNames(policies []*v1.NetworkPolicy) []string {
+	names := make([]string, 0, len(policies))
+
+	for _, policy := range policies {
+		names = append(names, policy.Namespace+"/"+policy.Name)
+	}
+
+	return names
+}
+
+type networkPathAttributes struct {
+	Path            string   `json:"path"`
+	Source          string   `json:"source"`
+	Destination     string   `json:"destination"`
+	Port            int32    `json:"port"`
+	Protocol        string   `json:"protocol"`
+	Allowed         bool     `json:"allowed"`
+	Verdict         string   `json:"verdict"`
+	Reason          string   `json:"reason"`
+	EgressPolicies  []string `json:"egressPolicies"`
+	IngressPolicies []string `json:"ingressPolicies"`
+	AllowedBy       []string `json:"allowedBy"`
+}
+
+func (s *networkPathAdapter) pathToItem(q networkPathQuery, verdict networkPathVerdict) (*sdp.Item, error) {
+	verdictName := "denied"
+
+	if verdict.Allowed {
+		verdictName = "allowed"
+	}
+
+	attributes, err := sdp.ToAttributesViaJson(networkPathAttributes{
+		Path:            fmt.Sprintf("%v -> %v %v/%v", q.Source, q.Destination, q.Protocol, q.Port),
+		Source:          q.Source,
+		Destination:     q.Destination,
+		Port:            q.Port,
+		Protocol:        string(q.Protocol),
+		Allowed:         verdict.Allowed,
+		Verdict:         verdictName,
+		Reason:          verdict.Reason,
+		EgressPolicies:  policyNames(verdict.EgressPolicies),
+		IngressPolicies: policyNames(verdict.IngressPolicies),
+		AllowedBy:       policyNames(verdict.AllowedBy),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	for _, ref := range []string{q.Source, q.Destination} {
+		namespace, name, _ := parsePodReference(ref)
+
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "Pod",
+				Method: sdp.QueryMethod_GET,
+				Query:  name,
+				Scope:  s.namespaceScope(namespace),
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// Changes to the pods' labels change which policies apply
+				In: true,
+				// The path doesn't change the pods
+				Out: false,
+			},
+		})
+	}
+
+	seen := make(map[*v1.NetworkPolicy]bool)
+
+	for _, policy := range slices.Concat(verdict.EgressPolicies, verdict.IngressPolicies) {
+		if seen[policy] {
+			continue
+		}
+
+		seen[policy] = true
+
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "NetworkPolicy",
+				Method: sdp.QueryMethod_GET,
+				Query:  policy.Name,
+				Scope:  s.namespaceScope(policy.Namespace),
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// Changes to the policies change whether the path is allowed
+				In: true,
+				// The path is worked out from the policies, so can't change
+				// them
+				Out: false,
+			},
+		})
+	}
+
+	return &sdp.Item{
+		Type:              s.Type(),
+		UniqueAttribute:   "path",
+		Scope:             s.Scopes()[0],
+		Attributes:        attributes,
+		LinkedItemQueries: queries,
+	}, nil
+}
+
+// linkAdapters Uses the loaded Pod, Namespace and NetworkPolicy adapters rather
+// than the adapter's own, so that they share informers and follow namespace
+// updates. Paths can't be worked out without pods and policies, but without
+// namespaces only the name label of each namespace is known
+func (s *networkPathAdapter) linkAdapters(adapterList []discovery.Adapter) bool {
+	pods, ok := linkedAdapter[*KubeTypeAdapter[*corev1.Pod, *corev1.PodList]](adapterList, "Pod")
+	if !ok {
+		return false
+	}
+
+	policies, ok := linkedAdapter[*KubeTypeAdapter[*v1.NetworkPolicy, *v1.NetworkPolicyList]](adapterList, "NetworkPolicy")
+	if !ok {
+		return false
+	}
+
+	s.pods = pods
+	s.policies = policies
+	s.namespaces, _ = linkedAdapter[*KubeTypeAdapter[*corev1.Namespace, *corev1.NamespaceList]](adapterList, "Namespace")
+
+	return true
+}
+
+func newNetworkPathAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &networkPathAdapter{
+		ClusterName: cluster,
+		pods:        newPodAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*corev1.Pod, *corev1.PodList]),
+		namespaces:  newNamespaceAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*corev1.Namespace, *corev1.NamespaceList]),
+		policies:    newNetworkPolicyAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*v1.NetworkPolicy, *v1.NetworkPolicyList]),
+	}
+}
+
+var networkPathAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:            "NetworkPath",
+	DescriptiveName: "Network Path",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_NETWORK,
+	PotentialLinks:  []string{"Pod", "NetworkPolicy"},
+	SupportedQueryMethods: &sdp.AdapterSupportedQueryMethods{
+		Search:            true,
+		SearchDescription: `Search for whether one pod can reach another on a port, according to the NetworkPolicies that select them e.g. {"source":"default/frontend","destination":"default/database","port":5432,"protocol":"TCP"}`,
+	},
+})
+
+func init() {
+	registerAdapterLoader(newNetworkPathAdapter)
+}
@@ -0,0 +1,533 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func networkPathPod(namespace string, name string, ip string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "main",
+					Image: "nginx",
+					Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			PodIPs: []corev1.PodIP{{IP: ip}},
+		},
+	}
+}
+
+func networkPathNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	}
+}
+
+func TestParseNetworkPathQuery(t *testing.T) {
+	q, err := parseNetworkPathQuery(`{"source":"default/frontend","destination":"data/database","port":5432}`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Source != "default/frontend" || q.Destination != "data/database" || q.Port != 5432 || q.Protocol != corev1.ProtocolTCP {
+		t.Errorf("unexpected query %+v", q)
+	}
+
+	invalid := []string{
+		`default/frontend`,
+		`{"source":"frontend","destination":"data/database","port":5432}`,
+		`{"source":"default/frontend","destination":"data/database"}`,
+		`{"source":"default/frontend","destination":"data/database","port":70000}`,
+		`{"source":"default/frontend","destination":"data/database","port":53,"protocol":"ICMP"}`,
+	}
+
+	for _, query := range invalid {
+		if _, err := parseNetworkPathQuery(query); err == nil {
+			t.Errorf("expected %v to be invalid", query)
+		}
+	}
+}
+
+func TestEvaluateNetworkPath(t *testing.T) {
+	frontend := networkPathEndpoint{
+		Pod:       networkPathPod("web", "frontend", "10.0.1.5", map[string]string{"app": "frontend"}),
+		Namespace: networkPathNamespace("web", map[string]string{"team": "web"}),
+	}
+
+	database := networkPathEndpoint{
+		Pod:       networkPathPod("data", "database", "10.0.2.7", map[string]string{"app": "database"}),
+		Namespace: networkPathNamespace("data", map[string]string{"team": "data"}),
+	}
+
+	udp := corev1.ProtocolUDP
+	port := func(p intstr.IntOrString) *intstr.IntOrString {
+		return &p
+	}
+
+	endPort := int32(8100)
+
+	// Isolates the database for ingress, allowing only the given peers and
+	// ports
+	ingressPolicy := func(name string, peers []v1.NetworkPolicyPeer, ports []v1.NetworkPolicyPort) *v1.NetworkPolicy {
+		return &v1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "data"},
+			Spec: v1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "database"}},
+				PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress},
+				Ingress:     []v1.NetworkPolicyIngressRule{{From: peers, Ports: ports}},
+			},
+		}
+	}
+
+	webNamespace := v1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}},
+	}
+
+	denyAllIngress := &v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-all", Namespace: "data"},
+		Spec: v1.NetworkPolicySpec{
+			PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress},
+		},
+	}
+
+	denyAllEgress := &v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-egress", Namespace: "web"},
+		Spec: v1.NetworkPolicySpec{
+			PolicyTypes: []v1.PolicyType{v1.PolicyTypeEgress},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		policies  []*v1.NetworkPolicy
+		port      int32
+		protocol  corev1.Protocol
+		allowed   bool
+		allowedBy []string
+	}{
+		{
+			name:    "without policies",
+			allowed: true,
+		},
+		{
+			name: "with policies in other namespaces",
+			policies: []*v1.NetworkPolicy{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", Namespace: "other"},
+					Spec:       v1.NetworkPolicySpec{PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress, v1.PolicyTypeEgress}},
+				},
+			},
+			allowed: true,
+		},
+		{
+			name:     "with the destination isolated",
+			policies: []*v1.NetworkPolicy{denyAllIngress},
+			allowed:  false,
+		},
+		{
+			name: "with a pod selector in the wrong namespace",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("frontend-pods", []v1.NetworkPolicyPeer{
+					{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}}},
+				}, nil),
+			},
+			allowed: false,
+		},
+		{
+			name: "with a namespace selector",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("from-web", []v1.NetworkPolicyPeer{webNamespace}, nil),
+			},
+			allowed:   true,
+			allowedBy: []string{"data/from-web"},
+		},
+		{
+			name: "with a namespace and pod selector",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("from-web-backend", []v1.NetworkPolicyPeer{
+					{
+						NamespaceSelector: webNamespace.NamespaceSelector,
+						PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "backend"}},
+					},
+				}, nil),
+			},
+			allowed: false,
+		},
+		{
+			name: "with the union of policies",
+			policies: []*v1.NetworkPolicy{
+				denyAllIngress,
+				ingressPolicy("from-web", []v1.NetworkPolicyPeer{webNamespace}, nil),
+			},
+			allowed:   true,
+			allowedBy: []string{"data/from-web"},
+		},
+		{
+			name: "with an ip block",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("from-subnet", []v1.NetworkPolicyPeer{
+					{IPBlock: &v1.IPBlock{CIDR: "10.0.0.0/16"}},
+				}, nil),
+			},
+			allowed:   true,
+			allowedBy: []string{"data/from-subnet"},
+		},
+		{
+			name: "with an ip block that excepts the source",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("from-subnet", []v1.NetworkPolicyPeer{
+					{IPBlock: &v1.IPBlock{CIDR: "10.0.0.0/16", Except: []string{"10.0.1.0/24"}}},
+				}, nil),
+			},
+			allowed: false,
+		},
+		{
+			name: "with a matching port",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("postgres", []v1.NetworkPolicyPeer{webNamespace}, []v1.NetworkPolicyPort{
+					{Port: port(intstr.FromInt32(5432))},
+				}),
+			},
+			port:      5432,
+			allowed:   true,
+			allowedBy: []string{"data/postgres"},
+		},
+		{
+			name: "with another port",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("postgres", []v1.NetworkPolicyPeer{webNamespace}, []v1.NetworkPolicyPort{
+					{Port: port(intstr.FromInt32(5432))},
+				}),
+			},
+			port:    6379,
+			allowed: false,
+		},
+		{
+			name: "with another protocol",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("dns", []v1.NetworkPolicyPeer{webNamespace}, []v1.NetworkPolicyPort{
+					{Protocol: &udp, Port: port(intstr.FromInt32(53))},
+				}),
+			},
+			port:    53,
+			allowed: false,
+		},
+		{
+			name: "with a port range",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("range", []v1.NetworkPolicyPeer{webNamespace}, []v1.NetworkPolicyPort{
+					{Port: port(intstr.FromInt32(8000)), EndPort: &endPort},
+				}),
+			},
+			port:      8080,
+			allowed:   true,
+			allowedBy: []string{"data/range"},
+		},
+		{
+			name: "with a named port",
+			policies: []*v1.NetworkPolicy{
+				ingressPolicy("named", []v1.NetworkPolicyPeer{webNamespace}, []v1.NetworkPolicyPort{
+					{Port: port(intstr.FromString("http"))},
+				}),
+			},
+			port:      8080,
+			allowed:   true,
+			allowedBy: []string{"data/named"},
+		},
+		{
+			name:     "with the source isolated",
+			policies: []*v1.NetworkPolicy{denyAllEgress},
+			allowed:  false,
+		},
+		{
+			name: "with egress and ingress allowed",
+			policies: []*v1.NetworkPolicy{
+				denyAllEgress,
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "to-data", Namespace: "web"},
+					Spec: v1.NetworkPolicySpec{
+						PolicyTypes: []v1.PolicyType{v1.PolicyTypeEgress},
+						Egress: []v1.NetworkPolicyEgressRule{
+							{
+								To: []v1.NetworkPolicyPeer{
+									{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "data"}}},
+								},
+							},
+						},
+					},
+				},
+				ingressPolicy("from-web", []v1.NetworkPolicyPeer{webNamespace}, nil),
+			},
+			allowed:   true,
+			allowedBy: []string{"web/to-data", "data/from-web"},
+		},
+		{
+			name: "with egress rules but only ingress types",
+			policies: []*v1.NetworkPolicy{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "ingress-only", Namespace: "web"},
+					Spec: v1.NetworkPolicySpec{
+						PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress},
+						Egress:      []v1.NetworkPolicyEgressRule{{To: []v1.NetworkPolicyPeer{webNamespace}}},
+					},
+				},
+			},
+			allowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.port
+
+			if p == 0 {
+				p = 80
+			}
+
+			protocol := tt.protocol
+
+			if protocol == "" {
+				protocol = corev1.ProtocolTCP
+			}
+
+			verdict := evaluateNetworkPath(frontend, database, p, protocol, tt.policies)
+
+			if verdict.Allowed != tt.allowed {
+				t.Errorf("expected allowed to be %v, got %v: %v", tt.allowed, verdict.Allowed, verdict.Reason)
+			}
+
+			if allowedBy := policyNames(verdict.AllowedBy); len(tt.allowedBy) > 0 && !slices.Equal(allowedBy, tt.allowedBy) {
+				t.Errorf("expected to be allowed by %v, got %v", tt.allowedBy, allowedBy)
+			}
+		})
+	}
+}
+
+func TestEvaluateNetworkPathHostNetwork(t *testing.T) {
+	frontend := networkPathEndpoint{
+		Pod:       networkPathPod("web", "frontend", "10.0.1.5", map[string]string{"app": "frontend"}),
+		Namespace: networkPathNamespace("web", nil),
+	}
+
+	agent := networkPathEndpoint{
+		Pod:       networkPathPod("web", "agent", "192.168.0.4", map[string]string{"app": "agent"}),
+		Namespace: frontend.Namespace,
+	}
+
+	agent.Pod.Spec.HostNetwork = true
+
+	denyAll := &v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "deny-all", Namespace: "web"},
+		Spec: v1.NetworkPolicySpec{
+			PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress, v1.PolicyTypeEgress},
+		},
+	}
+
+	// Policies don't apply to pods on the host network, so the deny-all
+	// policy only isolates the frontend
+	verdict := evaluateNetworkPath(agent, agent, 80, corev1.ProtocolTCP, []*v1.NetworkPolicy{denyAll})
+
+	if !verdict.Allowed {
+		t.Errorf("expected path between host network pods to be allowed: %v", verdict.Reason)
+	}
+
+	if len(verdict.EgressPolicies) != 0 || len(verdict.IngressPolicies) != 0 {
+		t.Errorf("expected host network pods not to be isolated, got %v and %v", policyNames(verdict.EgressPolicies), policyNames(verdict.IngressPolicies))
+	}
+
+	verdict = evaluateNetworkPath(agent, frontend, 80, corev1.ProtocolTCP, []*v1.NetworkPolicy{denyAll})
+
+	if verdict.Allowed {
+		t.Error("expected path to the isolated frontend to be denied")
+	}
+}
+
+func TestNetworkPathAdapter(t *testing.T) {
+	ctx := context.Background()
+
+	cs := fake.NewClientset(
+		networkPathNamespace("web", map[string]string{"team": "web"}),
+		networkPathNamespace("data", map[string]string{"team": "data"}),
+		networkPathNamespace("other", nil),
+		networkPathPod("web", "frontend", "10.0.1.5", map[string]string{"app": "frontend"}),
+		networkPathPod("data", "database", "10.0.2.7", map[string]string{"app": "database"}),
+		networkPathPod("other", "backend", "10.0.3.2", map[string]string{"app": "backend"}),
+		&v1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "from-web", Namespace: "data"},
+			Spec: v1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "database"}},
+				PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress},
+				Ingress: []v1.NetworkPolicyIngressRule{
+					{
+						From: []v1.NetworkPolicyPeer{
+							{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}}},
+						},
+					},
+				},
+			},
+		},
+	)
+
+	adapter := newNetworkPathAdapter(cs, "test-cluster", []string{"web", "data"})
+	searchable, ok := adapter.(interface {
+		Search(ctx context.Context, scope string, query string, ignoreCache bool) ([]*sdp.Item, error)
+	})
+
+	if !ok {
+		t.Fatal("expected NetworkPath to be searchable")
+	}
+
+	t.Run("Search for an allowed path", func(t *testing.T) {
+		items, err := searchable.Search(ctx, "test-cluster", `{"source":"web/frontend","destination":"data/database","port":5432}`, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 {
+			t.Fatalf("expected 1 item, got %v", len(items))
+		}
+
+		item := items[0]
+
+		if item.UniqueAttributeValue() != "web/frontend -> data/database TCP/5432" {
+			t.Errorf("unexpected path %v", item.UniqueAttributeValue())
+		}
+
+		allowed, err := item.GetAttributes().Get("allowed")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if allowed != true {
+			t.Errorf("expected path to be allowed, got %v", allowed)
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "Pod",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "frontend",
+				ExpectedScope:  "test-cluster.web",
+			},
+			{
+				ExpectedType:   "Pod",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "database",
+				ExpectedScope:  "test-cluster.data",
+			},
+			{
+				ExpectedType:   "NetworkPolicy",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "from-web",
+				ExpectedScope:  "test-cluster.data",
+			},
+		}.Execute(t, item)
+	})
+
+	t.Run("Search for a denied path", func(t *testing.T) {
+		items, err := searchable.Search(ctx, "test-cluster", `{"source":"data/database","destination":"data/database","port":5432}`, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		verdict, err := items[0].GetAttributes().Get("verdict")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if verdict != "denied" {
+			t.Errorf("expected path to be denied, got %v", verdict)
+		}
+	})
+
+	t.Run("Search for a pod that doesn't exist", func(t *testing.T) {
+		_, err := searchable.Search(ctx, "test-cluster", `{"source":"web/missing","destination":"data/database","port":5432}`, false)
+
+		var qErr *sdp.QueryError
+
+		if !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOTFOUND {
+			t.Errorf("expected NOTFOUND error, got %v", err)
+		}
+	})
+
+	t.Run("Bad scope", func(t *testing.T) {
+		if _, err := searchable.Search(ctx, "test-cluster.web", `{"source":"web/frontend","destination":"data/database","port":5432}`, false); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+
+	t.Run("Search in a namespace that isn't being discovered", func(t *testing.T) {
+		_, err := searchable.Search(ctx, "test-cluster", `{"source":"other/backend","destination":"data/database","port":5432}`, false)
+
+		var qErr *sdp.QueryError
+
+		if !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOSCOPE {
+			t.Errorf("expected NOSCOPE error, got %v", err)
+		}
+	})
+}
+
+func TestNetworkPathLinkAdapters(t *testing.T) {
+	ctx := context.Background()
+
+	cs := fake.NewClientset(
+		networkPathNamespace("web", nil),
+		networkPathPod("web", "frontend", "10.0.1.5", map[string]string{"app": "frontend"}),
+	)
+
+	var adapter *networkPathAdapter
+	var pods *KubeTypeAdapter[*corev1.Pod, *corev1.PodList]
+
+	for _, a := range LoadAllAdapters(cs, "test-cluster", []string{"web"}, TypeFilter{}) {
+		switch a := a.(type) {
+		case *networkPathAdapter:
+			adapter = a
+		case *KubeTypeAdapter[*corev1.Pod, *corev1.PodList]:
+			pods = a
+		}
+	}
+
+	if adapter == nil || pods == nil {
+		t.Fatal("expected NetworkPath and Pod adapters to be loaded")
+	}
+
+	if adapter.pods != pods {
+		t.Fatal("expected NetworkPath to use the loaded Pod adapter")
+	}
+
+	query := `{"source":"web/frontend","destination":"web/frontend","port":80}`
+
+	if _, err := adapter.Search(ctx, "test-cluster", query, false); err != nil {
+		t.Fatal(err)
+	}
+
+	pods.RemoveNamespace("web")
+
+	_, err := adapter.Search(ctx, "test-cluster", query, false)
+
+	var qErr *sdp.QueryError
+
+	if !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOSCOPE {
+		t.Errorf("expected NOSCOPE error once the namespace was removed, got %v", err)
+	}
+}
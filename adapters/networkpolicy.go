@@ -1,15 +1,197 @@
 package adapters
 
 import (
+	"fmt"
+	"net/netip"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/overmindtech/discovery"
 	"github.com/overmindtech/sdp-go"
 	"k8s.io/client-go/kubernetes"
 )
 
-func NetworkPolicyExtractor(resource *v1.NetworkPolicy, scope string) ([]*sdp.LinkedItemQuery, error) {
+// networkPolicyTypes Returns whether a policy restricts ingress, egress or
+// both. If the policy doesn't set its types it always restricts ingress, and
+// restricts egress if it has any egress rules
+func networkPolicyTypes(policy *v1.NetworkPolicy) (ingress bool, egress bool) {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return true, len(policy.Spec.Egress) > 0
+	}
+
+	return slices.Contains(policy.Spec.PolicyTypes, v1.PolicyTypeIngress), slices.Contains(policy.Spec.PolicyTypes, v1.PolicyTypeEgress)
+}
+
+// selectedNamespaces Returns the namespaces that are being discovered that a
+// namespace selector matches. Every namespace has a label with its name, so
+// selectors that only use that label, or match everything, can be worked out
+// without looking the namespaces up. Other selectors need the namespaces'
+// labels, so nothing is returned for them
+func selectedNamespaces(selector labels.Selector, namespaces []string) []string {
+	requirements, _ := selector.Requirements()
+
+	for _, requirement := range requirements {
+		if requirement.Key() != corev1.LabelMetadataName {
+			return nil
+		}
+	}
+
+	selected := make([]string, 0)
+
+	for _, namespace := range namespaces {
+		if selector.Matches(labels.Set{corev1.LabelMetadataName: namespace}) {
+			selected = append(selected, namespace)
+		}
+	}
+
+	slices.Sort(selected)
+
+	return selected
+}
+
+// networkPolicyPeerBlastPropagation Ingress peers are the sources of traffic,
+// so changes to the policy affect whether they can still connect. Egress
+// peers are the destinations, so changes to them e.g. an IP moving, affect
+// whether the traffic that the policy allows still reaches them
+func networkPolicyPeerBlastPropagation(direction v1.PolicyType) *sdp.BlastPropagation {
+	if direction == v1.PolicyTypeEgress {
+		return &sdp.BlastPropagation{
+			In:  true,
+			Out: false,
+		}
+	}
+
+	return &sdp.BlastPropagation{
+		In:  false,
+		Out: true,
+	}
+}
+
+// networkPolicyPeerQueries Links a peer to the pods, namespaces or IPs that it
+// allows. Pod selectors are in the policy's own namespace unless there is a
+// namespace selector, in which case the namespaces that it matches are linked
+// to. The pods are also searched for in them when the namespaces can be
+// worked out from the selector alone
+func networkPolicyPeerQueries(peer v1.NetworkPolicyPeer, sd ScopeDetails, direction v1.PolicyType, namespaces []string) ([]*sdp.LinkedItemQuery, error) {
 	queries := make([]*sdp.LinkedItemQuery, 0)
+	blastProp := networkPolicyPeerBlastPropagation(direction)
+
+	if peer.IPBlock != nil {
+		for _, cidr := range append([]string{peer.IPBlock.CIDR}, peer.IPBlock.Except...) {
+			if query := cidrQuery(cidr, blastProp); query != nil {
+				queries = append(queries, query)
+			}
+		}
+
+		// An ipBlock can't be combined with selectors
+		return queries, nil
+	}
+
+	// A missing pod selector selects every pod
+	podSelector := peer.PodSelector
+
+	if podSelector == nil {
+		podSelector = &metav1.LabelSelector{}
+	}
+
+	podQuery := LabelSelectorToQuery(podSelector)
+
+	if peer.NamespaceSelector == nil {
+		if peer.PodSelector == nil {
+			return queries, nil
+		}
+
+		return append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "Pod",
+				Method: sdp.QueryMethod_SEARCH,
+				Query:  podQuery,
+				Scope:  sd.String(),
+			},
+			BlastPropagation: blastProp,
+		}), nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+
+	if err != nil {
+		return nil, err
+	}
+
+	queries = append(queries, namespaceSelectorQuery(peer.NamespaceSelector, sd.ClusterName, blastProp))
+
+	for _, namespace := range selectedNamespaces(selector, namespaces) {
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "Pod",
+				Method: sdp.QueryMethod_SEARCH,
+				Query:  podQuery,
+				Scope: ScopeDetails{
+					ClusterName: sd.ClusterName,
+					Namespace:   namespace,
+				}.String(),
+			},
+			BlastPropagation: blastProp,
+		})
+	}
+
+	return queries, nil
+}
+
+// cidrQuery Links a CIDR to the IP if it is a single address, or to the
+// network that it is part of. Private and reserved ranges aren't registered
+// with anyone so aren't linked, nor are ranges that match everything
+func cidrQuery(cidr string, blastProp *sdp.BlastPropagation) *sdp.LinkedItemQuery {
+	prefix, err := netip.ParsePrefix(cidr)
+
+	if err != nil {
+		return nil
+	}
+
+	if prefix.IsSingleIP() {
+		return &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "ip",
+				Method: sdp.QueryMethod_GET,
+				Query:  prefix.Addr().String(),
+				Scope:  "global",
+			},
+			BlastPropagation: blastProp,
+		}
+	}
+
+	addr := prefix.Masked().Addr()
+
+	if prefix.Bits() == 0 || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+		return nil
+	}
+
+	return &sdp.LinkedItemQuery{
+		Query: &sdp.Query{
+			Type:   "rdap-ip-network",
+			Method: sdp.QueryMethod_SEARCH,
+			Query:  prefix.Masked().String(),
+			Scope:  "global",
+		},
+		BlastPropagation: blastProp,
+	}
+}
+
+// networkPolicyExtractor Extracts the links for a policy. The namespaces are
+// the ones that are being discovered, which are the only ones that pods are
+// searched for in
+func networkPolicyExtractor(resource *v1.NetworkPolicy, scope string, namespaces []string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	sd, err := ParseScope(scope, true)
+
+	if err != nil {
+		return nil, err
+	}
 
 	queries = append(queries, &sdp.LinkedItemQuery{
 		Query: &sdp.Query{
@@ -27,47 +209,101 @@ func NetworkPolicyExtractor(resource *v1.NetworkPolicy, scope string) ([]*sdp.Li
 		},
 	})
 
-	var peers []v1.NetworkPolicyPeer
-
-	for _, ig := range resource.Spec.Ingress {
-		peers = append(peers, ig.From...)
-	}
-
-	for _, eg := range resource.Spec.Egress {
-		peers = append(peers, eg.To...)
-	}
-
-	// Link all peers
-	for _, peer := range peers {
-		if ps := peer.PodSelector; ps != nil {
-			// TODO: Link to namespaces that are allowed to ingress e.g.
-			// - namespaceSelector:
-			// matchLabels:
-			//   project: something
-
-			queries = append(queries, &sdp.LinkedItemQuery{
-				Query: &sdp.Query{
-					Scope:  scope,
-					Method: sdp.QueryMethod_GET,
-					Query:  LabelSelectorToQuery(ps),
-					Type:   "Pod",
-				},
-				BlastPropagation: &sdp.BlastPropagation{
-					// Changes to pods won't affect the network policy or anything else
-					// that shares it
-					In: false,
-					// Changes to the network policy will affect pods
-					Out: true,
-				},
-			})
+	ingress, egress := networkPolicyTypes(resource)
+	peers := make(map[v1.PolicyType][]v1.NetworkPolicyPeer)
+
+	// Rules for directions that the policy doesn't restrict are ignored by
+	// kubernetes, so they are ignored here too
+	if ingress {
+		for _, rule := range resource.Spec.Ingress {
+			peers[v1.PolicyTypeIngress] = append(peers[v1.PolicyTypeIngress], rule.From...)
+		}
+	}
+
+	if egress {
+		for _, rule := range resource.Spec.Egress {
+			peers[v1.PolicyTypeEgress] = append(peers[v1.PolicyTypeEgress], rule.To...)
+		}
+	}
+
+	for _, direction := range []v1.PolicyType{v1.PolicyTypeIngress, v1.PolicyTypeEgress} {
+		for _, peer := range peers[direction] {
+			peerQueries, err := networkPolicyPeerQueries(peer, sd, direction, namespaces)
+
+			if err != nil {
+				return nil, err
+			}
+
+			queries = append(queries, peerQueries...)
 		}
 	}
 
 	return queries, nil
 }
 
-func newNetworkPolicyAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
-	return &KubeTypeAdapter[*v1.NetworkPolicy, *v1.NetworkPolicyList]{
+// networkPolicyPorts Describes the ports that rules allow e.g. `TCP/443`,
+// `TCP/8000-9000` or `UDP/dns`. Rules without ports allow every port, which
+// is shown as `*`
+func networkPolicyPorts(ports []v1.NetworkPolicyPort) []string {
+	if len(ports) == 0 {
+		return []string{"*"}
+	}
+
+	described := make([]string, 0, len(ports))
+
+	for _, port := range ports {
+		protocol := "TCP"
+
+		if port.Protocol != nil {
+			protocol = string(*port.Protocol)
+		}
+
+		switch {
+		case port.Port == nil:
+			described = append(described, protocol+"/*")
+		case port.EndPort != nil:
+			described = append(described, fmt.Sprintf("%v/%v-%v", protocol, port.Port.String(), *port.EndPort))
+		default:
+			described = append(described, protocol+"/"+port.Port.String())
+		}
+	}
+
+	return described
+}
+
+// networkPolicyAttributeExtractor Surfaces the ports that traffic is allowed
+// on in each direction so that they can be searched on
+func networkPolicyAttributeExtractor(resource *v1.NetworkPolicy) (map[string]interface{}, error) {
+	attributes := make(map[string]interface{})
+	ingress, egress := networkPolicyTypes(resource)
+
+	if ingress {
+		ports := make([]string, 0)
+
+		for _, rule := range resource.Spec.Ingress {
+			ports = append(ports, networkPolicyPorts(rule.Ports)...)
+		}
+
+		slices.Sort(ports)
+		attributes["ingressPorts"] = slices.Compact(ports)
+	}
+
+	if egress {
+		ports := make([]string, 0)
+
+		for _, rule := range resource.Spec.Egress {
+			ports = append(ports, networkPolicyPorts(rule.Ports)...)
+		}
+
+		slices.Sort(ports)
+		attributes["egressPorts"] = slices.Compact(ports)
+	}
+
+	return attributes, nil
+}
+
+func newNetworkPolicyAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	adapter := &KubeTypeAdapter[*v1.NetworkPolicy, *v1.NetworkPolicyList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
 		TypeName:    "NetworkPolicy",
@@ -83,16 +319,24 @@ func newNetworkPolicyAdapter(cs *kubernetes.Clientset, cluster string, namespace
 
 			return extracted, nil
 		},
-		LinkedItemQueryExtractor: NetworkPolicyExtractor,
-		AdapterMetadata:          networkPolicyAdapterMetadata,
+		AttributeExtractor: networkPolicyAttributeExtractor,
+		AdapterMetadata:    networkPolicyAdapterMetadata,
 	}
+
+	// The adapter's namespaces follow the namespace filter, so peers are only
+	// searched for in the namespaces that are being discovered
+	adapter.LinkedItemQueryExtractor = func(resource *v1.NetworkPolicy, scope string) ([]*sdp.LinkedItemQuery, error) {
+		return networkPolicyExtractor(resource, scope, adapter.currentNamespaces())
+	}
+
+	return adapter
 }
 
 var networkPolicyAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
 	Type:            "NetworkPolicy",
 	DescriptiveName: "Network Policy",
 	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
-	PotentialLinks:  []string{"Pod"},
+	PotentialLinks:  []string{"Pod", "Namespace", "ip", "rdap-ip-network"},
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
 			TerraformMethod:   sdp.QueryMethod_GET,
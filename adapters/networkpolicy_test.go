@@ -2,9 +2,14 @@ package adapters
 
 import (
 	"regexp"
+	"slices"
 	"testing"
 
 	"github.com/overmindtech/sdp-go"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 var NetworkPolicyYAML = `
@@ -48,8 +53,290 @@ func TestNetworkPolicyAdapter(t *testing.T) {
 				ExpectedMethod:       sdp.QueryMethod_SEARCH,
 				ExpectedScope:        sd.String(),
 			},
+			{
+				ExpectedType:   "Pod",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  `{"labelSelector":"app=frontend"}`,
+				ExpectedScope:  sd.String(),
+			},
 		},
 	}
 
 	st.Execute(t)
 }
+
+func TestNetworkPolicyPeerQueries(t *testing.T) {
+	sd := ScopeDetails{ClusterName: "test-cluster", Namespace: "default"}
+	namespaces := []string{"default", "logging", "web"}
+
+	tests := []struct {
+		name     string
+		peer     v1.NetworkPolicyPeer
+		queries  int
+		expected QueryTests
+	}{
+		{
+			name:    "pod selector",
+			queries: 1,
+			peer: v1.NetworkPolicyPeer{
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}},
+			},
+			expected: QueryTests{
+				{
+					ExpectedType:   "Pod",
+					ExpectedMethod: sdp.QueryMethod_SEARCH,
+					ExpectedQuery:  `{"labelSelector":"app=frontend"}`,
+					ExpectedScope:  "test-cluster.default",
+				},
+			},
+		},
+		{
+			name:    "namespace selector",
+			queries: 1,
+			peer: v1.NetworkPolicyPeer{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+			},
+			expected: QueryTests{
+				{
+					ExpectedType:   "Namespace",
+					ExpectedMethod: sdp.QueryMethod_SEARCH,
+					ExpectedQuery:  `{"labelSelector":"team=platform"}`,
+					ExpectedScope:  "test-cluster",
+				},
+			},
+		},
+		{
+			name:    "namespace and pod selector",
+			queries: 1,
+			peer: v1.NetworkPolicyPeer{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "web"}},
+				PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}},
+			},
+			expected: QueryTests{
+				{
+					ExpectedType:   "Namespace",
+					ExpectedMethod: sdp.QueryMethod_SEARCH,
+					ExpectedQuery:  `{"labelSelector":"team=web"}`,
+					ExpectedScope:  "test-cluster",
+				},
+			},
+		},
+		{
+			name:    "namespace name selector",
+			queries: 2,
+			peer: v1.NetworkPolicyPeer{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpIn, Values: []string{"web", "monitoring"}},
+					},
+				},
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}},
+			},
+			expected: QueryTests{
+				{
+					ExpectedType:   "Namespace",
+					ExpectedMethod: sdp.QueryMethod_SEARCH,
+					ExpectedQuery:  `{"labelSelector":"kubernetes.io/metadata.name in (monitoring,web)"}`,
+					ExpectedScope:  "test-cluster",
+				},
+				{
+					ExpectedType:   "Pod",
+					ExpectedMethod: sdp.QueryMethod_SEARCH,
+					ExpectedQuery:  `{"labelSelector":"app=frontend"}`,
+					ExpectedScope:  "test-cluster.web",
+				},
+			},
+		},
+		{
+			name:    "empty namespace selector",
+			queries: 4,
+			peer: v1.NetworkPolicyPeer{
+				NamespaceSelector: &metav1.LabelSelector{},
+			},
+			expected: QueryTests{
+				{
+					ExpectedType:   "Namespace",
+					ExpectedMethod: sdp.QueryMethod_LIST,
+					ExpectedScope:  "test-cluster",
+				},
+				{
+					ExpectedType:   "Pod",
+					ExpectedMethod: sdp.QueryMethod_SEARCH,
+					ExpectedQuery:  "{}",
+					ExpectedScope:  "test-cluster.web",
+				},
+			},
+		},
+		{
+			name:    "ip block",
+			queries: 2,
+			peer: v1.NetworkPolicyPeer{
+				IPBlock: &v1.IPBlock{
+					CIDR:   "203.0.113.0/24",
+					Except: []string{"203.0.113.7/32"},
+				},
+			},
+			expected: QueryTests{
+				{
+					ExpectedType:   "rdap-ip-network",
+					ExpectedMethod: sdp.QueryMethod_SEARCH,
+					ExpectedQuery:  "203.0.113.0/24",
+					ExpectedScope:  "global",
+				},
+				{
+					ExpectedType:   "ip",
+					ExpectedMethod: sdp.QueryMethod_GET,
+					ExpectedQuery:  "203.0.113.7",
+					ExpectedScope:  "global",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries, err := networkPolicyPeerQueries(tt.peer, sd, v1.PolicyTypeIngress, namespaces)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(queries) != tt.queries {
+				t.Errorf("expected %v queries, got %v", tt.queries, len(queries))
+			}
+
+			tt.expected.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+		})
+	}
+
+	t.Run("private and catch-all ip blocks", func(t *testing.T) {
+		queries, err := networkPolicyPeerQueries(v1.NetworkPolicyPeer{
+			IPBlock: &v1.IPBlock{
+				CIDR:   "0.0.0.0/0",
+				Except: []string{"10.0.0.0/8", "fd00::/8"},
+			},
+		}, sd, v1.PolicyTypeEgress, namespaces)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(queries) != 0 {
+			t.Errorf("expected no queries, got %v", queries)
+		}
+	})
+}
+
+func TestNetworkPolicyExtractor(t *testing.T) {
+	frontend := v1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}},
+	}
+
+	database := v1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "database"}},
+	}
+
+	policy := func(policyTypes ...v1.PolicyType) *v1.NetworkPolicy {
+		return &v1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: v1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				PolicyTypes: policyTypes,
+				Ingress:     []v1.NetworkPolicyIngressRule{{From: []v1.NetworkPolicyPeer{frontend}}},
+				Egress:      []v1.NetworkPolicyEgressRule{{To: []v1.NetworkPolicyPeer{database}}},
+			},
+		}
+	}
+
+	peerDirections := func(t *testing.T, resource *v1.NetworkPolicy) map[string]*sdp.BlastPropagation {
+		t.Helper()
+
+		queries, err := networkPolicyExtractor(resource, "test-cluster.default", []string{"default"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		directions := make(map[string]*sdp.BlastPropagation)
+
+		for _, query := range queries {
+			directions[query.GetQuery().GetQuery()] = query.GetBlastPropagation()
+		}
+
+		return directions
+	}
+
+	t.Run("without policy types", func(t *testing.T) {
+		directions := peerDirections(t, policy())
+
+		if _, ok := directions[`{"labelSelector":"app=web"}`]; !ok {
+			t.Error("expected a link to the pods the policy applies to")
+		}
+
+		if bp := directions[`{"labelSelector":"app=frontend"}`]; bp == nil || bp.GetIn() || !bp.GetOut() {
+			t.Errorf("expected the ingress peer to be affected by the policy, got %v", bp)
+		}
+
+		if bp := directions[`{"labelSelector":"app=database"}`]; bp == nil || !bp.GetIn() || bp.GetOut() {
+			t.Errorf("expected the egress peer to affect the policy, got %v", bp)
+		}
+	})
+
+	t.Run("with only ingress", func(t *testing.T) {
+		directions := peerDirections(t, policy(v1.PolicyTypeIngress))
+
+		if _, ok := directions[`{"labelSelector":"app=database"}`]; ok {
+			t.Error("expected egress rules to be ignored")
+		}
+
+		if _, ok := directions[`{"labelSelector":"app=frontend"}`]; !ok {
+			t.Error("expected a link to the ingress peer")
+		}
+	})
+
+	t.Run("with only egress", func(t *testing.T) {
+		directions := peerDirections(t, policy(v1.PolicyTypeEgress))
+
+		if _, ok := directions[`{"labelSelector":"app=frontend"}`]; ok {
+			t.Error("expected ingress rules to be ignored")
+		}
+
+		if _, ok := directions[`{"labelSelector":"app=database"}`]; !ok {
+			t.Error("expected a link to the egress peer")
+		}
+	})
+}
+
+func TestNetworkPolicyAttributeExtractor(t *testing.T) {
+	udp := corev1.ProtocolUDP
+	http := intstr.FromString("http")
+	https := intstr.FromInt32(443)
+	high := intstr.FromInt32(8000)
+	endPort := int32(9000)
+
+	attributes, err := networkPolicyAttributeExtractor(&v1.NetworkPolicy{
+		Spec: v1.NetworkPolicySpec{
+			PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress, v1.PolicyTypeEgress},
+			Ingress: []v1.NetworkPolicyIngressRule{
+				{Ports: []v1.NetworkPolicyPort{{Port: &https}, {Port: &http}}},
+				{Ports: []v1.NetworkPolicyPort{{Port: &high, EndPort: &endPort}}},
+			},
+			Egress: []v1.NetworkPolicyEgressRule{
+				{Ports: []v1.NetworkPolicyPort{{Protocol: &udp}}},
+				{},
+			},
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ports := attributes["ingressPorts"].([]string); !slices.Equal(ports, []string{"TCP/443", "TCP/8000-9000", "TCP/http"}) {
+		t.Errorf("unexpected ingress ports %v", ports)
+	}
+
+	if ports := attributes["egressPorts"].([]string); !slices.Equal(ports, []string{"*", "UDP/*"}) {
+		t.Errorf("unexpected egress ports %v", ports)
+	}
+}
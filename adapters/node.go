@@ -1,15 +1,19 @@
 package adapters
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/overmindtech/discovery"
 	"github.com/overmindtech/sdp-go"
 	v1 "k8s.io/api/core/v1"
-
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
+// linkedItemExtractor Extracts the links for a node. The pods on the node can
+// be in any namespace, so they are searched for in the cluster scope, which
+// covers every namespace that is being discovered
 func linkedItemExtractor(resource *v1.Node, scope string) ([]*sdp.LinkedItemQuery, error) {
 	queries := make([]*sdp.LinkedItemQuery, 0)
 
@@ -72,10 +76,193 @@ func linkedItemExtractor(resource *v1.Node, scope string) ([]*sdp.LinkedItemQuer
 		}
 	}
 
+	queries = append(queries, providerIDQueries(resource.Spec.ProviderID)...)
+
+	queries = append(queries, &sdp.LinkedItemQuery{
+		Query: &sdp.Query{
+			Type:   "Pod",
+			Method: sdp.QueryMethod_SEARCH,
+			Query: ListOptionsToQuery(&metav1.ListOptions{
+				FieldSelector: Selector{
+					"spec.nodeName": resource.Name,
+				}.String(),
+			}),
+			Scope: scope,
+		},
+		BlastPropagation: &sdp.BlastPropagation{
+			// The pods can't change the node
+			In: false,
+			// If the node fails then so do all of its pods
+			Out: true,
+		},
+	})
+
 	return queries, nil
 }
 
-func newNodeAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+// providerIDQueries Links a node to the cloud instance that it is running on,
+// using the provider ID which is in one of the following formats:
+//
+//   - AWS: aws:///eu-west-2a/i-0abc123
+//   - GCP: gce://my-project/europe-west2-a/my-instance
+//   - Azure: azure:///subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Compute/virtualMachines/{name}
+//   - Azure scale sets: azure:///subscriptions/{sub}/resourceGroups/{rg}/providers/Microsoft.Compute/virtualMachineScaleSets/{set}/virtualMachines/{id}
+func providerIDQueries(providerID string) []*sdp.LinkedItemQuery {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	provider, path, found := strings.Cut(providerID, "://")
+
+	if !found {
+		return queries
+	}
+
+	sections := strings.Split(strings.Trim(path, "/"), "/")
+
+	// The node is the instance, so changes go both ways
+	blastPropagation := &sdp.BlastPropagation{
+		In:  true,
+		Out: true,
+	}
+
+	switch provider {
+	case "aws":
+		// The zone is optional, the instance ID is always last. Fargate nodes
+		// aren't EC2 instances so are ignored
+		instanceID := sections[len(sections)-1]
+
+		if strings.HasPrefix(instanceID, "i-") {
+			queries = append(queries, &sdp.LinkedItemQuery{
+				Query: &sdp.Query{
+					Type:   "ec2-instance",
+					Method: sdp.QueryMethod_GET,
+					Query:  instanceID,
+					Scope:  "*",
+				},
+				BlastPropagation: blastPropagation,
+			})
+		}
+	case "gce":
+		// Instances are zonal, so are in the scope of their project and zone
+		if len(sections) == 3 && !slices.Contains(sections, "") {
+			queries = append(queries, &sdp.LinkedItemQuery{
+				Query: &sdp.Query{
+					Type:   "gcp-compute-instance",
+					Method: sdp.QueryMethod_GET,
+					Query:  sections[2],
+					Scope:  sections[0] + "." + sections[1],
+				},
+				BlastPropagation: blastPropagation,
+			})
+		}
+	case "azure":
+		// Scale set instances are nested under the scale set, so whichever
+		// type comes first is the one that the node belongs to
+		for i := 0; i+1 < len(sections); i++ {
+			switch strings.ToLower(sections[i]) {
+			case "virtualmachinescalesets":
+				queries = append(queries, &sdp.LinkedItemQuery{
+					Query: &sdp.Query{
+						Type:   "azure-compute-virtual-machine-scale-set",
+						Method: sdp.QueryMethod_GET,
+						Query:  sections[i+1],
+						Scope:  "*",
+					},
+					BlastPropagation: &sdp.BlastPropagation{
+						// Changes to the scale set can replace the node
+						In: true,
+						// The node is only one instance in the scale set
+						Out: false,
+					},
+				})
+
+				return queries
+			case "virtualmachines":
+				queries = append(queries, &sdp.LinkedItemQuery{
+					Query: &sdp.Query{
+						Type:   "azure-compute-virtual-machine",
+						Method: sdp.QueryMethod_GET,
+						Query:  sections[i+1],
+						Scope:  "*",
+					},
+					BlastPropagation: blastPropagation,
+				})
+
+				return queries
+			}
+		}
+	}
+
+	return queries
+}
+
+// Well-known node labels that describe where a node is running. The beta
+// labels are deprecated but still set by some providers
+var nodeLocationLabels = map[string][]string{
+	"region":       {v1.LabelTopologyRegion, v1.LabelFailureDomainBetaRegion},
+	"zone":         {v1.LabelTopologyZone, v1.LabelFailureDomainBetaZone},
+	"instanceType": {v1.LabelInstanceTypeStable, v1.LabelInstanceType},
+}
+
+// nodeAttributeExtractor Surfaces the cloud provider, zone and instance type of
+// a node as top-level attributes so that they can be searched on
+func nodeAttributeExtractor(resource *v1.Node) (map[string]interface{}, error) {
+	attributes := make(map[string]interface{})
+
+	for attribute, labels := range nodeLocationLabels {
+		for _, label := range labels {
+			if value, ok := resource.Labels[label]; ok && value != "" {
+				attributes[attribute] = value
+				break
+			}
+		}
+	}
+
+	if provider, _, found := strings.Cut(resource.Spec.ProviderID, "://"); found {
+		attributes["cloudProvider"] = provider
+	}
+
+	return attributes, nil
+}
+
+// nodeHealthExtractor Nodes that aren't ready can't run pods. Nodes that are
+// ready but under pressure, or that have been cordoned, can run existing pods
+// but may evict them or not accept new ones
+func nodeHealthExtractor(resource *v1.Node) *sdp.Health {
+	conditions := make(map[v1.NodeConditionType]v1.ConditionStatus)
+
+	for _, condition := range resource.Status.Conditions {
+		conditions[condition.Type] = condition.Status
+	}
+
+	switch conditions[v1.NodeReady] {
+	case v1.ConditionTrue:
+		// Carry on checking the other conditions
+	case v1.ConditionFalse:
+		return sdp.Health_HEALTH_ERROR.Enum()
+	default:
+		// The kubelet has stopped reporting, or hasn't reported yet
+		return sdp.Health_HEALTH_UNKNOWN.Enum()
+	}
+
+	for _, conditionType := range []v1.NodeConditionType{
+		v1.NodeMemoryPressure,
+		v1.NodeDiskPressure,
+		v1.NodePIDPressure,
+		v1.NodeNetworkUnavailable,
+	} {
+		if conditions[conditionType] == v1.ConditionTrue {
+			return sdp.Health_HEALTH_WARNING.Enum()
+		}
+	}
+
+	if resource.Spec.Unschedulable {
+		return sdp.Health_HEALTH_WARNING.Enum()
+	}
+
+	return sdp.Health_HEALTH_OK.Enum()
+}
+
+func newNodeAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.Node, *v1.NodeList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -93,15 +280,26 @@ func newNodeAdapter(cs *kubernetes.Clientset, cluster string, namespaces []strin
 			return extracted, nil
 		},
 		LinkedItemQueryExtractor: linkedItemExtractor,
+		HealthExtractor:          nodeHealthExtractor,
+		AttributeExtractor:       nodeAttributeExtractor,
 		AdapterMetadata:          nodeAdapterMetadata,
 	}
 }
 
 var nodeAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "Node",
-	DescriptiveName:       "Node",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
-	PotentialLinks:        []string{"dns", "ip", "ec2-volume"},
+	Type:            "Node",
+	DescriptiveName: "Node",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
+	PotentialLinks: []string{
+		"dns",
+		"ip",
+		"ec2-volume",
+		"ec2-instance",
+		"gcp-compute-instance",
+		"azure-compute-virtual-machine",
+		"azure-compute-virtual-machine-scale-set",
+		"Pod",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Node"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestNodeAdapter(t *testing.T) {
@@ -26,8 +28,189 @@ func TestNodeAdapter(t *testing.T) {
 				ExpectedScope:        "global",
 				ExpectedQueryMatches: regexp.MustCompile(`172\.`),
 			},
+			{
+				ExpectedType:   "Pod",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedScope:  CurrentCluster.Name,
+				ExpectedQuery:  `{"fieldSelector":"spec.nodeName=local-tests-control-plane"}`,
+			},
 		},
 	}
 
 	st.Execute(t)
 }
+
+func TestProviderIDQueries(t *testing.T) {
+	tests := []struct {
+		name          string
+		providerID    string
+		expectedType  string
+		expectedQuery string
+		expectedScope string
+	}{
+		{
+			name:          "AWS",
+			providerID:    "aws:///eu-west-2a/i-0abc123def456",
+			expectedType:  "ec2-instance",
+			expectedQuery: "i-0abc123def456",
+		},
+		{
+			name:          "AWS without zone",
+			providerID:    "aws:///i-0abc123def456",
+			expectedType:  "ec2-instance",
+			expectedQuery: "i-0abc123def456",
+		},
+		{
+			name:       "AWS Fargate",
+			providerID: "aws:///eu-west-2a/fargate-ip-10-0-1-2.eu-west-2.compute.internal",
+		},
+		{
+			name:          "GCP",
+			providerID:    "gce://my-project/europe-west2-a/gke-cluster-pool-abc123",
+			expectedType:  "gcp-compute-instance",
+			expectedQuery: "gke-cluster-pool-abc123",
+			expectedScope: "my-project.europe-west2-a",
+		},
+		{
+			name:       "GCP without zone",
+			providerID: "gce://my-project//gke-cluster-pool-abc123",
+		},
+		{
+			name:          "Azure",
+			providerID:    "azure:///subscriptions/1234/resourceGroups/my-rg/providers/Microsoft.Compute/virtualMachines/my-vm",
+			expectedType:  "azure-compute-virtual-machine",
+			expectedQuery: "my-vm",
+		},
+		{
+			name:          "Azure scale set",
+			providerID:    "azure:///subscriptions/1234/resourceGroups/mc_rg/providers/Microsoft.Compute/virtualMachineScaleSets/aks-nodepool1-123-vmss/virtualMachines/0",
+			expectedType:  "azure-compute-virtual-machine-scale-set",
+			expectedQuery: "aks-nodepool1-123-vmss",
+		},
+		{
+			name:       "kind",
+			providerID: "kind://docker/local-tests/local-tests-control-plane",
+		},
+		{
+			name:       "empty",
+			providerID: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := providerIDQueries(tt.providerID)
+
+			if tt.expectedType == "" {
+				if len(queries) != 0 {
+					t.Errorf("expected no queries, got %v", queries)
+				}
+
+				return
+			}
+
+			if len(queries) != 1 {
+				t.Fatalf("expected 1 query, got %v", len(queries))
+			}
+
+			scope := tt.expectedScope
+
+			if scope == "" {
+				scope = "*"
+			}
+
+			QueryTests{
+				{
+					ExpectedType:   tt.expectedType,
+					ExpectedMethod: sdp.QueryMethod_GET,
+					ExpectedQuery:  tt.expectedQuery,
+					ExpectedScope:  scope,
+				},
+			}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+		})
+	}
+}
+
+func TestNodeAttributeExtractor(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-1",
+			Labels: map[string]string{
+				"topology.kubernetes.io/region":          "eu-west-2",
+				"topology.kubernetes.io/zone":            "eu-west-2a",
+				"failure-domain.beta.kubernetes.io/zone": "eu-west-2b",
+				"beta.kubernetes.io/instance-type":       "m5.large",
+			},
+		},
+		Spec: v1.NodeSpec{
+			ProviderID: "aws:///eu-west-2a/i-0abc123def456",
+		},
+	}
+
+	attributes, err := nodeAttributeExtractor(node)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"region":        "eu-west-2",
+		"zone":          "eu-west-2a",
+		"instanceType":  "m5.large",
+		"cloudProvider": "aws",
+	}
+
+	for key, value := range expected {
+		if attributes[key] != value {
+			t.Errorf("expected %v to be %v, got %v", key, value, attributes[key])
+		}
+	}
+}
+
+func TestNodeHealthExtractor(t *testing.T) {
+	node := func(unschedulable bool, conditions ...v1.NodeCondition) *v1.Node {
+		return &v1.Node{
+			Spec: v1.NodeSpec{
+				Unschedulable: unschedulable,
+			},
+			Status: v1.NodeStatus{
+				Conditions: conditions,
+			},
+		}
+	}
+
+	ready := v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue}
+
+	HealthTests[*v1.Node]{
+		{
+			Name:           "ready",
+			Resource:       node(false, ready, v1.NodeCondition{Type: v1.NodeMemoryPressure, Status: v1.ConditionFalse}),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "not ready",
+			Resource:       node(false, v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionFalse}),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name:           "stopped reporting",
+			Resource:       node(false, v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionUnknown}),
+			ExpectedHealth: sdp.Health_HEALTH_UNKNOWN.Enum(),
+		},
+		{
+			Name:           "memory pressure",
+			Resource:       node(false, ready, v1.NodeCondition{Type: v1.NodeMemoryPressure, Status: v1.ConditionTrue}),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+		{
+			Name:           "disk pressure",
+			Resource:       node(false, ready, v1.NodeCondition{Type: v1.NodeDiskPressure, Status: v1.ConditionTrue}),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+		{
+			Name:           "cordoned",
+			Resource:       node(true, ready),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+	}.Execute(t, nodeHealthExtractor)
+}
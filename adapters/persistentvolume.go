@@ -91,7 +91,25 @@ func PersistentVolumeExtractor(resource *v1.PersistentVolume, scope string) ([]*
 	return queries, nil
 }
 
-func newPersistentVolumeAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func persistentVolumeHealthExtractor(resource *v1.PersistentVolume) *sdp.Health {
+	switch resource.Status.Phase {
+	case v1.VolumeAvailable, v1.VolumeBound:
+		return sdp.Health_HEALTH_OK.Enum()
+	case v1.VolumePending:
+		return sdp.Health_HEALTH_PENDING.Enum()
+	case v1.VolumeReleased:
+		// The claim has been deleted but the volume hasn't been reclaimed, so
+		// it can't be used by a new claim
+		return sdp.Health_HEALTH_WARNING.Enum()
+	case v1.VolumeFailed:
+		// Automatic reclamation failed
+		return sdp.Health_HEALTH_ERROR.Enum()
+	}
+
+	return nil
+}
+
+func newPersistentVolumeAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.PersistentVolume, *v1.PersistentVolumeList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -109,6 +127,7 @@ func newPersistentVolumeAdapter(cs *kubernetes.Clientset, cluster string, namesp
 			return extracted, nil
 		},
 		LinkedItemQueryExtractor: PersistentVolumeExtractor,
+		HealthExtractor:          persistentVolumeHealthExtractor,
 		AdapterMetadata:          persistentVolumeAdapterMetadata,
 	}
 }
@@ -2,6 +2,9 @@ package adapters
 
 import (
 	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
 )
 
 var persistentVolumeYAML = `
@@ -37,3 +40,41 @@ func TestPersistentVolumeAdapter(t *testing.T) {
 
 	st.Execute(t)
 }
+
+func TestPersistentVolumeHealthExtractor(t *testing.T) {
+	pv := func(phase v1.PersistentVolumePhase) *v1.PersistentVolume {
+		return &v1.PersistentVolume{
+			Status: v1.PersistentVolumeStatus{
+				Phase: phase,
+			},
+		}
+	}
+
+	HealthTests[*v1.PersistentVolume]{
+		{
+			Name:           "available",
+			Resource:       pv(v1.VolumeAvailable),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "bound",
+			Resource:       pv(v1.VolumeBound),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "pending",
+			Resource:       pv(v1.VolumePending),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name:           "released",
+			Resource:       pv(v1.VolumeReleased),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+		{
+			Name:           "failed",
+			Resource:       pv(v1.VolumeFailed),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+	}.Execute(t, persistentVolumeHealthExtractor)
+}
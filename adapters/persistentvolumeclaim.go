@@ -37,7 +37,21 @@ func PersistentVolumeClaimExtractor(resource *v1.PersistentVolumeClaim, scope st
 	return links, nil
 }
 
-func newPersistentVolumeClaimAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func persistentVolumeClaimHealthExtractor(resource *v1.PersistentVolumeClaim) *sdp.Health {
+	switch resource.Status.Phase {
+	case v1.ClaimBound:
+		return sdp.Health_HEALTH_OK.Enum()
+	case v1.ClaimPending:
+		return sdp.Health_HEALTH_PENDING.Enum()
+	case v1.ClaimLost:
+		// The volume that the claim was bound to no longer exists
+		return sdp.Health_HEALTH_ERROR.Enum()
+	}
+
+	return nil
+}
+
+func newPersistentVolumeClaimAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.PersistentVolumeClaim, *v1.PersistentVolumeClaimList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -55,6 +69,7 @@ func newPersistentVolumeClaimAdapter(cs *kubernetes.Clientset, cluster string, n
 			return extracted, nil
 		},
 		LinkedItemQueryExtractor: PersistentVolumeClaimExtractor,
+		HealthExtractor:          persistentVolumeClaimHealthExtractor,
 		AdapterMetadata:          persistentVolumeClaimAdapterMetadata,
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
 )
 
 var persistentVolumeClaimYAML = `
@@ -78,3 +79,35 @@ func TestPersistentVolumeClaimAdapter(t *testing.T) {
 
 	st.Execute(t)
 }
+
+func TestPersistentVolumeClaimHealthExtractor(t *testing.T) {
+	pvc := func(phase v1.PersistentVolumeClaimPhase) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			Status: v1.PersistentVolumeClaimStatus{
+				Phase: phase,
+			},
+		}
+	}
+
+	HealthTests[*v1.PersistentVolumeClaim]{
+		{
+			Name:           "bound",
+			Resource:       pvc(v1.ClaimBound),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "pending",
+			Resource:       pvc(v1.ClaimPending),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name:           "lost",
+			Resource:       pvc(v1.ClaimLost),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name:     "no phase",
+			Resource: pvc(""),
+		},
+	}.Execute(t, persistentVolumeClaimHealthExtractor)
+}
@@ -30,7 +30,25 @@ func podDisruptionBudgetExtractor(resource *v1.PodDisruptionBudget, scope string
 	return queries, nil
 }
 
-func newPodDisruptionBudgetAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func podDisruptionBudgetHealthExtractor(resource *v1.PodDisruptionBudget) *sdp.Health {
+	if resource.Status.ObservedGeneration < resource.Generation {
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	// There are already fewer healthy pods than the budget requires
+	if resource.Status.CurrentHealthy < resource.Status.DesiredHealthy {
+		return sdp.Health_HEALTH_ERROR.Enum()
+	}
+
+	// No pods can be evicted, which will block things like node drains
+	if resource.Status.DisruptionsAllowed == 0 {
+		return sdp.Health_HEALTH_WARNING.Enum()
+	}
+
+	return sdp.Health_HEALTH_OK.Enum()
+}
+
+func newPodDisruptionBudgetAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.PodDisruptionBudget, *v1.PodDisruptionBudgetList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -48,6 +66,7 @@ func newPodDisruptionBudgetAdapter(cs *kubernetes.Clientset, cluster string, nam
 			return extracted, nil
 		},
 		LinkedItemQueryExtractor: podDisruptionBudgetExtractor,
+		HealthExtractor:          podDisruptionBudgetHealthExtractor,
 		AdapterMetadata:          podDisruptionBudgetAdapterMetadata,
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/policy/v1"
 )
 
 var PodDisruptionBudgetYAML = `
@@ -44,3 +45,33 @@ func TestPodDisruptionBudgetAdapter(t *testing.T) {
 
 	st.Execute(t)
 }
+
+func TestPodDisruptionBudgetHealthExtractor(t *testing.T) {
+	pdb := func(currentHealthy, desiredHealthy, disruptionsAllowed int32) *v1.PodDisruptionBudget {
+		return &v1.PodDisruptionBudget{
+			Status: v1.PodDisruptionBudgetStatus{
+				CurrentHealthy:     currentHealthy,
+				DesiredHealthy:     desiredHealthy,
+				DisruptionsAllowed: disruptionsAllowed,
+			},
+		}
+	}
+
+	HealthTests[*v1.PodDisruptionBudget]{
+		{
+			Name:           "disruptions allowed",
+			Resource:       pdb(3, 2, 1),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "no disruptions allowed",
+			Resource:       pdb(2, 2, 0),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+		{
+			Name:           "not enough healthy pods",
+			Resource:       pdb(1, 2, 0),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+	}.Execute(t, podDisruptionBudgetHealthExtractor)
+}
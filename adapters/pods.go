@@ -3,17 +3,27 @@ package adapters
 import (
 	"net"
 	"slices"
+	"strconv"
 	"time"
 
 	"github.com/overmindtech/discovery"
 	"github.com/overmindtech/sdp-go"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/kubernetes"
 )
 
-func PodExtractor(resource *v1.Pod, scope string) ([]*sdp.LinkedItemQuery, error) {
+// PodSpecExtractor Extracts linked item queries from a pod spec. This is used
+// for pods themselves, and for the pod templates of workload controllers so
+// that they are linked to the things that their pods will use even when no
+// pods are running
+func PodSpecExtractor(spec *v1.PodSpec, scope string) ([]*sdp.LinkedItemQuery, error) {
 	queries := make([]*sdp.LinkedItemQuery, 0)
 
+	if spec == nil {
+		return queries, nil
+	}
+
 	sd, err := ParseScope(scope, true)
 
 	if err != nil {
@@ -21,13 +31,13 @@ func PodExtractor(resource *v1.Pod, scope string) ([]*sdp.LinkedItemQuery, error
 	}
 
 	// Link service accounts
-	if resource.Spec.ServiceAccountName != "" {
+	if spec.ServiceAccountName != "" {
 		queries = append(queries, &sdp.LinkedItemQuery{
 			Query: &sdp.Query{
 				Type:   "ServiceAccount",
 				Scope:  scope,
 				Method: sdp.QueryMethod_GET,
-				Query:  resource.Spec.ServiceAccountName,
+				Query:  spec.ServiceAccountName,
 			},
 			BlastPropagation: &sdp.BlastPropagation{
 				// Changes to the service account can affect the pod
@@ -39,7 +49,7 @@ func PodExtractor(resource *v1.Pod, scope string) ([]*sdp.LinkedItemQuery, error
 	}
 
 	// Link items from volumes
-	for _, vol := range resource.Spec.Volumes {
+	for _, vol := range spec.Volumes {
 		// Link PVCs
 		if vol.PersistentVolumeClaim != nil {
 			queries = append(queries, &sdp.LinkedItemQuery{
@@ -190,8 +200,32 @@ func PodExtractor(resource *v1.Pod, scope string) ([]*sdp.LinkedItemQuery, error
 		}
 	}
 
+	// Link the images that the containers run
+	for _, container := range slices.Concat(spec.InitContainers, spec.Containers) {
+		ref, err := ParseImageReference(container.Image)
+
+		if err != nil {
+			continue
+		}
+
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Scope:  scope,
+				Method: sdp.QueryMethod_GET,
+				Query:  ref.String(),
+				Type:   "ContainerImage",
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// A broken or missing image will break the pod
+				In: true,
+				// The pod however isn't going to affect the image
+				Out: false,
+			},
+		})
+	}
+
 	// Link items from containers
-	for _, container := range resource.Spec.Containers {
+	for _, container := range spec.Containers {
 		// Loop over environment variables
 		for _, env := range container.Env {
 			if env.ValueFrom != nil {
@@ -270,12 +304,12 @@ func PodExtractor(resource *v1.Pod, scope string) ([]*sdp.LinkedItemQuery, error
 		}
 	}
 
-	if resource.Spec.PriorityClassName != "" {
+	if spec.PriorityClassName != "" {
 		queries = append(queries, &sdp.LinkedItemQuery{
 			Query: &sdp.Query{
 				Scope:  sd.ClusterName,
 				Method: sdp.QueryMethod_GET,
-				Query:  resource.Spec.PriorityClassName,
+				Query:  spec.PriorityClassName,
 				Type:   "PriorityClass",
 			},
 			BlastPropagation: &sdp.BlastPropagation{
@@ -289,6 +323,40 @@ func PodExtractor(resource *v1.Pod, scope string) ([]*sdp.LinkedItemQuery, error
 		})
 	}
 
+	return queries, nil
+}
+
+func PodExtractor(resource *v1.Pod, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries, err := PodSpecExtractor(&resource.Spec, scope)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resource.Spec.NodeName != "" {
+		sd, err := ParseScope(scope, true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "Node",
+				Method: sdp.QueryMethod_GET,
+				Query:  resource.Spec.NodeName,
+				Scope:  sd.ClusterName,
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// If the node fails then so does the pod
+				In: true,
+				// The pod can use up the resources of the node, but if it
+				// does the node will evict it
+				Out: false,
+			},
+		})
+	}
+
 	if len(resource.Status.PodIPs) > 0 {
 		for _, ip := range resource.Status.PodIPs {
 			queries = append(queries, &sdp.LinkedItemQuery{
@@ -324,7 +392,28 @@ func PodExtractor(resource *v1.Pod, scope string) ([]*sdp.LinkedItemQuery, error
 	return queries, nil
 }
 
-func newPodAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+// podSelectableFields The fields that the API server supports in field
+// selectors for pods
+func podSelectableFields(resource *v1.Pod) fields.Set {
+	var podIP string
+
+	if len(resource.Status.PodIPs) > 0 {
+		podIP = resource.Status.PodIPs[0].IP
+	}
+
+	return fields.Set{
+		"spec.nodeName":            resource.Spec.NodeName,
+		"spec.restartPolicy":       string(resource.Spec.RestartPolicy),
+		"spec.schedulerName":       resource.Spec.SchedulerName,
+		"spec.serviceAccountName":  resource.Spec.ServiceAccountName,
+		"spec.hostNetwork":         strconv.FormatBool(resource.Spec.HostNetwork),
+		"status.phase":             string(resource.Status.Phase),
+		"status.podIP":             podIP,
+		"status.nominatedNodeName": resource.Status.NominatedNodeName,
+	}
+}
+
+func newPodAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.Pod, *v1.PodList]{
 		ClusterName:      cluster,
 		Namespaces:       namespaces,
@@ -344,6 +433,10 @@ func newPodAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string
 			return extracted, nil
 		},
 		LinkedItemQueryExtractor: PodExtractor,
+		SearchMatcher:            podImageMatcher,
+		SelectableFields:         podSelectableFields,
+		// Nodes search for their pods across all namespaces
+		ClusterSearch: true,
 		HealthExtractor: func(resource *v1.Pod) *sdp.Health {
 			switch resource.Status.Phase {
 			case v1.PodPending:
@@ -370,6 +463,18 @@ func newPodAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string
 	}
 }
 
+// podImageMatcher Matches pods that are running an image, so that pods can be
+// searched for by image reference or digest
+func podImageMatcher(query string, resource *v1.Pod) bool {
+	ref, err := ParseImageReference(query)
+
+	if err != nil {
+		return false
+	}
+
+	return podRunsImage(resource, ref)
+}
+
 // a pod's status phase can be ok, but the container may not be ok
 // this is a check for the container statuses
 // hasWaitingContainerErrors returns true if any of the container statuses are in a waiting state with an error reason
@@ -391,15 +496,24 @@ var podAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
 	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
 	PotentialLinks: []string{
 		"ConfigMap",
+		"ContainerImage",
 		"ec2-volume",
 		"dns",
 		"ip",
+		"Node",
 		"PersistentVolumeClaim",
 		"PriorityClass",
 		"Secret",
 		"ServiceAccount",
 	},
-	SupportedQueryMethods: DefaultSupportedQueryMethods("Pod"),
+	SupportedQueryMethods: &sdp.AdapterSupportedQueryMethods{
+		Get:               true,
+		GetDescription:    "Get a Pod by name",
+		List:              true,
+		ListDescription:   "List all Pods",
+		Search:            true,
+		SearchDescription: `Search for a Pod using the ListOptions JSON format e.g. {"labelSelector": "app=wordpress"}, or find the pods running an image by searching for its reference or digest e.g. nginx:1.27 or sha256:...`,
+	},
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
 			TerraformMethod:   sdp.QueryMethod_GET,
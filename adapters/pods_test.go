@@ -175,6 +175,18 @@ func TestPodAdapter(t *testing.T) {
 				ExpectedQuery:  "pod-test-configmap-cert",
 				ExpectedScope:  sd.String(),
 			},
+			{
+				ExpectedType:   "ContainerImage",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "docker.io/library/nginx:latest",
+				ExpectedScope:  sd.String(),
+			},
+			{
+				ExpectedType:   "Node",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "local-tests-control-plane",
+				ExpectedScope:  sd.ClusterName,
+			},
 		},
 		Wait: func(item *sdp.Item) bool {
 			return len(item.GetLinkedItemQueries()) >= 9
@@ -292,3 +304,89 @@ func TestHasWaitingContainerErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestPodSpecExtractor(t *testing.T) {
+	scope := "test-cluster.default"
+
+	t.Run("with a nil spec", func(t *testing.T) {
+		queries, err := PodSpecExtractor(nil, scope)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(queries) != 0 {
+			t.Errorf("expected no queries, got %v", len(queries))
+		}
+	})
+
+	t.Run("with a full spec", func(t *testing.T) {
+		spec := &v1.PodSpec{
+			ServiceAccountName: "runner",
+			PriorityClassName:  "high",
+			Containers: []v1.Container{
+				{
+					Name: "main",
+					EnvFrom: []v1.EnvFromSource{
+						{
+							SecretRef: &v1.SecretEnvSource{
+								LocalObjectReference: v1.LocalObjectReference{Name: "creds"},
+							},
+						},
+					},
+				},
+			},
+			Volumes: []v1.Volume{
+				{
+					Name: "data",
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+					},
+				},
+			},
+		}
+
+		queries, err := PodSpecExtractor(spec, scope)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		item := &sdp.Item{LinkedItemQueries: queries}
+
+		QueryTests{
+			{
+				ExpectedType:   "ServiceAccount",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "runner",
+				ExpectedScope:  scope,
+			},
+			{
+				ExpectedType:   "PriorityClass",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "high",
+				ExpectedScope:  "test-cluster",
+			},
+			{
+				ExpectedType:   "Secret",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "creds",
+				ExpectedScope:  scope,
+			},
+			{
+				ExpectedType:   "PersistentVolumeClaim",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "data",
+				ExpectedScope:  scope,
+			},
+		}.Execute(t, item)
+	})
+
+	t.Run("with an invalid scope", func(t *testing.T) {
+		_, err := PodSpecExtractor(&v1.PodSpec{}, "test-cluster")
+
+		if err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+}
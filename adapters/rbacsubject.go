@@ -0,0 +1,479 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// This file contains the reverse of the links from bindings to their
+// subjects. Subjects don't reference the bindings that name them, so the
+// bindings have to be listed and indexed by subject. Users and groups aren't
+// stored in the cluster at all, so they are pseudo-types that only exist
+// because bindings name them
+
+// rbacSubject A subject of a RoleBinding or ClusterRoleBinding. The namespace
+// is only set for service accounts
+type rbacSubject struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// rbacBinding A RoleBinding or ClusterRoleBinding that names a subject
+type rbacBinding struct {
+	Kind      string
+	Namespace string
+	Name      string
+	RoleRef   rbacv1.RoleRef
+}
+
+// rbacSubjectIndex Maps subjects to the bindings that name them. The bindings
+// are listed through the RoleBinding and ClusterRoleBinding adapters when the
+// index is first used, and again once it is older than their cache duration
+type rbacSubjectIndex struct {
+	roleBindings        *KubeTypeAdapter[*rbacv1.RoleBinding, *rbacv1.RoleBindingList]
+	clusterRoleBindings *KubeTypeAdapter[*rbacv1.ClusterRoleBinding, *rbacv1.ClusterRoleBindingList]
+
+	mu       sync.Mutex // Protects the fields below, but isn't held while listing
+	builtAt  time.Time
+	bindings map[rbacSubject][]rbacBinding
+	// The error from the last build. This is returned until the index is
+	// stale, so that a build that fails isn't retried by every query
+	err error
+	// Closed when the build that is in progress finishes, nil if there isn't
+	// one
+	building chan struct{}
+}
+
+func newRBACSubjectIndex(roleBindings *KubeTypeAdapter[*rbacv1.RoleBinding, *rbacv1.RoleBindingList], clusterRoleBindings *KubeTypeAdapter[*rbacv1.ClusterRoleBinding, *rbacv1.ClusterRoleBindingList]) *rbacSubjectIndex {
+	return &rbacSubjectIndex{
+		roleBindings:        roleBindings,
+		clusterRoleBindings: clusterRoleBindings,
+	}
+}
+
+// rbacSubjectIndexUser An adapter that uses an index of bindings
+type rbacSubjectIndexUser interface {
+	subjectIndex() *rbacSubjectIndex
+}
+
+// linkedRBACSubjectIndex Returns the index for the loaded RoleBinding and
+// ClusterRoleBinding adapters. The adapters that need the bindings share it,
+// so they are only listed once, and it is created by whichever is linked
+// first. There is no index if either type isn't loaded
+func linkedRBACSubjectIndex(adapterList []discovery.Adapter) (*rbacSubjectIndex, bool) {
+	roleBindings, ok := linkedAdapter[*KubeTypeAdapter[*rbacv1.RoleBinding, *rbacv1.RoleBindingList]](adapterList, "RoleBinding")
+	if !ok {
+		return nil, false
+	}
+
+	clusterRoleBindings, ok := linkedAdapter[*KubeTypeAdapter[*rbacv1.ClusterRoleBinding, *rbacv1.ClusterRoleBindingList]](adapterList, "ClusterRoleBinding")
+	if !ok {
+		return nil, false
+	}
+
+	for _, adapter := range adapterList {
+		user, ok := adapter.(rbacSubjectIndexUser)
+		if !ok {
+			continue
+		}
+
+		if index := user.subjectIndex(); index != nil && index.roleBindings == roleBindings && index.clusterRoleBindings == clusterRoleBindings {
+			return index, true
+		}
+	}
+
+	return newRBACSubjectIndex(roleBindings, clusterRoleBindings), true
+}
+
+// Bindings Returns the bindings that name a subject, sorted by kind,
+// namespace and name
+func (i *rbacSubjectIndex) Bindings(ctx context.Context, subject rbacSubject) ([]rbacBinding, error) {
+	bindings, err := i.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return bindings[subject], nil
+}
+
+// Subjects Returns all of the subjects of a kind that are named by bindings,
+// sorted by namespace and name
+func (i *rbacSubjectIndex) Subjects(ctx context.Context, kind string) ([]rbacSubject, error) {
+	bindings, err := i.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subjects := make([]rbacSubject, 0)
+
+	for subject := range bindings {
+		if subject.Kind == kind {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	slices.SortFunc(subjects, func(a, b rbacSubject) int {
+		return strings.Compare(a.Namespace+"/"+a.Name, b.Namespace+"/"+b.Name)
+	})
+
+	return subjects, nil
+}
+
+// current Returns the bindings by subject, listing them if the index is
+// missing or stale. Only one build runs at a time, anything else that needs
+// the bindings waits for it. The map isn't changed once it is built, so it can
+// be read without the lock
+func (i *rbacSubjectIndex) current(ctx context.Context) (map[rbacSubject][]rbacBinding, error) {
+	for {
+		i.mu.Lock()
+
+		if !i.builtAt.IsZero() && time.Since(i.builtAt) < i.roleBindings.cacheDuration() {
+			bindings, err := i.bindings, i.err
+			i.mu.Unlock()
+
+			return bindings, err
+		}
+
+		if i.building == nil {
+			break
+		}
+
+		building := i.building
+		i.mu.Unlock()
+
+		select {
+		case <-building:
+			// Check again, if the build was cancelled this one will try
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	building := make(chan struct{})
+	i.building = building
+	i.mu.Unlock()
+
+	bindings, err := i.build(ctx)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// Failures are kept too, unless it was the query that gave up, since the
+	// next query could still build it
+	if err == nil || ctx.Err() == nil {
+		i.bindings = bindings
+		i.err = err
+		i.builtAt = time.Now()
+	}
+
+	i.building = nil
+	close(building)
+
+	return bindings, err
+}
+
+// build Lists the bindings and indexes them by subject. Role bindings are
+// only listed in the namespaces that are being discovered
+func (i *rbacSubjectIndex) build(ctx context.Context) (map[rbacSubject][]rbacBinding, error) {
+	bindings := make(map[rbacSubject][]rbacBinding)
+
+	for _, namespace := range i.roleBindings.currentNamespaces() {
+		roleBindings, err := i.roleBindings.listResources(ctx, ScopeDetails{
+			ClusterName: i.roleBindings.ClusterName,
+			Namespace:   namespace,
+		}.String())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rb := range roleBindings {
+			binding := rbacBinding{
+				Kind:      "RoleBinding",
+				Namespace: rb.Namespace,
+				Name:      rb.Name,
+				RoleRef:   rb.RoleRef,
+			}
+
+			for _, subject := range rb.Subjects {
+				key := rbacSubjectKey(subject)
+				bindings[key] = append(bindings[key], binding)
+			}
+		}
+	}
+
+	clusterRoleBindings, err := i.clusterRoleBindings.listResources(ctx, ScopeDetails{
+		ClusterName: i.clusterRoleBindings.ClusterName,
+	}.String())
+
+	switch {
+	case k8serr.IsForbidden(err):
+		// Sources that are limited to a set of namespaces can't see cluster
+		// role bindings, but the role bindings are still useful
+	case err != nil:
+		return nil, err
+	default:
+		for _, crb := range clusterRoleBindings {
+			binding := rbacBinding{
+				Kind:    "ClusterRoleBinding",
+				Name:    crb.Name,
+				RoleRef: crb.RoleRef,
+			}
+
+			for _, subject := range crb.Subjects {
+				key := rbacSubjectKey(subject)
+				bindings[key] = append(bindings[key], binding)
+			}
+		}
+	}
+
+	for key := range bindings {
+		slices.SortFunc(bindings[key], func(a, b rbacBinding) int {
+			return strings.Compare(a.Kind+"/"+a.Namespace+"/"+a.Name, b.Kind+"/"+b.Namespace+"/"+b.Name)
+		})
+	}
+
+	return bindings, nil
+}
+
+// rbacSubjectKey Returns the key for a subject in the index. Only service
+// accounts are namespaced, so the namespace is ignored for users and groups
+func rbacSubjectKey(subject rbacv1.Subject) rbacSubject {
+	key := rbacSubject{
+		Kind: subject.Kind,
+		Name: subject.Name,
+	}
+
+	if subject.Kind == rbacv1.ServiceAccountKind {
+		key.Namespace = subject.Namespace
+	}
+
+	return key
+}
+
+// rbacBindingQueries Links a subject to the bindings that name it
+func rbacBindingQueries(bindings []rbacBinding, clusterName string) []*sdp.LinkedItemQuery {
+	queries := make([]*sdp.LinkedItemQuery, 0, len(bindings))
+
+	for _, binding := range bindings {
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   binding.Kind,
+				Method: sdp.QueryMethod_GET,
+				Query:  binding.Name,
+				Scope: ScopeDetails{
+					ClusterName: clusterName,
+					Namespace:   binding.Namespace,
+				}.String(),
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// Changes to the binding, or the role it binds, change what
+				// the subject is allowed to do
+				In: true,
+				// Changes to the subject don't affect the binding
+				Out: false,
+			},
+		})
+	}
+
+	return queries
+}
+
+// rbacSubjectAdapter Serves users or groups. These only exist as the subjects
+// of bindings, so items are created for every user or group that a binding
+// names
+type rbacSubjectAdapter struct {
+	TypeName        string
+	ClusterName     string
+	AdapterMetadata *sdp.AdapterMetadata
+
+	index *rbacSubjectIndex
+}
+
+func (s *rbacSubjectAdapter) Type() string {
+	return s.TypeName
+}
+
+func (s *rbacSubjectAdapter) Name() string {
+	return fmt.Sprintf("k8s-%v", s.TypeName)
+}
+
+func (s *rbacSubjectAdapter) Metadata() *sdp.AdapterMetadata {
+	return s.AdapterMetadata
+}
+
+func (s *rbacSubjectAdapter) Weight() int {
+	return 10
+}
+
+func (s *rbacSubjectAdapter) Scopes() []string {
+	return []string{
+		ScopeDetails{
+			ClusterName: s.ClusterName,
+		}.String(),
+	}
+}
+
+// GroupResource Users and groups are found by listing bindings, so the access
+// that is needed is the same as for ClusterRoleBindings
+func (s *rbacSubjectAdapter) GroupResource() (schema.GroupResource, error) {
+	return rbacv1.Resource("clusterrolebindings"), nil
+}
+
+func (s *rbacSubjectAdapter) Get(ctx context.Context, scope string, query string, ignoreCache bool) (*sdp.Item, error) {
+	if err := s.checkScope(scope); err != nil {
+		return nil, err
+	}
+
+	subject := rbacSubject{
+		Kind: s.TypeName,
+		Name: query,
+	}
+
+	bindings, err := s.index.Bindings(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bindings) == 0 {
+		return nil, &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOTFOUND,
+			ErrorString: fmt.Sprintf("%v %v is not named by any bindings", s.TypeName, query),
+		}
+	}
+
+	return s.subjectToItem(subject, bindings)
+}
+
+func (s *rbacSubjectAdapter) List(ctx context.Context, scope string, ignoreCache bool) ([]*sdp.Item, error) {
+	if err := s.checkScope(scope); err != nil {
+		return nil, err
+	}
+
+	subjects, err := s.index.Subjects(ctx, s.TypeName)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*sdp.Item, 0, len(subjects))
+
+	for _, subject := range subjects {
+		bindings, err := s.index.Bindings(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+
+		item, err := s.subjectToItem(subject, bindings)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (s *rbacSubjectAdapter) checkScope(scope string) error {
+	if !slices.Contains(s.Scopes(), scope) {
+		return &sdp.QueryError{
+			ErrorType:   sdp.QueryError_NOSCOPE,
+			ErrorString: fmt.Sprintf("scope %v is not served by this adapter", scope),
+		}
+	}
+
+	return nil
+}
+
+func (s *rbacSubjectAdapter) subjectToItem(subject rbacSubject, bindings []rbacBinding) (*sdp.Item, error) {
+	attributes, err := sdp.ToAttributes(map[string]interface{}{
+		"name": subject.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sdp.Item{
+		Type:              s.TypeName,
+		UniqueAttribute:   "name",
+		Scope:             s.Scopes()[0],
+		Attributes:        attributes,
+		LinkedItemQueries: append(rbacBindingQueries(bindings, s.ClusterName), effectivePermissionsLink(subject, s.ClusterName)),
+	}, nil
+}
+
+// subjectIndex The index is shared with the other adapters that need the
+// bindings once they are linked
+func (s *rbacSubjectAdapter) subjectIndex() *rbacSubjectIndex {
+	return s.index
+}
+
+// linkAdapters Uses the index of the loaded RoleBinding and ClusterRoleBinding
+// adapters rather than the adapter's own. Users and groups only exist as the
+// subjects of bindings, so they can't be found without both
+func (s *rbacSubjectAdapter) linkAdapters(adapterList []discovery.Adapter) bool {
+	index, ok := linkedRBACSubjectIndex(adapterList)
+	if !ok {
+		return false
+	}
+
+	s.index = index
+
+	return true
+}
+
+func newRBACSubjectAdapterLoader(typeName string, metadata *sdp.AdapterMetadata) AdapterLoader {
+	return func(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+		return &rbacSubjectAdapter{
+			TypeName:        typeName,
+			ClusterName:     cluster,
+			AdapterMetadata: metadata,
+			index: newRBACSubjectIndex(
+				newRoleBindingAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*rbacv1.RoleBinding, *rbacv1.RoleBindingList]),
+				newClusterRoleBindingAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*rbacv1.ClusterRoleBinding, *rbacv1.ClusterRoleBindingList]),
+			),
+		}
+	}
+}
+
+var userAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:            rbacv1.UserKind,
+	DescriptiveName: "User",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks:  []string{"RoleBinding", "ClusterRoleBinding", "EffectivePermissions"},
+	SupportedQueryMethods: &sdp.AdapterSupportedQueryMethods{
+		Get:             true,
+		GetDescription:  "Get a User by name. Users aren't stored in the cluster, so only users that are named by a RoleBinding or ClusterRoleBinding can be found",
+		List:            true,
+		ListDescription: "List all Users that are named by a RoleBinding or ClusterRoleBinding",
+	},
+})
+
+var groupAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:            rbacv1.GroupKind,
+	DescriptiveName: "Group",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks:  []string{"RoleBinding", "ClusterRoleBinding", "EffectivePermissions"},
+	SupportedQueryMethods: &sdp.AdapterSupportedQueryMethods{
+		Get:             true,
+		GetDescription:  "Get a Group by name e.g. system:masters. Groups aren't stored in the cluster, so only groups that are named by a RoleBinding or ClusterRoleBinding can be found",
+		List:            true,
+		ListDescription: "List all Groups that are named by a RoleBinding or ClusterRoleBinding",
+	},
+})
+
+func init() {
+	registerAdapterLoader(newRBACSubjectAdapterLoader(rbacv1.UserKind, userAdapterMetadata))
+	registerAdapterLoader(newRBACSubjectAdapterLoader(rbacv1.GroupKind, groupAdapterMetadata))
+}
@@ -0,0 +1,314 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newRBACSubjectClient() *fake.Clientset {
+	return fake.NewClientset(
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-reader", Namespace: "team-a"},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "app", Namespace: "team-a"},
+				{Kind: rbacv1.UserKind, Name: "alice"},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "cross-namespace", Namespace: "team-b"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "app", Namespace: "team-a"},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.GroupKind, Name: "platform"},
+				{Kind: rbacv1.UserKind, Name: "alice"},
+			},
+		},
+	)
+}
+
+func newTestRBACSubjectIndex(cs *fake.Clientset, namespaces []string) *rbacSubjectIndex {
+	return newRBACSubjectIndex(
+		newRoleBindingAdapter(cs, "test-cluster", namespaces).(*KubeTypeAdapter[*rbacv1.RoleBinding, *rbacv1.RoleBindingList]),
+		newClusterRoleBindingAdapter(cs, "test-cluster", namespaces).(*KubeTypeAdapter[*rbacv1.ClusterRoleBinding, *rbacv1.ClusterRoleBindingList]),
+	)
+}
+
+func TestRBACSubjectIndex(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("across all namespaces", func(t *testing.T) {
+		index := newTestRBACSubjectIndex(newRBACSubjectClient(), []string{"team-a", "team-b"})
+
+		bindings, err := index.Bindings(ctx, rbacSubject{Kind: rbacv1.ServiceAccountKind, Namespace: "team-a", Name: "app"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bindings) != 2 {
+			t.Fatalf("expected 2 bindings, got %v", bindings)
+		}
+
+		if bindings[0].Name != "app-reader" || bindings[1].Name != "cross-namespace" || bindings[1].Namespace != "team-b" {
+			t.Errorf("unexpected bindings %v", bindings)
+		}
+
+		bindings, err = index.Bindings(ctx, rbacSubject{Kind: rbacv1.UserKind, Name: "alice"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bindings) != 2 || bindings[0].Kind != "ClusterRoleBinding" {
+			t.Errorf("expected alice to have a ClusterRoleBinding and a RoleBinding, got %v", bindings)
+		}
+
+		subjects, err := index.Subjects(ctx, rbacv1.GroupKind)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(subjects) != 1 || subjects[0].Name != "platform" {
+			t.Errorf("expected only the platform group, got %v", subjects)
+		}
+	})
+
+	t.Run("limited to some namespaces", func(t *testing.T) {
+		index := newTestRBACSubjectIndex(newRBACSubjectClient(), []string{"team-a"})
+
+		bindings, err := index.Bindings(ctx, rbacSubject{Kind: rbacv1.ServiceAccountKind, Namespace: "team-a", Name: "app"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bindings) != 1 || bindings[0].Name != "app-reader" {
+			t.Errorf("expected only the binding in team-a, got %v", bindings)
+		}
+	})
+
+	t.Run("cluster role bindings are forbidden", func(t *testing.T) {
+		cs := newRBACSubjectClient()
+
+		cs.PrependReactor("list", "clusterrolebindings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, k8serr.NewForbidden(action.GetResource().GroupResource(), "", errors.New("not allowed"))
+		})
+
+		index := newTestRBACSubjectIndex(cs, []string{"team-a"})
+
+		bindings, err := index.Bindings(ctx, rbacSubject{Kind: rbacv1.UserKind, Name: "alice"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bindings) != 1 || bindings[0].Kind != "RoleBinding" {
+			t.Errorf("expected only alice's RoleBinding, got %v", bindings)
+		}
+	})
+
+	t.Run("failures are cached", func(t *testing.T) {
+		cs := newRBACSubjectClient()
+
+		cs.PrependReactor("list", "rolebindings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("connection refused")
+		})
+
+		index := newTestRBACSubjectIndex(cs, []string{"team-a"})
+
+		for range 3 {
+			if _, err := index.Subjects(ctx, rbacv1.UserKind); err == nil {
+				t.Error("expected error, got none")
+			}
+		}
+
+		var lists int
+
+		for _, action := range cs.Actions() {
+			if action.Matches("list", "rolebindings") {
+				lists++
+			}
+		}
+
+		if lists != 1 {
+			t.Errorf("expected role bindings to be listed once, got %v", lists)
+		}
+	})
+
+	t.Run("cancelled builds aren't cached", func(t *testing.T) {
+		cs := newRBACSubjectClient()
+		cancelled, cancel := context.WithCancel(ctx)
+
+		// The query gives up while the bindings are being listed
+		cs.PrependReactor("list", "rolebindings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if cancelled.Err() != nil {
+				return false, nil, nil
+			}
+
+			cancel()
+
+			return true, nil, context.Canceled
+		})
+
+		index := newTestRBACSubjectIndex(cs, []string{"team-a"})
+
+		if _, err := index.Subjects(cancelled, rbacv1.UserKind); err == nil {
+			t.Fatal("expected error, got none")
+		}
+
+		bindings, err := index.Bindings(ctx, rbacSubject{Kind: rbacv1.UserKind, Name: "alice"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bindings) != 2 {
+			t.Errorf("expected alice to have 2 bindings, got %v", bindings)
+		}
+	})
+}
+
+func TestLinkedRBACSubjectIndex(t *testing.T) {
+	cs := newRBACSubjectClient()
+	namespaces := []string{"team-a", "team-b"}
+
+	t.Run("shared by the adapters that need bindings", func(t *testing.T) {
+		adapterList := LoadAllAdapters(cs, "test-cluster", namespaces, TypeFilter{})
+
+		roleBindings, _ := linkedAdapter[*KubeTypeAdapter[*rbacv1.RoleBinding, *rbacv1.RoleBindingList]](adapterList, "RoleBinding")
+
+		var index *rbacSubjectIndex
+
+		for _, typ := range []string{"ServiceAccount", "User", "Group", "EffectivePermissions"} {
+			adapter, _ := linkedAdapter[discovery.Adapter](adapterList, typ)
+			user, ok := adapter.(rbacSubjectIndexUser)
+
+			if !ok {
+				t.Fatalf("expected %v to be loaded with an index", typ)
+			}
+
+			if index == nil {
+				index = user.subjectIndex()
+			}
+
+			if user.subjectIndex() != index {
+				t.Errorf("expected %v to share the index", typ)
+			}
+		}
+
+		if index.roleBindings != roleBindings {
+			t.Error("expected the index to use the loaded RoleBinding adapter")
+		}
+	})
+
+	t.Run("separate for each set of adapters", func(t *testing.T) {
+		first, _ := linkedAdapter[*rbacSubjectAdapter](LoadAllAdapters(cs, "test-cluster", namespaces, TypeFilter{}), "User")
+		second, _ := linkedAdapter[*rbacSubjectAdapter](LoadAllAdapters(cs, "test-cluster", namespaces, TypeFilter{}), "User")
+
+		if first.subjectIndex() == second.subjectIndex() {
+			t.Error("expected a different index for each set of adapters")
+		}
+	})
+
+	t.Run("without the binding types", func(t *testing.T) {
+		adapterList := LoadAllAdapters(cs, "test-cluster", namespaces, TypeFilter{Deny: []string{"ClusterRoleBinding"}})
+
+		for _, typ := range []string{"User", "Group", "EffectivePermissions"} {
+			if _, ok := linkedAdapter[discovery.Adapter](adapterList, typ); ok {
+				t.Errorf("expected %v to be removed when ClusterRoleBinding is filtered out", typ)
+			}
+		}
+
+		serviceAccounts, ok := linkedAdapter[*serviceAccountAdapter](adapterList, "ServiceAccount")
+
+		if !ok {
+			t.Fatal("expected ServiceAccount to be loaded")
+		}
+
+		if serviceAccounts.index != nil {
+			t.Error("expected ServiceAccount not to use an index when ClusterRoleBinding is filtered out")
+		}
+	})
+}
+
+func TestRBACSubjectAdapter(t *testing.T) {
+	ctx := context.Background()
+	adapter := newRBACSubjectAdapterLoader(rbacv1.UserKind, userAdapterMetadata)(newRBACSubjectClient(), "test-cluster", []string{"team-a", "team-b"})
+
+	t.Run("Get", func(t *testing.T) {
+		item, err := adapter.Get(ctx, "test-cluster", "alice", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "ClusterRoleBinding",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "admins",
+				ExpectedScope:  "test-cluster",
+			},
+			{
+				ExpectedType:   "RoleBinding",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "app-reader",
+				ExpectedScope:  "test-cluster.team-a",
+			},
+			{
+				ExpectedType:   "EffectivePermissions",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "User/alice",
+				ExpectedScope:  "test-cluster",
+			},
+		}.Execute(t, item)
+	})
+
+	t.Run("Get a user that isn't bound", func(t *testing.T) {
+		_, err := adapter.Get(ctx, "test-cluster", "bob", false)
+
+		var qErr *sdp.QueryError
+
+		if !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOTFOUND {
+			t.Errorf("expected NOTFOUND error, got %v", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		items, err := adapter.List(ctx, "test-cluster", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 || items[0].UniqueAttributeValue() != "alice" {
+			t.Errorf("expected only alice, got %v", items)
+		}
+	})
+
+	t.Run("Bad scope", func(t *testing.T) {
+		_, err := adapter.List(ctx, "test-cluster.default", false)
+
+		if err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+}
@@ -28,10 +28,19 @@ func replicaSetExtractor(resource *v1.ReplicaSet, scope string) ([]*sdp.LinkedIt
 		})
 	}
 
+	// Link to everything that the pods will use, even if none are running
+	podQueries, err := PodSpecExtractor(&resource.Spec.Template.Spec, scope)
+
+	if err != nil {
+		return nil, err
+	}
+
+	queries = append(queries, podQueries...)
+
 	return queries, nil
 }
 
-func newReplicaSetAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func newReplicaSetAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.ReplicaSet, *v1.ReplicaSetList]{
 		ClusterName:      cluster,
 		Namespaces:       namespaces,
@@ -55,10 +64,21 @@ func newReplicaSetAdapter(cs *kubernetes.Clientset, cluster string, namespaces [
 }
 
 var replicaSetAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "ReplicaSet",
-	DescriptiveName:       "Replica Set",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
-	PotentialLinks:        []string{"Pod"},
+	Type:            "ReplicaSet",
+	DescriptiveName: "Replica Set",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
+	PotentialLinks: []string{
+		"Pod",
+		"ConfigMap",
+		"ContainerImage",
+		"dns",
+		"ec2-volume",
+		"ip",
+		"PersistentVolumeClaim",
+		"PriorityClass",
+		"Secret",
+		"ServiceAccount",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("ReplicaSet"),
 })
 
@@ -30,10 +30,21 @@ func replicationControllerExtractor(resource *v1.ReplicationController, scope st
 		})
 	}
 
+	// Link to everything that the pods will use, even if none are running
+	if resource.Spec.Template != nil {
+		podQueries, err := PodSpecExtractor(&resource.Spec.Template.Spec, scope)
+
+		if err != nil {
+			return nil, err
+		}
+
+		queries = append(queries, podQueries...)
+	}
+
 	return queries, nil
 }
 
-func newReplicationControllerAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func newReplicationControllerAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.ReplicationController, *v1.ReplicationControllerList]{
 		ClusterName:      cluster,
 		Namespaces:       namespaces,
@@ -57,10 +68,21 @@ func newReplicationControllerAdapter(cs *kubernetes.Clientset, cluster string, n
 }
 
 var replicationControllerAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "ReplicationController",
-	DescriptiveName:       "Replication Controller",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
-	PotentialLinks:        []string{"Pod"},
+	Type:            "ReplicationController",
+	DescriptiveName: "Replication Controller",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
+	PotentialLinks: []string{
+		"Pod",
+		"ConfigMap",
+		"ContainerImage",
+		"dns",
+		"ec2-volume",
+		"ip",
+		"PersistentVolumeClaim",
+		"PriorityClass",
+		"Secret",
+		"ServiceAccount",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("ReplicationController"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
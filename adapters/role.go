@@ -1,14 +1,140 @@
 package adapters
 
 import (
+	"strings"
+	"sync"
+
 	"github.com/overmindtech/discovery"
 	"github.com/overmindtech/sdp-go"
 	v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
 )
 
-func newRoleAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+// policyRuleResourceKinds Maps API resources to the kind of object that they
+// hold e.g. `secrets` to `Secret`. This is worked out from the types that
+// client-go knows about, since the kind is also the type of the item
+var policyRuleResourceKinds = sync.OnceValue(func() map[schema.GroupResource]string {
+	kinds := make(map[schema.GroupResource]string)
+
+	for gvk := range scheme.Scheme.AllKnownTypes() {
+		if strings.HasSuffix(gvk.Kind, "List") {
+			continue
+		}
+
+		plural, _ := meta.UnsafeGuessKindToResource(gvk)
+		kinds[plural.GroupResource()] = gvk.Kind
+
+		// Kinds that are already plural, such as Endpoints, have a resource
+		// with the same name
+		kinds[schema.GroupResource{Group: gvk.Group, Resource: strings.ToLower(gvk.Kind)}] = gvk.Kind
+	}
+
+	return kinds
+})
+
+// clusterScopedResources The resources that client-go knows about that aren't
+// namespaced
+var clusterScopedResources = map[schema.GroupResource]bool{
+	{Resource: "namespaces"}:        true,
+	{Resource: "nodes"}:             true,
+	{Resource: "persistentvolumes"}: true,
+	{Group: "rbac.authorization.k8s.io", Resource: "clusterroles"}:                         true,
+	{Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"}:                  true,
+	{Group: "storage.k8s.io", Resource: "storageclasses"}:                                  true,
+	{Group: "storage.k8s.io", Resource: "csidrivers"}:                                      true,
+	{Group: "storage.k8s.io", Resource: "csinodes"}:                                        true,
+	{Group: "storage.k8s.io", Resource: "volumeattachments"}:                               true,
+	{Group: "scheduling.k8s.io", Resource: "priorityclasses"}:                              true,
+	{Group: "networking.k8s.io", Resource: "ingressclasses"}:                               true,
+	{Group: "node.k8s.io", Resource: "runtimeclasses"}:                                     true,
+	{Group: "certificates.k8s.io", Resource: "certificatesigningrequests"}:                 true,
+	{Group: "admissionregistration.k8s.io", Resource: "mutatingwebhookconfigurations"}:     true,
+	{Group: "admissionregistration.k8s.io", Resource: "validatingwebhookconfigurations"}:   true,
+	{Group: "admissionregistration.k8s.io", Resource: "validatingadmissionpolicies"}:       true,
+	{Group: "admissionregistration.k8s.io", Resource: "validatingadmissionpolicybindings"}: true,
+	{Group: "flowcontrol.apiserver.k8s.io", Resource: "flowschemas"}:                       true,
+	{Group: "flowcontrol.apiserver.k8s.io", Resource: "prioritylevelconfigurations"}:       true,
+}
+
+// policyRuleQueries Links rules to the objects that they name in
+// `resourceNames`, since changing the role changes who can access them.
+// Namespaced objects are looked for in `namespacedScope`, and cluster scoped
+// ones in the cluster. Wildcard groups and resources can't be resolved to a
+// type so are skipped
+func policyRuleQueries(rules []v1.PolicyRule, clusterName string, namespacedScope string) []*sdp.LinkedItemQuery {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+	seen := make(map[string]bool)
+
+	for _, rule := range rules {
+		if len(rule.ResourceNames) == 0 {
+			continue
+		}
+
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				// Subresources such as `pods/exec` name the parent object
+				resource, _, _ = strings.Cut(resource, "/")
+
+				gr := schema.GroupResource{Group: group, Resource: resource}
+				kind, ok := policyRuleResourceKinds()[gr]
+
+				if !ok {
+					continue
+				}
+
+				scope := namespacedScope
+
+				if clusterScopedResources[gr] {
+					scope = ScopeDetails{ClusterName: clusterName}.String()
+				}
+
+				for _, name := range rule.ResourceNames {
+					key := kind + "/" + scope + "/" + name
+
+					if seen[key] {
+						continue
+					}
+
+					seen[key] = true
+
+					queries = append(queries, &sdp.LinkedItemQuery{
+						Query: &sdp.Query{
+							Type:   kind,
+							Method: sdp.QueryMethod_GET,
+							Query:  name,
+							Scope:  scope,
+						},
+						BlastPropagation: &sdp.BlastPropagation{
+							// Changes to the object don't affect the role
+							In: false,
+							// Changes to the role change who can access the
+							// object
+							Out: true,
+						},
+					})
+				}
+			}
+		}
+	}
+
+	return queries
+}
+
+func roleExtractor(resource *v1.Role, scope string) ([]*sdp.LinkedItemQuery, error) {
+	sd, err := ParseScope(scope, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return policyRuleQueries(resource.Rules, sd.ClusterName, sd.String()), nil
+}
+
+func newRoleAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.Role, *v1.RoleList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -25,7 +151,8 @@ func newRoleAdapter(cs *kubernetes.Clientset, cluster string, namespaces []strin
 
 			return extracted, nil
 		},
-		AdapterMetadata: roleAdapterMetadata,
+		LinkedItemQueryExtractor: roleExtractor,
+		AdapterMetadata:          roleAdapterMetadata,
 	}
 }
 
@@ -33,6 +160,7 @@ var roleAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
 	Type:                  "Role",
 	DescriptiveName:       "Role",
 	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks:        []string{"Secret", "ConfigMap", "ServiceAccount", "Pod"},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Role"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
@@ -2,6 +2,9 @@ package adapters
 
 import (
 	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/rbac/v1"
 )
 
 var RoleYAML = `
@@ -29,6 +32,14 @@ rules:
       - create
       - update
       - delete
+  - apiGroups:
+      - ""
+    resources:
+      - secrets
+    resourceNames:
+      - db-creds
+    verbs:
+      - get
 `
 
 func TestRoleAdapter(t *testing.T) {
@@ -44,7 +55,100 @@ func TestRoleAdapter(t *testing.T) {
 		GetQuery:  "role-test-role",
 		GetScope:  sd.String(),
 		SetupYAML: RoleYAML,
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "Secret",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "db-creds",
+				ExpectedScope:  sd.String(),
+			},
+		},
 	}
 
 	st.Execute(t)
 }
+
+func TestPolicyRuleQueries(t *testing.T) {
+	rules := []v1.PolicyRule{
+		{
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets", "configmaps"},
+			ResourceNames: []string{"db-creds"},
+			Verbs:         []string{"get"},
+		},
+		{
+			// Subresources link to the parent object
+			APIGroups:     []string{""},
+			Resources:     []string{"pods/exec", "pods/log"},
+			ResourceNames: []string{"debug"},
+			Verbs:         []string{"create", "get"},
+		},
+		{
+			// Cluster scoped objects are always in the cluster scope
+			APIGroups:     []string{""},
+			Resources:     []string{"nodes"},
+			ResourceNames: []string{"node-1"},
+			Verbs:         []string{"get"},
+		},
+		{
+			APIGroups:     []string{"apps"},
+			Resources:     []string{"deployments"},
+			ResourceNames: []string{"web"},
+			Verbs:         []string{"patch"},
+		},
+		{
+			// Wildcards can't be resolved to a type
+			APIGroups:     []string{"*"},
+			Resources:     []string{"*"},
+			ResourceNames: []string{"anything"},
+			Verbs:         []string{"get"},
+		},
+		{
+			// Rules without names apply to every object, so aren't linked
+			APIGroups: []string{""},
+			Resources: []string{"services"},
+			Verbs:     []string{"get"},
+		},
+	}
+
+	queries := policyRuleQueries(rules, "test-cluster", "test-cluster.team-a")
+
+	if len(queries) != 5 {
+		t.Errorf("expected 5 queries, got %v", len(queries))
+	}
+
+	item := &sdp.Item{LinkedItemQueries: queries}
+
+	QueryTests{
+		{
+			ExpectedType:   "Secret",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "db-creds",
+			ExpectedScope:  "test-cluster.team-a",
+		},
+		{
+			ExpectedType:   "ConfigMap",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "db-creds",
+			ExpectedScope:  "test-cluster.team-a",
+		},
+		{
+			ExpectedType:   "Pod",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "debug",
+			ExpectedScope:  "test-cluster.team-a",
+		},
+		{
+			ExpectedType:   "Node",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "node-1",
+			ExpectedScope:  "test-cluster",
+		},
+		{
+			ExpectedType:   "Deployment",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "web",
+			ExpectedScope:  "test-cluster.team-a",
+		},
+	}.Execute(t, item)
+}
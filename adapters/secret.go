@@ -2,6 +2,8 @@ package adapters
 
 import (
 	"crypto/sha512"
+	"maps"
+	"slices"
 
 	"github.com/overmindtech/discovery"
 	"github.com/overmindtech/sdp-go"
@@ -9,7 +11,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
-func newSecretAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func newSecretAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.Secret, *v1.SecretList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -32,10 +34,11 @@ func newSecretAdapter(cs *kubernetes.Clientset, cluster string, namespaces []str
 			// the data in the secret and return the hash
 			hash := sha512.New()
 
-			for k, v := range resource.Data {
+			// Sort the keys so that the hash is the same every time
+			for _, k := range slices.Sorted(maps.Keys(resource.Data)) {
 				// Write the data into the hash
 				hash.Write([]byte(k))
-				hash.Write(v)
+				hash.Write(resource.Data[k])
 			}
 
 			resource.Data = map[string][]byte{
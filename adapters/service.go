@@ -91,6 +91,8 @@ func serviceExtractor(resource *v1.Service, scope string) ([]*sdp.LinkedItemQuer
 		},
 	})
 
+	hostnames := make([]string, 0)
+
 	for _, ingress := range resource.Status.LoadBalancer.Ingress {
 		if ingress.IP != "" {
 			queries = append(queries, &sdp.LinkedItemQuery{
@@ -109,6 +111,8 @@ func serviceExtractor(resource *v1.Service, scope string) ([]*sdp.LinkedItemQuer
 		}
 
 		if ingress.Hostname != "" {
+			hostnames = append(hostnames, ingress.Hostname)
+
 			queries = append(queries, &sdp.LinkedItemQuery{
 				Query: &sdp.Query{
 					Type:   "dns",
@@ -125,10 +129,28 @@ func serviceExtractor(resource *v1.Service, scope string) ([]*sdp.LinkedItemQuer
 		}
 	}
 
+	if resource.Spec.Type == v1.ServiceTypeLoadBalancer {
+		queries = append(queries, serviceLoadBalancerQueries(resource.Annotations, hostnames)...)
+	}
+
 	return queries, nil
 }
 
-func newServiceAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+// serviceHealthExtractor Only LoadBalancer services have a status, they are
+// pending until the cloud provider has created the load balancer
+func serviceHealthExtractor(resource *v1.Service) *sdp.Health {
+	if resource.Spec.Type != v1.ServiceTypeLoadBalancer {
+		return nil
+	}
+
+	if len(resource.Status.LoadBalancer.Ingress) == 0 {
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	return sdp.Health_HEALTH_OK.Enum()
+}
+
+func newServiceAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.Service, *v1.ServiceList]{
 		ClusterName: cluster,
 		Namespaces:  namespaces,
@@ -146,15 +168,32 @@ func newServiceAdapter(cs *kubernetes.Clientset, cluster string, namespaces []st
 			return extracted, nil
 		},
 		LinkedItemQueryExtractor: serviceExtractor,
+		HealthExtractor:          serviceHealthExtractor,
 		AdapterMetadata:          serviceAdapterMetadata,
 	}
 }
 
 var serviceAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "Service",
-	DescriptiveName:       "Service",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_NETWORK,
-	PotentialLinks:        []string{"Pod", "ip", "dns", "Endpoint"},
+	Type:            "Service",
+	DescriptiveName: "Service",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_NETWORK,
+	PotentialLinks: []string{
+		"Pod",
+		"ip",
+		"dns",
+		"Endpoint",
+		"elb-load-balancer",
+		"elbv2-load-balancer",
+		"acm-certificate",
+		"ec2-security-group",
+		"ec2-subnet",
+		"gcp-compute-forwarding-rule",
+		"gcp-compute-backend-service",
+		"gcp-compute-health-check",
+		"gcp-compute-firewall",
+		"azure-network-load-balancer",
+		"azure-network-public-ip-address",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Service"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
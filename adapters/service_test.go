@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
 )
 
 var serviceYAML = `
@@ -81,3 +82,35 @@ func TestServiceAdapter(t *testing.T) {
 
 	st.Execute(t)
 }
+
+func TestServiceHealthExtractor(t *testing.T) {
+	service := func(serviceType v1.ServiceType, ingress ...v1.LoadBalancerIngress) *v1.Service {
+		return &v1.Service{
+			Spec: v1.ServiceSpec{
+				Type: serviceType,
+			},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{
+					Ingress: ingress,
+				},
+			},
+		}
+	}
+
+	HealthTests[*v1.Service]{
+		{
+			Name:           "load balancer ready",
+			Resource:       service(v1.ServiceTypeLoadBalancer, v1.LoadBalancerIngress{Hostname: "my-lb-1234567890.eu-west-2.elb.amazonaws.com"}),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "load balancer pending",
+			Resource:       service(v1.ServiceTypeLoadBalancer),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name:     "cluster IP",
+			Resource: service(v1.ServiceTypeClusterIP),
+		},
+	}.Execute(t, serviceHealthExtractor)
+}
@@ -1,9 +1,12 @@
 package adapters
 
 import (
+	"context"
+
 	"github.com/overmindtech/discovery"
 	"github.com/overmindtech/sdp-go"
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -46,36 +49,221 @@ func serviceAccountExtractor(resource *v1.ServiceAccount, scope string) ([]*sdp.
 		})
 	}
 
+	queries = append(queries, workloadIdentityQueries(resource.Annotations)...)
+
 	return queries, nil
 }
 
-func newServiceAccountAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
-	return &KubeTypeAdapter[*v1.ServiceAccount, *v1.ServiceAccountList]{
-		ClusterName: cluster,
-		Namespaces:  namespaces,
-		TypeName:    "ServiceAccount",
-		NamespacedInterfaceBuilder: func(namespace string) ItemInterface[*v1.ServiceAccount, *v1.ServiceAccountList] {
-			return cs.CoreV1().ServiceAccounts(namespace)
-		},
-		ListExtractor: func(list *v1.ServiceAccountList) ([]*v1.ServiceAccount, error) {
-			extracted := make([]*v1.ServiceAccount, len(list.Items))
+// Annotations that are used by cloud providers to bind a service account to a
+// cloud identity, pods using the service account can then act as that
+// identity
+const (
+	// IAM roles for service accounts (IRSA) on EKS e.g.
+	// arn:aws:iam::123456789012:role/my-role
+	awsRoleARNAnnotation = "eks.amazonaws.com/role-arn"
+	// GKE Workload Identity e.g. my-sa@my-project.iam.gserviceaccount.com
+	gcpServiceAccountAnnotation = "iam.gke.io/gcp-service-account"
+	// Azure Workload Identity, this is the client ID of a managed identity
+	azureClientIDAnnotation = "azure.workload.identity/client-id"
+)
 
-			for i := range list.Items {
-				extracted[i] = &list.Items[i]
-			}
+// workloadIdentityQueries Links a service account to the cloud identity that
+// it has been bound to
+func workloadIdentityQueries(annotations map[string]string) []*sdp.LinkedItemQuery {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	// Changes to the permissions of the cloud identity will affect everything
+	// that uses the service account, but the service account can't change the
+	// cloud identity
+	blastPropagation := &sdp.BlastPropagation{
+		In:  true,
+		Out: false,
+	}
+
+	if arn := annotations[awsRoleARNAnnotation]; arn != "" {
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "iam-role",
+				Method: sdp.QueryMethod_SEARCH,
+				Query:  arn,
+				Scope:  "*",
+			},
+			BlastPropagation: blastPropagation,
+		})
+	}
 
-			return extracted, nil
+	if email := annotations[gcpServiceAccountAnnotation]; email != "" {
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "gcp-iam-service-account",
+				Method: sdp.QueryMethod_GET,
+				Query:  email,
+				Scope:  "*",
+			},
+			BlastPropagation: blastPropagation,
+		})
+	}
+
+	if clientID := annotations[azureClientIDAnnotation]; clientID != "" {
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "azure-managed-identity",
+				Method: sdp.QueryMethod_SEARCH,
+				Query:  clientID,
+				Scope:  "*",
+			},
+			BlastPropagation: blastPropagation,
+		})
+	}
+
+	return queries
+}
+
+// serviceAccountAdapter Serves service accounts, with links back to the
+// bindings that grant them access so that changes to roles propagate to the
+// pods that use them. The links are added once the items have been found, so
+// that the bindings are looked up with the query's context rather than while
+// the items are being converted
+type serviceAccountAdapter struct {
+	*KubeTypeAdapter[*v1.ServiceAccount, *v1.ServiceAccountList]
+
+	// nil if the binding types aren't loaded, in which case there are no
+	// links to bindings
+	index *rbacSubjectIndex
+}
+
+func (s *serviceAccountAdapter) Get(ctx context.Context, scope string, query string, ignoreCache bool) (*sdp.Item, error) {
+	item, err := s.KubeTypeAdapter.Get(ctx, scope, query, ignoreCache)
+	if err != nil {
+		return nil, err
+	}
+
+	s.addBindingQueries(ctx, item)
+
+	return item, nil
+}
+
+func (s *serviceAccountAdapter) List(ctx context.Context, scope string, ignoreCache bool) ([]*sdp.Item, error) {
+	items, err := s.KubeTypeAdapter.List(ctx, scope, ignoreCache)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		s.addBindingQueries(ctx, item)
+	}
+
+	return items, nil
+}
+
+func (s *serviceAccountAdapter) Search(ctx context.Context, scope string, query string, ignoreCache bool) ([]*sdp.Item, error) {
+	items, err := s.KubeTypeAdapter.Search(ctx, scope, query, ignoreCache)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		s.addBindingQueries(ctx, item)
+	}
+
+	return items, nil
+}
+
+// addBindingQueries Links a service account to the bindings that name it. The
+// bindings are only needed for the links, so if they can't be listed the
+// service account is still returned without them
+func (s *serviceAccountAdapter) addBindingQueries(ctx context.Context, item *sdp.Item) {
+	if s.index == nil {
+		return
+	}
+
+	sd, err := ParseScope(item.GetScope(), true)
+	if err != nil {
+		return
+	}
+
+	bindings, err := s.index.Bindings(ctx, rbacSubject{
+		Kind:      rbacv1.ServiceAccountKind,
+		Namespace: sd.Namespace,
+		Name:      item.UniqueAttributeValue(),
+	})
+	if err != nil {
+		return
+	}
+
+	item.LinkedItemQueries = append(item.LinkedItemQueries, rbacBindingQueries(bindings, sd.ClusterName)...)
+}
+
+func (s *serviceAccountAdapter) subjectIndex() *rbacSubjectIndex {
+	return s.index
+}
+
+// linkAdapters Uses the index of the loaded RoleBinding and ClusterRoleBinding
+// adapters rather than the adapter's own. Service accounts are still served
+// without them, just without the links to bindings
+func (s *serviceAccountAdapter) linkAdapters(adapterList []discovery.Adapter) bool {
+	s.index, _ = linkedRBACSubjectIndex(adapterList)
+
+	return true
+}
+
+func newServiceAccountAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &serviceAccountAdapter{
+		KubeTypeAdapter: &KubeTypeAdapter[*v1.ServiceAccount, *v1.ServiceAccountList]{
+			ClusterName: cluster,
+			Namespaces:  namespaces,
+			TypeName:    "ServiceAccount",
+			NamespacedInterfaceBuilder: func(namespace string) ItemInterface[*v1.ServiceAccount, *v1.ServiceAccountList] {
+				return cs.CoreV1().ServiceAccounts(namespace)
+			},
+			ListExtractor: func(list *v1.ServiceAccountList) ([]*v1.ServiceAccount, error) {
+				extracted := make([]*v1.ServiceAccount, len(list.Items))
+
+				for i := range list.Items {
+					extracted[i] = &list.Items[i]
+				}
+
+				return extracted, nil
+			},
+			LinkedItemQueryExtractor: func(resource *v1.ServiceAccount, scope string) ([]*sdp.LinkedItemQuery, error) {
+				queries, err := serviceAccountExtractor(resource, scope)
+				if err != nil {
+					return nil, err
+				}
+
+				sd, err := ParseScope(scope, true)
+				if err != nil {
+					return nil, err
+				}
+
+				return append(queries, effectivePermissionsLink(rbacSubject{
+					Kind:      rbacv1.ServiceAccountKind,
+					Namespace: resource.Namespace,
+					Name:      resource.Name,
+				}, sd.ClusterName)), nil
+			},
+			AdapterMetadata: serviceAccountAdapterMetadata,
 		},
-		LinkedItemQueryExtractor: serviceAccountExtractor,
-		AdapterMetadata:          serviceAccountAdapterMetadata,
+		index: newRBACSubjectIndex(
+			newRoleBindingAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*rbacv1.RoleBinding, *rbacv1.RoleBindingList]),
+			newClusterRoleBindingAdapter(cs, cluster, namespaces).(*KubeTypeAdapter[*rbacv1.ClusterRoleBinding, *rbacv1.ClusterRoleBindingList]),
+		),
 	}
 }
 
 var serviceAccountAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "ServiceAccount",
-	DescriptiveName:       "Service Account",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
-	PotentialLinks:        []string{"Secret"},
+	Type:            "ServiceAccount",
+	DescriptiveName: "Service Account",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks: []string{
+		"Secret",
+		"RoleBinding",
+		"ClusterRoleBinding",
+		"EffectivePermissions",
+		"iam-role",
+		"gcp-iam-service-account",
+		"azure-managed-identity",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("ServiceAccount"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
@@ -1,9 +1,17 @@
 package adapters
 
 import (
+	"context"
+	"errors"
+	"slices"
 	"testing"
 
 	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 var serviceAccountYAML = `
@@ -32,6 +40,19 @@ secrets:
 - name: service-account-secret
 imagePullSecrets:
 - name: service-account-secret-pull
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: service-account-binding
+subjects:
+- kind: ServiceAccount
+  name: test-service-account
+  namespace: default
+roleRef:
+  kind: ClusterRole
+  name: view
+  apiGroup: rbac.authorization.k8s.io
 `
 
 func TestServiceAccountAdapter(t *testing.T) {
@@ -60,8 +81,186 @@ func TestServiceAccountAdapter(t *testing.T) {
 				ExpectedQuery:  "service-account-secret-pull",
 				ExpectedScope:  sd.String(),
 			},
+			{
+				ExpectedType:   "RoleBinding",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "service-account-binding",
+				ExpectedScope:  sd.String(),
+			},
 		},
 	}
 
 	st.Execute(t)
 }
+
+func TestServiceAccountBindingsError(t *testing.T) {
+	cs := fake.NewClientset(&v1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Secrets:    []v1.ObjectReference{{Name: "app-token"}},
+	})
+
+	cs.PrependReactor("list", "rolebindings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+
+	adapter := newServiceAccountAdapter(cs, "test-cluster", []string{"default"})
+
+	item, err := adapter.Get(context.Background(), "test-cluster.default", "app", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The service account is still returned, without the links to bindings
+	QueryTests{
+		{
+			ExpectedType:   "Secret",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "app-token",
+			ExpectedScope:  "test-cluster.default",
+		},
+		{
+			ExpectedType:   "EffectivePermissions",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "ServiceAccount/default/app",
+			ExpectedScope:  "test-cluster",
+		},
+	}.Execute(t, item)
+}
+
+func TestServiceAccountBindingQueries(t *testing.T) {
+	cs := newRBACSubjectClient()
+
+	for _, name := range []string{"app", "worker"} {
+		if err := cs.Tracker().Add(&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	adapter, ok := linkedAdapter[*serviceAccountAdapter](LoadAllAdapters(cs, "test-cluster", []string{"team-a", "team-b"}, TypeFilter{}), "ServiceAccount")
+
+	if !ok {
+		t.Fatal("expected ServiceAccount to be loaded")
+	}
+
+	items, err := adapter.List(context.Background(), "test-cluster.team-a", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 2 {
+		t.Fatalf("expected 2 service accounts, got %v", len(items))
+	}
+
+	app := items[slices.IndexFunc(items, func(item *sdp.Item) bool {
+		return item.UniqueAttributeValue() == "app"
+	})]
+
+	QueryTests{
+		{
+			ExpectedType:   "RoleBinding",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "app-reader",
+			ExpectedScope:  "test-cluster.team-a",
+		},
+		{
+			ExpectedType:   "RoleBinding",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "cross-namespace",
+			ExpectedScope:  "test-cluster.team-b",
+		},
+	}.Execute(t, app)
+
+	var lists int
+
+	for _, action := range cs.Actions() {
+		if action.Matches("list", "rolebindings") {
+			lists++
+		}
+	}
+
+	// Once for each namespace, however many service accounts there are
+	if lists != 2 {
+		t.Errorf("expected role bindings to be listed twice, got %v", lists)
+	}
+}
+
+func TestWorkloadIdentityQueries(t *testing.T) {
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		expectedType  string
+		expectedQuery string
+		method        sdp.QueryMethod
+	}{
+		{
+			name: "AWS",
+			annotations: map[string]string{
+				"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/my-role",
+			},
+			expectedType:  "iam-role",
+			expectedQuery: "arn:aws:iam::123456789012:role/my-role",
+			method:        sdp.QueryMethod_SEARCH,
+		},
+		{
+			name: "GCP",
+			annotations: map[string]string{
+				"iam.gke.io/gcp-service-account": "my-sa@my-project.iam.gserviceaccount.com",
+			},
+			expectedType:  "gcp-iam-service-account",
+			expectedQuery: "my-sa@my-project.iam.gserviceaccount.com",
+			method:        sdp.QueryMethod_GET,
+		},
+		{
+			name: "Azure",
+			annotations: map[string]string{
+				"azure.workload.identity/client-id": "00000000-0000-0000-0000-000000000000",
+				"azure.workload.identity/tenant-id": "11111111-1111-1111-1111-111111111111",
+			},
+			expectedType:  "azure-managed-identity",
+			expectedQuery: "00000000-0000-0000-0000-000000000000",
+			method:        sdp.QueryMethod_SEARCH,
+		},
+		{
+			name: "empty annotation",
+			annotations: map[string]string{
+				"eks.amazonaws.com/role-arn": "",
+			},
+		},
+		{
+			name: "no annotations",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := workloadIdentityQueries(tt.annotations)
+
+			if tt.expectedType == "" {
+				if len(queries) != 0 {
+					t.Errorf("expected no queries, got %v", queries)
+				}
+
+				return
+			}
+
+			if len(queries) != 1 {
+				t.Fatalf("expected 1 query, got %v", len(queries))
+			}
+
+			if queries[0].GetBlastPropagation().GetOut() {
+				t.Error("expected blast propagation not to go out to the cloud identity")
+			}
+
+			QueryTests{
+				{
+					ExpectedType:   tt.expectedType,
+					ExpectedMethod: tt.method,
+					ExpectedQuery:  tt.expectedQuery,
+					ExpectedScope:  "*",
+				},
+			}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+		})
+	}
+}
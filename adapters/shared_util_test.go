@@ -1,6 +1,10 @@
 package adapters
 
-import "testing"
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 func TestParseScope(t *testing.T) {
 	type ParseTest struct {
@@ -125,3 +129,32 @@ func TestParseScope(t *testing.T) {
 	}
 
 }
+
+func TestLabelSelectorToQuery(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Selector *metav1.LabelSelector
+		Expected string
+	}{
+		{
+			Name:     "empty",
+			Selector: &metav1.LabelSelector{},
+			Expected: `{}`,
+		},
+		{
+			Name: "match labels",
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "web"},
+			},
+			Expected: `{"labelSelector":"app=web"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if query := LabelSelectorToQuery(test.Selector); query != test.Expected {
+				t.Errorf("expected %v, got %v", test.Expected, query)
+			}
+		})
+	}
+}
@@ -0,0 +1,530 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/overmindtech/discovery"
+	corev1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Snapshot The recorded state of one or more clusters. This can be used to
+// serve queries without access to the clusters themselves, for example when
+// analysing an air-gapped cluster or reproducing a bug
+type Snapshot struct {
+	// When the snapshot was taken
+	CreatedAt time.Time          `json:"createdAt"`
+	Clusters  []*ClusterSnapshot `json:"clusters"`
+}
+
+// ClusterSnapshot The raw objects from a single cluster. Objects are stored
+// rather than items so that the adapters can be run against them, meaning that
+// links and health are worked out by the version of the source that is serving
+// the snapshot rather than the one that recorded it
+type ClusterSnapshot struct {
+	// The name of the cluster, this is used to generate scopes
+	Name    string                       `json:"name"`
+	Objects []*unstructured.Unstructured `json:"objects"`
+
+	seen map[string]bool // Keys of the objects that have been added
+}
+
+// SnapshotAdapter An adapter that can list the raw objects that it serves, so
+// that they can be recorded in a snapshot
+type SnapshotAdapter interface {
+	SnapshotObjects(ctx context.Context, scope string) ([]*unstructured.Unstructured, error)
+}
+
+// ReadSnapshotFile Reads a snapshot that was written by `WriteFile`
+func ReadSnapshotFile(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return ReadSnapshot(f)
+}
+
+// ReadSnapshot Reads a snapshot that was written by `Write`
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+
+	err := json.NewDecoder(r).Decode(&snapshot)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// WriteFile Writes the snapshot to a file, replacing it if it exists
+func (s *Snapshot) WriteFile(path string) error {
+	f, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	err = s.Write(f)
+
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Write Writes the snapshot as JSON
+func (s *Snapshot) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(s)
+}
+
+// AddObjects Adds objects to the snapshot. Objects that are already in the
+// snapshot are ignored, since the same object can be returned by more than one
+// adapter
+func (c *ClusterSnapshot) AddObjects(objects ...*unstructured.Unstructured) {
+	if c.seen == nil {
+		c.seen = make(map[string]bool)
+
+		for _, obj := range c.Objects {
+			c.seen[snapshotKey(obj)] = true
+		}
+	}
+
+	for _, obj := range objects {
+		key := snapshotKey(obj)
+
+		if c.seen[key] {
+			continue
+		}
+
+		c.seen[key] = true
+		c.Objects = append(c.Objects, obj)
+	}
+}
+
+func snapshotKey(obj *unstructured.Unstructured) string {
+	return fmt.Sprintf("%v/%v/%v", obj.GroupVersionKind().String(), obj.GetNamespace(), obj.GetName())
+}
+
+var customResourceDefinitionGK = schema.GroupKind{
+	Group: CustomResourceDefinitionGVR.Group,
+	Kind:  "CustomResourceDefinition",
+}
+
+// snapshotServer An adapter that can serve the objects of its kind from a
+// snapshot rather than querying the API
+type snapshotServer interface {
+	snapshotKind() (schema.GroupVersionKind, error)
+	serveSnapshot(gvk schema.GroupVersionKind, objects []*unstructured.Unstructured) error
+}
+
+// LoadAdapters Creates the adapters for the cluster in the same way as
+// `LoadAllAdapters`, but serves them from the objects in the snapshot. The
+// adapters are created without a clientset since they never query the API
+func (c *ClusterSnapshot) LoadAdapters(namespaces []string, types TypeFilter) ([]discovery.Adapter, error) {
+	adapterList := LoadAllAdapters(nil, c.Name, namespaces, types)
+
+	for _, adapter := range adapterList {
+		server, ok := adapter.(snapshotServer)
+
+		if !ok {
+			// Adapters that are built on other types are served by the
+			// adapters for those types
+			continue
+		}
+
+		err := c.serve(server)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not serve %v from snapshot: %w", adapter.Type(), err)
+		}
+	}
+
+	return adapterList, nil
+}
+
+// CustomResourceManager Creates a manager for the custom resources in the
+// snapshot. This works in the same way as for a real cluster, except that the
+// CRDs and custom resources are read from the snapshot
+func (c *ClusterSnapshot) CustomResourceManager(reservedTypes []string) *CustomResourceManager {
+	manager := NewCustomResourceManager(nil, nil, c.Name, reservedTypes)
+	manager.snapshot = c
+
+	return manager
+}
+
+// Namespaces Returns the namespaces that were recorded in the snapshot
+func (c *ClusterSnapshot) Namespaces() (*corev1.NamespaceList, error) {
+	list := &corev1.NamespaceList{}
+	gvk := corev1.SchemeGroupVersion.WithKind("Namespace")
+
+	for _, obj := range c.Objects {
+		if obj.GroupVersionKind() != gvk {
+			continue
+		}
+
+		var namespace corev1.Namespace
+
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &namespace)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not convert Namespace %v: %w", obj.GetName(), err)
+		}
+
+		list.Items = append(list.Items, namespace)
+	}
+
+	return list, nil
+}
+
+// customResourceDefinitions Returns the CRDs that were recorded in the
+// snapshot
+func (c *ClusterSnapshot) customResourceDefinitions() []unstructured.Unstructured {
+	crds := make([]unstructured.Unstructured, 0)
+
+	for _, obj := range c.Objects {
+		if obj.GroupVersionKind().GroupKind() == customResourceDefinitionGK {
+			crds = append(crds, *obj.DeepCopy())
+		}
+	}
+
+	return crds
+}
+
+// serve Gives an adapter the objects of its kind from the snapshot
+func (c *ClusterSnapshot) serve(adapter snapshotServer) error {
+	gvk, err := adapter.snapshotKind()
+
+	if err != nil {
+		return err
+	}
+
+	objects := make([]*unstructured.Unstructured, 0)
+
+	for _, obj := range c.Objects {
+		if obj.GroupVersionKind() == gvk {
+			objects = append(objects, obj)
+		}
+	}
+
+	return adapter.serveSnapshot(gvk, objects)
+}
+
+// snapshotAPIResource Works out how the resource for a CRD would be served.
+// There is no discovery API for a snapshot, so this comes from the CRD itself
+func snapshotAPIResource(crd *unstructured.Unstructured, gvr schema.GroupVersionResource) metav1.APIResource {
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+
+	return metav1.APIResource{
+		Name:       gvr.Resource,
+		Kind:       kind,
+		Namespaced: scope == "Namespaced",
+		Verbs:      metav1.Verbs{"get", "list"},
+	}
+}
+
+// snapshotKind Returns the kind of the objects in a snapshot that the adapter
+// serves
+func (s *KubeTypeAdapter[Resource, ResourceList]) snapshotKind() (schema.GroupVersionKind, error) {
+	return s.groupVersionKind()
+}
+
+// snapshotKind Returns the kind of the objects in a snapshot that the adapter
+// serves. This is the version of the CRD that the adapter queries, which is
+// also the version that was recorded
+func (s *customResourceAdapter) snapshotKind() (schema.GroupVersionKind, error) {
+	return s.Resource.GVR.GroupVersion().WithKind(s.Resource.Kind), nil
+}
+
+// serveSnapshot Serves the adapter from a store holding the given objects
+// rather than from the API. This must be called before the adapter is used
+func (s *KubeTypeAdapter[Resource, ResourceList]) serveSnapshot(gvk schema.GroupVersionKind, objects []*unstructured.Unstructured) error {
+	store := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+	})
+
+	for _, obj := range objects {
+		resource, err := s.snapshotResource(obj)
+
+		if err != nil {
+			return err
+		}
+
+		err = store.Add(resource)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	plural, _ := meta.UnsafeGuessKindToResource(gvk)
+
+	if s.namespaced() {
+		s.NamespacedInterfaceBuilder = func(namespace string) ItemInterface[Resource, ResourceList] {
+			return &storeItemInterface[Resource, ResourceList]{
+				adapter:   s,
+				store:     store,
+				resource:  plural.GroupResource(),
+				namespace: namespace,
+			}
+		}
+	} else {
+		s.ClusterInterfaceBuilder = func() ItemInterface[Resource, ResourceList] {
+			return &storeItemInterface[Resource, ResourceList]{
+				adapter:  s,
+				store:    store,
+				resource: plural.GroupResource(),
+			}
+		}
+	}
+
+	return nil
+}
+
+// snapshotResource Converts an object from a snapshot into a resource. Custom
+// resources are served as they are, while built-in types are converted to
+// their typed objects
+func (s *KubeTypeAdapter[Resource, ResourceList]) snapshotResource(obj *unstructured.Unstructured) (Resource, error) {
+	if resource, ok := any(obj.DeepCopy()).(Resource); ok {
+		return resource, nil
+	}
+
+	var resource Resource
+
+	t := reflect.TypeOf(resource)
+
+	if t == nil || t.Kind() != reflect.Pointer {
+		return resource, fmt.Errorf("%v is not a pointer", t)
+	}
+
+	resource = reflect.New(t.Elem()).Interface().(Resource)
+
+	object, ok := any(resource).(runtime.Object)
+
+	if !ok {
+		return resource, fmt.Errorf("%v is not a runtime.Object", t)
+	}
+
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, object)
+
+	if err != nil {
+		return resource, fmt.Errorf("could not convert %v %v: %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	// Typed objects from the API don't have their kind set, so it's cleared
+	// to give the same items as the live cluster
+	object.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+
+	return resource, nil
+}
+
+// storeItemInterface An `ItemInterface` that serves resources from a store
+// rather than from the API. Selectors are evaluated in the same way as they
+// are for informers, so field selectors can only use the fields that the API
+// server supports for the type
+type storeItemInterface[Resource metav1.Object, ResourceList any] struct {
+	adapter   *KubeTypeAdapter[Resource, ResourceList]
+	store     cache.Indexer
+	resource  schema.GroupResource // Used in errors, so that they match the API's
+	namespace string               // Blank for all namespaces, or for cluster-scoped resources
+}
+
+func (i *storeItemInterface[Resource, ResourceList]) Get(ctx context.Context, name string, opts metav1.GetOptions) (Resource, error) {
+	var resource Resource
+
+	key := name
+
+	if i.namespace != "" {
+		key = i.namespace + "/" + name
+	}
+
+	obj, exists, err := i.store.GetByKey(key)
+
+	if err != nil {
+		return resource, err
+	}
+
+	if !exists {
+		return resource, k8serr.NewNotFound(i.resource, name)
+	}
+
+	return i.adapter.storedResource(obj)
+}
+
+func (i *storeItemInterface[Resource, ResourceList]) List(ctx context.Context, opts metav1.ListOptions) (ResourceList, error) {
+	var list ResourceList
+
+	selector, err := labels.Parse(opts.LabelSelector)
+
+	if err != nil {
+		return list, err
+	}
+
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+
+	if err != nil {
+		return list, err
+	}
+
+	err = i.adapter.checkFieldSelector(fieldSelector)
+
+	if err != nil {
+		return list, err
+	}
+
+	objects := i.store.List()
+
+	if i.namespace != "" {
+		objects, err = i.store.ByIndex(cache.NamespaceIndex, i.namespace)
+
+		if err != nil {
+			return list, err
+		}
+	}
+
+	resources := make([]Resource, 0, len(objects))
+
+	for _, obj := range objects {
+		resource, err := i.adapter.storedResource(obj)
+
+		if err != nil {
+			return list, err
+		}
+
+		if selector.Matches(labels.Set(resource.GetLabels())) && fieldSelector.Matches(i.adapter.selectableFields(resource)) {
+			resources = append(resources, resource)
+		}
+	}
+
+	// The store is unordered, sort in the same order as the API
+	slices.SortFunc(resources, func(a, b Resource) int {
+		if n := strings.Compare(a.GetNamespace(), b.GetNamespace()); n != 0 {
+			return n
+		}
+
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+
+	matching := make([]runtime.Object, len(resources))
+
+	for j, resource := range resources {
+		matching[j] = any(resource).(runtime.Object)
+	}
+
+	t := reflect.TypeOf(list)
+
+	if t == nil || t.Kind() != reflect.Pointer {
+		return list, fmt.Errorf("%v is not a pointer", t)
+	}
+
+	list = reflect.New(t.Elem()).Interface().(ResourceList)
+
+	listObject, ok := any(list).(runtime.Object)
+
+	if !ok {
+		return list, fmt.Errorf("%v is not a runtime.Object", t)
+	}
+
+	return list, meta.SetList(listObject, matching)
+}
+
+// SnapshotObjects Lists the raw objects in a scope so that they can be
+// recorded in a snapshot. Sensitive data is redacted in the same way as it is
+// for items
+func (s *KubeTypeAdapter[Resource, ResourceList]) SnapshotObjects(ctx context.Context, scope string) ([]*unstructured.Unstructured, error) {
+	// The objects are snapshotted in their namespaces' scopes
+	if s.isClusterSearchScope(scope) {
+		return nil, nil
+	}
+
+	i, err := s.itemInterface(scope)
+
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := i.List(ctx, metav1.ListOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.ListExtractor(list)
+
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]*unstructured.Unstructured, 0, len(resources))
+
+	for _, resource := range resources {
+		if s.Redact != nil {
+			resource = s.Redact(resource)
+		}
+
+		obj, ok := any(resource).(runtime.Object)
+
+		if !ok {
+			return nil, fmt.Errorf("%T is not a runtime.Object", resource)
+		}
+
+		u, err := SnapshotObject(obj)
+
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, u)
+	}
+
+	return objects, nil
+}
+
+// SnapshotObject Converts an object to the format that is stored in a
+// snapshot. Objects from typed lists don't have their kind set, but it's
+// needed to be able to read them back, so it's looked up from the scheme
+func SnapshotObject(obj runtime.Object) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+
+	if err != nil {
+		return nil, err
+	}
+
+	u := &unstructured.Unstructured{Object: content}
+
+	if u.GetKind() == "" {
+		gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+
+		if err != nil {
+			return nil, err
+		}
+
+		u.SetGroupVersionKind(gvks[0])
+	}
+
+	return u, nil
+}
@@ -0,0 +1,263 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	// Record a snapshot from a fake cluster using the adapters
+	live := fake.NewClientset(
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app": "web",
+				},
+			},
+			Spec: v1.PodSpec{
+				NodeName:           "node-1",
+				ServiceAccountName: "web",
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "db",
+				Namespace: "default",
+				Labels: map[string]string{
+					"app": "db",
+				},
+			},
+			Spec: v1.PodSpec{
+				NodeName: "node-2",
+			},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "password",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"password": []byte("hunter2"),
+			},
+		},
+	)
+
+	cluster := &ClusterSnapshot{Name: "test"}
+
+	for _, adapter := range []SnapshotAdapter{
+		newPodAdapter(live, "test", []string{"default"}).(SnapshotAdapter),
+		newSecretAdapter(live, "test", []string{"default"}).(SnapshotAdapter),
+	} {
+		objects, err := adapter.SnapshotObjects(ctx, "test.default")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cluster.AddObjects(objects...)
+	}
+
+	crd := newTestCRD("widgets.example.com", "example.com", "widgets", "Widget")
+	widget := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata": map[string]interface{}{
+				"name":      "my-widget",
+				"namespace": "default",
+			},
+		},
+	}
+
+	// Adding the same object twice should only store it once
+	cluster.AddObjects(crd, widget, widget)
+
+	if len(cluster.Objects) != 5 {
+		t.Fatalf("expected 5 objects, got %v", len(cluster.Objects))
+	}
+
+	for _, obj := range cluster.Objects {
+		if obj.GetKind() == "" {
+			t.Errorf("expected kind to be set on %v", obj.GetName())
+		}
+
+		if obj.GetKind() == "Secret" {
+			data, _, _ := unstructured.NestedMap(obj.Object, "data")
+
+			if _, found := data["password"]; found {
+				t.Error("expected secret data to be redacted")
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+
+	err := (&Snapshot{
+		CreatedAt: time.Now(),
+		Clusters:  []*ClusterSnapshot{cluster},
+	}).Write(&buf)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := ReadSnapshot(&buf)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(snapshot.Clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %v", len(snapshot.Clusters))
+	}
+
+	adapterList, err := snapshot.Clusters[0].LoadAdapters([]string{"default"}, TypeFilter{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pods, ok := linkedAdapter[*KubeTypeAdapter[*v1.Pod, *v1.PodList]](adapterList, "Pod")
+
+	if !ok {
+		t.Fatal("expected a Pod adapter")
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		item, err := pods.Get(ctx, "test.default", "web", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := item.GetAttributes().Get("kind"); err == nil {
+			t.Error("expected kind not to be set, the same as for items from the API")
+		}
+
+		QueryTests{
+			{
+				ExpectedType:   "ServiceAccount",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "web",
+				ExpectedScope:  "test.default",
+			},
+		}.Execute(t, item)
+	})
+
+	t.Run("Get missing", func(t *testing.T) {
+		_, err := pods.Get(ctx, "test.default", "api", false)
+
+		var qErr *sdp.QueryError
+
+		if !errors.As(err, &qErr) || qErr.GetErrorType() != sdp.QueryError_NOTFOUND {
+			t.Errorf("expected a NOTFOUND error, got %v", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		items, err := pods.List(ctx, "test.default", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 2 || items[0].UniqueAttributeValue() != "db" || items[1].UniqueAttributeValue() != "web" {
+			t.Errorf("expected db and web, got %v", items)
+		}
+	})
+
+	t.Run("Search by label", func(t *testing.T) {
+		items, err := pods.Search(ctx, "test.default", `{"labelSelector":"app=db"}`, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 || items[0].UniqueAttributeValue() != "db" {
+			t.Errorf("expected to find db, got %v", items)
+		}
+	})
+
+	t.Run("Search by field", func(t *testing.T) {
+		items, err := pods.Search(ctx, "test.default", `{"fieldSelector":"spec.nodeName=node-1"}`, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 || items[0].UniqueAttributeValue() != "web" {
+			t.Errorf("expected to find web, got %v", items)
+		}
+	})
+
+	t.Run("Search by field that isn't set", func(t *testing.T) {
+		// The API server supports this field for pods even though neither
+		// pod sets it
+		items, err := pods.Search(ctx, "test.default", `{"fieldSelector":"spec.hostNetwork=false"}`, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 2 {
+			t.Errorf("expected to find both pods, got %v", items)
+		}
+	})
+
+	t.Run("Search by unsupported field", func(t *testing.T) {
+		_, err := pods.Search(ctx, "test.default", `{"fieldSelector":"metadata.labels.app=web"}`, false)
+
+		if err == nil {
+			t.Error("expected an error for a field the API server doesn't support")
+		}
+	})
+
+	t.Run("Search in the cluster scope", func(t *testing.T) {
+		items, err := pods.Search(ctx, "test", `{"fieldSelector":"spec.nodeName=node-2"}`, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 1 || items[0].UniqueAttributeValue() != "db" {
+			t.Errorf("expected to find db, got %v", items)
+		}
+	})
+
+	t.Run("Custom resources", func(t *testing.T) {
+		manager := snapshot.Clusters[0].CustomResourceManager(nil)
+
+		created, _, err := manager.Sync(ctx, []string{"default"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(created) != 1 {
+			t.Fatalf("expected 1 adapter, got %v", len(created))
+		}
+
+		item, err := created[0].(*customResourceAdapter).Get(ctx, "test.default", "my-widget", false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.GetType() != "Widget" {
+			t.Errorf("expected type Widget, got %v", item.GetType())
+		}
+	})
+}
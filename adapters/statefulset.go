@@ -63,10 +63,43 @@ func statefulSetExtractor(resource *v1.StatefulSet, scope string) ([]*sdp.Linked
 		})
 	}
 
+	// Link to everything that the pods will use, even if none are running
+	podQueries, err := PodSpecExtractor(&resource.Spec.Template.Spec, scope)
+
+	if err != nil {
+		return nil, err
+	}
+
+	queries = append(queries, podQueries...)
+
 	return queries, nil
 }
 
-func newStatefulSetAdapter(cs *kubernetes.Clientset, cluster string, namespaces []string) discovery.ListableAdapter {
+func statefulSetHealthExtractor(resource *v1.StatefulSet) *sdp.Health {
+	desired := int32(1)
+
+	if resource.Spec.Replicas != nil {
+		desired = *resource.Spec.Replicas
+	}
+
+	// The controller hasn't caught up with the latest spec
+	if resource.Status.ObservedGeneration < resource.Generation {
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	// Or is still rolling out a new revision. With the OnDelete strategy pods
+	// are only updated when they are deleted by hand, so the revisions can
+	// differ indefinitely and that isn't a rollout in progress
+	rollingUpdate := resource.Spec.UpdateStrategy.Type != v1.OnDeleteStatefulSetStrategyType
+
+	if rollingUpdate && resource.Status.UpdateRevision != resource.Status.CurrentRevision {
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	return replicaHealth(desired, resource.Status.ReadyReplicas)
+}
+
+func newStatefulSetAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
 	return &KubeTypeAdapter[*v1.StatefulSet, *v1.StatefulSetList]{
 		ClusterName:      cluster,
 		Namespaces:       namespaces,
@@ -85,14 +118,28 @@ func newStatefulSetAdapter(cs *kubernetes.Clientset, cluster string, namespaces
 			return extracted, nil
 		},
 		LinkedItemQueryExtractor: statefulSetExtractor,
+		HealthExtractor:          statefulSetHealthExtractor,
 		AdapterMetadata:          statefulSetAdapterMetadata,
 	}
 }
 
 var statefulSetAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
-	Type:                  "StatefulSet",
-	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
-	DescriptiveName:       "Stateful Set",
+	Type:            "StatefulSet",
+	Category:        sdp.AdapterCategory_ADAPTER_CATEGORY_COMPUTE_APPLICATION,
+	DescriptiveName: "Stateful Set",
+	PotentialLinks: []string{
+		"Pod",
+		"Service",
+		"ConfigMap",
+		"ContainerImage",
+		"dns",
+		"ec2-volume",
+		"ip",
+		"PersistentVolumeClaim",
+		"PriorityClass",
+		"Secret",
+		"ServiceAccount",
+	},
 	SupportedQueryMethods: DefaultSupportedQueryMethods("Stateful Set"),
 	TerraformMappings: []*sdp.TerraformMapping{
 		{
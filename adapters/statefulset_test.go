@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var statefulSetYAML = `
@@ -73,3 +75,60 @@ func TestStatefulSetAdapter(t *testing.T) {
 
 	st.Execute(t)
 }
+
+func TestStatefulSetHealthExtractor(t *testing.T) {
+	statefulSet := func(replicas int32, ready int32, currentRevision string) *v1.StatefulSet {
+		return &v1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Generation: 2,
+			},
+			Spec: v1.StatefulSetSpec{
+				Replicas: &replicas,
+			},
+			Status: v1.StatefulSetStatus{
+				ObservedGeneration: 2,
+				ReadyReplicas:      ready,
+				CurrentRevision:    currentRevision,
+				UpdateRevision:     "rev-2",
+			},
+		}
+	}
+
+	HealthTests[*v1.StatefulSet]{
+		{
+			Name:           "all ready",
+			Resource:       statefulSet(3, 3, "rev-2"),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "some ready",
+			Resource:       statefulSet(3, 1, "rev-2"),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+		{
+			Name:           "none ready",
+			Resource:       statefulSet(3, 0, "rev-2"),
+			ExpectedHealth: sdp.Health_HEALTH_ERROR.Enum(),
+		},
+		{
+			Name:           "scaled to zero",
+			Resource:       statefulSet(0, 0, "rev-2"),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "rolling out",
+			Resource:       statefulSet(3, 3, "rev-1"),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name: "revisions differ with OnDelete",
+			Resource: func() *v1.StatefulSet {
+				s := statefulSet(3, 3, "rev-1")
+				s.Spec.UpdateStrategy.Type = v1.OnDeleteStatefulSetStrategyType
+
+				return s
+			}(),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+	}.Execute(t, statefulSetHealthExtractor)
+}
@@ -0,0 +1,81 @@
+package adapters
+
+import (
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func validatingAdmissionPolicyExtractor(resource *v1.ValidatingAdmissionPolicy, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	sd, err := ParseScope(scope, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The policy does nothing until it is bound, and the binding can narrow
+	// down what it matches further, but it can never match more than this
+	if match := resource.Spec.MatchConstraints; match != nil {
+		queries = append(queries, namespaceSelectorQuery(match.NamespaceSelector, sd.ClusterName, &sdp.BlastPropagation{
+			// Namespaces don't affect the policy
+			In: false,
+			// The policy can reject requests in the namespaces
+			Out: true,
+		}))
+	}
+
+	return queries, nil
+}
+
+// validatingAdmissionPolicyHealthExtractor The API server type checks the CEL
+// expressions in a policy, if any of them have problems they may not be
+// enforced as expected
+func validatingAdmissionPolicyHealthExtractor(resource *v1.ValidatingAdmissionPolicy) *sdp.Health {
+	if resource.Status.ObservedGeneration < resource.Generation {
+		return sdp.Health_HEALTH_PENDING.Enum()
+	}
+
+	if resource.Status.TypeChecking != nil && len(resource.Status.TypeChecking.ExpressionWarnings) > 0 {
+		return sdp.Health_HEALTH_WARNING.Enum()
+	}
+
+	return sdp.Health_HEALTH_OK.Enum()
+}
+
+func newValidatingAdmissionPolicyAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &KubeTypeAdapter[*v1.ValidatingAdmissionPolicy, *v1.ValidatingAdmissionPolicyList]{
+		ClusterName: cluster,
+		Namespaces:  namespaces,
+		TypeName:    "ValidatingAdmissionPolicy",
+		ClusterInterfaceBuilder: func() ItemInterface[*v1.ValidatingAdmissionPolicy, *v1.ValidatingAdmissionPolicyList] {
+			return cs.AdmissionregistrationV1().ValidatingAdmissionPolicies()
+		},
+		ListExtractor: func(list *v1.ValidatingAdmissionPolicyList) ([]*v1.ValidatingAdmissionPolicy, error) {
+			extracted := make([]*v1.ValidatingAdmissionPolicy, len(list.Items))
+
+			for i := range list.Items {
+				extracted[i] = &list.Items[i]
+			}
+
+			return extracted, nil
+		},
+		LinkedItemQueryExtractor: validatingAdmissionPolicyExtractor,
+		HealthExtractor:          validatingAdmissionPolicyHealthExtractor,
+		AdapterMetadata:          validatingAdmissionPolicyAdapterMetadata,
+	}
+}
+
+var validatingAdmissionPolicyAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:                  "ValidatingAdmissionPolicy",
+	DescriptiveName:       "Validating Admission Policy",
+	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks:        []string{"Namespace"},
+	SupportedQueryMethods: DefaultSupportedQueryMethods("Validating Admission Policy"),
+})
+
+func init() {
+	registerAdapterLoader(newValidatingAdmissionPolicyAdapter)
+}
@@ -0,0 +1,88 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var validatingAdmissionPolicyYAML = `
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicy
+metadata:
+  name: replica-limit-test
+spec:
+  failurePolicy: Fail
+  matchConstraints:
+    namespaceSelector:
+      matchLabels:
+        environment: test
+    resourceRules:
+    - apiGroups: ["apps"]
+      apiVersions: ["v1"]
+      operations: ["CREATE", "UPDATE"]
+      resources: ["deployments"]
+  validations:
+  - expression: "object.spec.replicas <= 5"
+`
+
+func TestValidatingAdmissionPolicyAdapter(t *testing.T) {
+	sd := ScopeDetails{
+		ClusterName: CurrentCluster.Name,
+	}
+
+	adapter := newValidatingAdmissionPolicyAdapter(CurrentCluster.ClientSet, sd.ClusterName, []string{"default"})
+
+	st := AdapterTests{
+		Adapter:   adapter,
+		GetQuery:  "replica-limit-test",
+		GetScope:  sd.String(),
+		SetupYAML: validatingAdmissionPolicyYAML,
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "Namespace",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  `{"labelSelector":"environment=test"}`,
+				ExpectedScope:  sd.String(),
+			},
+		},
+	}
+
+	st.Execute(t)
+}
+
+func TestValidatingAdmissionPolicyHealthExtractor(t *testing.T) {
+	policy := func(generation int64, observedGeneration int64, warnings ...v1.ExpressionWarning) *v1.ValidatingAdmissionPolicy {
+		return &v1.ValidatingAdmissionPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Generation: generation,
+			},
+			Status: v1.ValidatingAdmissionPolicyStatus{
+				ObservedGeneration: observedGeneration,
+				TypeChecking: &v1.TypeChecking{
+					ExpressionWarnings: warnings,
+				},
+			},
+		}
+	}
+
+	HealthTests[*v1.ValidatingAdmissionPolicy]{
+		{
+			Name:           "type checked",
+			Resource:       policy(2, 2),
+			ExpectedHealth: sdp.Health_HEALTH_OK.Enum(),
+		},
+		{
+			Name:           "not checked yet",
+			Resource:       policy(2, 1),
+			ExpectedHealth: sdp.Health_HEALTH_PENDING.Enum(),
+		},
+		{
+			Name:           "expression warnings",
+			Resource:       policy(1, 1, v1.ExpressionWarning{FieldRef: "spec.validations[0].expression", Warning: "no such key: replicas"}),
+			ExpectedHealth: sdp.Health_HEALTH_WARNING.Enum(),
+		},
+	}.Execute(t, validatingAdmissionPolicyHealthExtractor)
+}
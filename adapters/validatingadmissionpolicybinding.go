@@ -0,0 +1,82 @@
+package adapters
+
+import (
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func validatingAdmissionPolicyBindingExtractor(resource *v1.ValidatingAdmissionPolicyBinding, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	sd, err := ParseScope(scope, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resource.Spec.PolicyName != "" {
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "ValidatingAdmissionPolicy",
+				Method: sdp.QueryMethod_GET,
+				Query:  resource.Spec.PolicyName,
+				Scope:  sd.String(),
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// Changes to the policy change what the binding enforces
+				In: true,
+				// Changing the binding doesn't change the policy
+				Out: false,
+			},
+		})
+	}
+
+	// If there are no match resources the binding applies everywhere the
+	// policy does
+	if match := resource.Spec.MatchResources; match != nil {
+		queries = append(queries, namespaceSelectorQuery(match.NamespaceSelector, sd.ClusterName, &sdp.BlastPropagation{
+			// Namespaces don't affect the binding
+			In: false,
+			// The binding can reject requests in the namespaces
+			Out: true,
+		}))
+	}
+
+	return queries, nil
+}
+
+func newValidatingAdmissionPolicyBindingAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &KubeTypeAdapter[*v1.ValidatingAdmissionPolicyBinding, *v1.ValidatingAdmissionPolicyBindingList]{
+		ClusterName: cluster,
+		Namespaces:  namespaces,
+		TypeName:    "ValidatingAdmissionPolicyBinding",
+		ClusterInterfaceBuilder: func() ItemInterface[*v1.ValidatingAdmissionPolicyBinding, *v1.ValidatingAdmissionPolicyBindingList] {
+			return cs.AdmissionregistrationV1().ValidatingAdmissionPolicyBindings()
+		},
+		ListExtractor: func(list *v1.ValidatingAdmissionPolicyBindingList) ([]*v1.ValidatingAdmissionPolicyBinding, error) {
+			extracted := make([]*v1.ValidatingAdmissionPolicyBinding, len(list.Items))
+
+			for i := range list.Items {
+				extracted[i] = &list.Items[i]
+			}
+
+			return extracted, nil
+		},
+		LinkedItemQueryExtractor: validatingAdmissionPolicyBindingExtractor,
+		AdapterMetadata:          validatingAdmissionPolicyBindingAdapterMetadata,
+	}
+}
+
+var validatingAdmissionPolicyBindingAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:                  "ValidatingAdmissionPolicyBinding",
+	DescriptiveName:       "Validating Admission Policy Binding",
+	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks:        []string{"ValidatingAdmissionPolicy", "Namespace"},
+	SupportedQueryMethods: DefaultSupportedQueryMethods("Validating Admission Policy Binding"),
+})
+
+func init() {
+	registerAdapterLoader(newValidatingAdmissionPolicyBindingAdapter)
+}
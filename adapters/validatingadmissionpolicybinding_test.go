@@ -0,0 +1,52 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+)
+
+var validatingAdmissionPolicyBindingYAML = `
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingAdmissionPolicyBinding
+metadata:
+  name: replica-limit-binding-test
+spec:
+  policyName: replica-limit-test
+  validationActions: [Warn]
+  matchResources:
+    namespaceSelector:
+      matchLabels:
+        environment: test
+`
+
+func TestValidatingAdmissionPolicyBindingAdapter(t *testing.T) {
+	sd := ScopeDetails{
+		ClusterName: CurrentCluster.Name,
+	}
+
+	adapter := newValidatingAdmissionPolicyBindingAdapter(CurrentCluster.ClientSet, sd.ClusterName, []string{"default"})
+
+	st := AdapterTests{
+		Adapter:   adapter,
+		GetQuery:  "replica-limit-binding-test",
+		GetScope:  sd.String(),
+		SetupYAML: validatingAdmissionPolicyBindingYAML,
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "ValidatingAdmissionPolicy",
+				ExpectedMethod: sdp.QueryMethod_GET,
+				ExpectedQuery:  "replica-limit-test",
+				ExpectedScope:  sd.String(),
+			},
+			{
+				ExpectedType:   "Namespace",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  `{"labelSelector":"environment=test"}`,
+				ExpectedScope:  sd.String(),
+			},
+		},
+	}
+
+	st.Execute(t)
+}
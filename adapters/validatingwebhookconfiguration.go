@@ -0,0 +1,68 @@
+package adapters
+
+import (
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func validatingWebhookConfigurationExtractor(resource *v1.ValidatingWebhookConfiguration, scope string) ([]*sdp.LinkedItemQuery, error) {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	sd, err := ParseScope(scope, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, webhook := range resource.Webhooks {
+		queries = append(queries, webhookQueries(webhook.ClientConfig, webhook.NamespaceSelector, sd.ClusterName)...)
+	}
+
+	return queries, nil
+}
+
+func newValidatingWebhookConfigurationAdapter(cs kubernetes.Interface, cluster string, namespaces []string) discovery.ListableAdapter {
+	return &KubeTypeAdapter[*v1.ValidatingWebhookConfiguration, *v1.ValidatingWebhookConfigurationList]{
+		ClusterName: cluster,
+		Namespaces:  namespaces,
+		TypeName:    "ValidatingWebhookConfiguration",
+		ClusterInterfaceBuilder: func() ItemInterface[*v1.ValidatingWebhookConfiguration, *v1.ValidatingWebhookConfigurationList] {
+			return cs.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+		},
+		ListExtractor: func(list *v1.ValidatingWebhookConfigurationList) ([]*v1.ValidatingWebhookConfiguration, error) {
+			extracted := make([]*v1.ValidatingWebhookConfiguration, len(list.Items))
+
+			for i := range list.Items {
+				extracted[i] = &list.Items[i]
+			}
+
+			return extracted, nil
+		},
+		LinkedItemQueryExtractor: validatingWebhookConfigurationExtractor,
+		AdapterMetadata:          validatingWebhookConfigurationAdapterMetadata,
+	}
+}
+
+var validatingWebhookConfigurationAdapterMetadata = Metadata.Register(&sdp.AdapterMetadata{
+	Type:                  "ValidatingWebhookConfiguration",
+	DescriptiveName:       "Validating Webhook Configuration",
+	Category:              sdp.AdapterCategory_ADAPTER_CATEGORY_SECURITY,
+	PotentialLinks:        []string{"Service", "Namespace", "dns", "ip"},
+	SupportedQueryMethods: DefaultSupportedQueryMethods("Validating Webhook Configuration"),
+	TerraformMappings: []*sdp.TerraformMapping{
+		{
+			TerraformMethod:   sdp.QueryMethod_GET,
+			TerraformQueryMap: "kubernetes_validating_webhook_configuration_v1.metadata[0].name",
+		},
+		{
+			TerraformMethod:   sdp.QueryMethod_GET,
+			TerraformQueryMap: "kubernetes_validating_webhook_configuration.metadata[0].name",
+		},
+	},
+})
+
+func init() {
+	registerAdapterLoader(newValidatingWebhookConfigurationAdapter)
+}
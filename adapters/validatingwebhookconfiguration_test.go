@@ -0,0 +1,64 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+)
+
+// The webhook ignores failures and only matches a namespace that doesn't
+// exist, so it won't interfere with the other tests
+var validatingWebhookConfigurationYAML = `
+apiVersion: admissionregistration.k8s.io/v1
+kind: ValidatingWebhookConfiguration
+metadata:
+  name: validating-webhook-test
+webhooks:
+- name: validate.example.com
+  admissionReviewVersions: ["v1"]
+  sideEffects: None
+  failurePolicy: Ignore
+  clientConfig:
+    url: https://policy.example.com/validate
+  namespaceSelector:
+    matchExpressions:
+    - key: kubernetes.io/metadata.name
+      operator: In
+      values: ["does-not-exist"]
+  rules:
+  - apiGroups: ["apps"]
+    apiVersions: ["v1"]
+    operations: ["CREATE", "UPDATE"]
+    resources: ["deployments"]
+`
+
+func TestValidatingWebhookConfigurationAdapter(t *testing.T) {
+	sd := ScopeDetails{
+		ClusterName: CurrentCluster.Name,
+	}
+
+	adapter := newValidatingWebhookConfigurationAdapter(CurrentCluster.ClientSet, sd.ClusterName, []string{"default"})
+
+	st := AdapterTests{
+		Adapter:   adapter,
+		GetQuery:  "validating-webhook-test",
+		GetScope:  sd.String(),
+		SetupYAML: validatingWebhookConfigurationYAML,
+		GetQueryTests: QueryTests{
+			{
+				ExpectedType:   "dns",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  "policy.example.com",
+				ExpectedScope:  "global",
+			},
+			{
+				ExpectedType:   "Namespace",
+				ExpectedMethod: sdp.QueryMethod_SEARCH,
+				ExpectedQuery:  `{"labelSelector":"kubernetes.io/metadata.name in (does-not-exist)"}`,
+				ExpectedScope:  sd.String(),
+			},
+		},
+	}
+
+	st.Execute(t)
+}
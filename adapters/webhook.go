@@ -0,0 +1,121 @@
+package adapters
+
+import (
+	"net"
+	"net/url"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// webhookQueries Links an admission webhook to whatever it calls, and to the
+// namespaces whose requests it intercepts. A webhook that is broken or
+// unreachable can reject every request it matches, so changes to it propagate
+// out to everything in those namespaces. The webhook's `objectSelector` isn't
+// linked since it can match objects of any type, so requests are assumed to be
+// intercepted for everything in the selected namespaces
+func webhookQueries(clientConfig v1.WebhookClientConfig, namespaceSelector *metav1.LabelSelector, clusterName string) []*sdp.LinkedItemQuery {
+	queries := make([]*sdp.LinkedItemQuery, 0)
+
+	if svc := clientConfig.Service; svc != nil {
+		queries = append(queries, &sdp.LinkedItemQuery{
+			Query: &sdp.Query{
+				Type:   "Service",
+				Method: sdp.QueryMethod_GET,
+				Query:  svc.Name,
+				Scope: ScopeDetails{
+					ClusterName: clusterName,
+					Namespace:   svc.Namespace,
+				}.String(),
+			},
+			BlastPropagation: &sdp.BlastPropagation{
+				// If the service is down then the webhook fails
+				In: true,
+				// Changing the webhook doesn't affect the service
+				Out: false,
+			},
+		})
+	}
+
+	if clientConfig.URL != nil {
+		if u, err := url.Parse(*clientConfig.URL); err == nil && u.Hostname() != "" {
+			if net.ParseIP(u.Hostname()) != nil {
+				queries = append(queries, &sdp.LinkedItemQuery{
+					Query: &sdp.Query{
+						Type:   "ip",
+						Method: sdp.QueryMethod_GET,
+						Query:  u.Hostname(),
+						Scope:  "global",
+					},
+					BlastPropagation: &sdp.BlastPropagation{
+						// IPs are always bidirectional
+						In:  true,
+						Out: true,
+					},
+				})
+			} else {
+				queries = append(queries, &sdp.LinkedItemQuery{
+					Query: &sdp.Query{
+						Type:   "dns",
+						Method: sdp.QueryMethod_SEARCH,
+						Query:  u.Hostname(),
+						Scope:  "global",
+					},
+					BlastPropagation: &sdp.BlastPropagation{
+						// DNS is always bidirectional
+						In:  true,
+						Out: true,
+					},
+				})
+			}
+		}
+	}
+
+	queries = append(queries, namespaceSelectorQuery(namespaceSelector, clusterName, &sdp.BlastPropagation{
+		// Namespaces don't affect the webhook
+		In: false,
+		// The webhook can reject or modify every request in the namespaces
+		Out: true,
+	}))
+
+	return queries
+}
+
+// namespaceSelectorQuery Links to the namespaces that match a selector. A nil
+// or empty selector matches every namespace, the same as for webhooks and
+// admission policies. Namespace selectors commonly exclude namespaces with
+// match expressions e.g. `kubernetes.io/metadata.name notin (kube-system)`, so
+// unlike LabelSelectorToQuery these are included
+func namespaceSelectorQuery(selector *metav1.LabelSelector, clusterName string, blastProp *sdp.BlastPropagation) *sdp.LinkedItemQuery {
+	query := &sdp.LinkedItemQuery{
+		Query: &sdp.Query{
+			Type:   "Namespace",
+			Method: sdp.QueryMethod_LIST,
+			Scope:  clusterName,
+		},
+		BlastPropagation: blastProp,
+	}
+
+	if selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0) {
+		return query
+	}
+
+	query.Query.Method = sdp.QueryMethod_SEARCH
+
+	parsed, err := metav1.LabelSelectorAsSelector(selector)
+
+	if err != nil {
+		// The API server validates selectors so this shouldn't happen, fall
+		// back to the labels that can be matched
+		query.Query.Query = LabelSelectorToQuery(selector)
+
+		return query
+	}
+
+	query.Query.Query = ListOptionsToQuery(&metav1.ListOptions{
+		LabelSelector: parsed.String(),
+	})
+
+	return query
+}
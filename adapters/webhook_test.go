@@ -0,0 +1,73 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/overmindtech/sdp-go"
+	v1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWebhookQueries(t *testing.T) {
+	url := "https://10.0.0.5:8443/validate"
+
+	queries := webhookQueries(v1.WebhookClientConfig{URL: &url}, nil, "test")
+
+	QueryTests{
+		{
+			ExpectedType:   "ip",
+			ExpectedMethod: sdp.QueryMethod_GET,
+			ExpectedQuery:  "10.0.0.5",
+			ExpectedScope:  "global",
+		},
+		{
+			// No selector means every namespace
+			ExpectedType:   "Namespace",
+			ExpectedMethod: sdp.QueryMethod_LIST,
+			ExpectedScope:  "test",
+		},
+	}.Execute(t, &sdp.Item{LinkedItemQueries: queries})
+
+	for _, query := range queries {
+		if query.GetQuery().GetType() == "Namespace" && !query.GetBlastPropagation().GetOut() {
+			t.Error("expected changes to the webhook to propagate to namespaces")
+		}
+	}
+}
+
+func TestNamespaceSelectorQuery(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		query := namespaceSelectorQuery(nil, "test", nil)
+
+		if query.GetQuery().GetMethod() != sdp.QueryMethod_LIST {
+			t.Errorf("expected a nil selector to list every namespace, got %v", query.GetQuery().GetMethod())
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		query := namespaceSelectorQuery(&metav1.LabelSelector{}, "test", nil)
+
+		if query.GetQuery().GetMethod() != sdp.QueryMethod_LIST {
+			t.Errorf("expected an empty selector to list every namespace, got %v", query.GetQuery().GetMethod())
+		}
+	})
+
+	t.Run("match expressions", func(t *testing.T) {
+		query := namespaceSelectorQuery(&metav1.LabelSelector{
+			MatchLabels: map[string]string{"team": "web"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      "kubernetes.io/metadata.name",
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"kube-system"},
+				},
+			},
+		}, "test", nil)
+
+		expected := `{"labelSelector":"kubernetes.io/metadata.name notin (kube-system),team=web"}`
+
+		if query.GetQuery().GetMethod() != sdp.QueryMethod_SEARCH || query.GetQuery().GetQuery() != expected {
+			t.Errorf("expected SEARCH %v, got %v %v", expected, query.GetQuery().GetMethod(), query.GetQuery().GetQuery())
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/k8s-source/adapters"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/kubernetes"
+)
+
+// accessCheckerFromViper Creates an access checker for the cluster, this is
+// nil if permissions shouldn't be checked
+func accessCheckerFromViper(clientSet kubernetes.Interface) *adapters.AccessChecker {
+	if !viper.GetBool("check-permissions") {
+		return nil
+	}
+
+	verbs := []string{"get", "list"}
+
+	if viper.GetBool("use-informers") {
+		verbs = append(verbs, "watch")
+	}
+
+	return &adapters.AccessChecker{
+		Client: clientSet,
+		Verbs:  verbs,
+	}
+}
+
+// restrictAdapters Checks which scopes each adapter has permission to query.
+// Namespaces that can't be queried are removed from the adapters, and
+// adapters that can't query anything are dropped, so that these fail cleanly
+// rather than with a 403 at query time
+func (c *Cluster) restrictAdapters(ctx context.Context, adapterList []discovery.Adapter) []discovery.Adapter {
+	if c.accessChecker == nil {
+		return adapterList
+	}
+
+	accessList, err := c.accessChecker.Check(ctx, adapterList)
+
+	if err != nil {
+		// Not being able to check permissions shouldn't stop discovery, any
+		// queries we don't have access to will fail as they would have
+		c.logger().WithError(err).Warn("Could not check permissions")
+
+		return adapterList
+	}
+
+	byType := make(map[string]*adapters.AdapterAccess)
+
+	for _, access := range accessList {
+		byType[access.Type] = access
+	}
+
+	restricted := make([]discovery.Adapter, 0, len(adapterList))
+	var denied, partial int
+
+	for _, adapter := range adapterList {
+		access, ok := byType[adapter.Type()]
+
+		if !ok || len(access.Denied) == 0 {
+			restricted = append(restricted, adapter)
+			continue
+		}
+
+		accessLog := c.logger().WithFields(log.Fields{
+			"type":     access.Type,
+			"resource": access.Resource,
+			"verbs":    c.accessChecker.Verbs,
+		})
+
+		// A namespaced adapter that can't query any of its namespaces would
+		// have no scopes, so is dropped the same as a cluster-wide one
+		if !access.Namespaced || len(access.Allowed) == 0 {
+			accessLog.Warn("No permission to query type, it will not be discovered")
+			denied++
+
+			continue
+		}
+
+		if nu, ok := adapter.(adapters.NamespaceUpdater); ok {
+			for _, scope := range access.Denied {
+				if details, err := adapters.ParseScope(scope, true); err == nil {
+					nu.RemoveNamespace(details.Namespace)
+				}
+			}
+		}
+
+		accessLog.WithFields(log.Fields{
+			"allowed": len(access.Allowed),
+			"denied":  len(access.Denied),
+		}).Warn("No permission to query type in some namespaces, these will not be discovered")
+		partial++
+
+		restricted = append(restricted, adapter)
+	}
+
+	c.logger().WithFields(log.Fields{
+		"checked":    len(accessList),
+		"restricted": partial,
+		"denied":     denied,
+	}).Info("Checked permissions")
+
+	c.accessMu.Lock()
+	defer c.accessMu.Unlock()
+
+	if c.access == nil {
+		c.access = make(map[string]*adapters.AdapterAccess)
+	}
+
+	maps.Copy(c.access, byType)
+
+	return restricted
+}
+
+// canQueryNamespace Returns whether an adapter has permission to query a
+// namespace that has been created since the adapters were loaded. If this
+// can't be checked it is assumed to be allowed
+func (c *Cluster) canQueryNamespace(adapter discovery.Adapter, namespace string) bool {
+	if c.accessChecker == nil {
+		return true
+	}
+
+	c.accessMu.Lock()
+	access, ok := c.access[adapter.Type()]
+	c.accessMu.Unlock()
+
+	if !ok || access.ClusterWide {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	allowed, err := c.accessChecker.AllowedInNamespace(ctx, adapter, namespace)
+
+	if err != nil {
+		c.logger().WithError(err).WithField("namespace", namespace).Warn("Could not check permissions for namespace")
+
+		return true
+	}
+
+	scope := adapters.ScopeDetails{ClusterName: c.Name, Namespace: namespace}.String()
+
+	c.accessMu.Lock()
+	defer c.accessMu.Unlock()
+
+	if allowed {
+		access.Allowed = append(access.Allowed, scope)
+	} else {
+		access.Denied = append(access.Denied, scope)
+	}
+
+	return allowed
+}
+
+// forgetNamespaceAccess Removes a namespace that has been deleted from the
+// permissions report
+func (c *Cluster) forgetNamespaceAccess(namespace string) {
+	scope := adapters.ScopeDetails{ClusterName: c.Name, Namespace: namespace}.String()
+	isScope := func(s string) bool { return s == scope }
+
+	c.accessMu.Lock()
+	defer c.accessMu.Unlock()
+
+	for _, access := range c.access {
+		access.Allowed = slices.DeleteFunc(access.Allowed, isScope)
+		access.Denied = slices.DeleteFunc(access.Denied, isScope)
+	}
+}
+
+// Access Returns the result of the permissions check for each type, sorted by
+// type. This is empty if permissions aren't being checked
+func (c *Cluster) Access() []adapters.AdapterAccess {
+	c.accessMu.Lock()
+	defer c.accessMu.Unlock()
+
+	accessList := make([]adapters.AdapterAccess, 0, len(c.access))
+
+	for _, itemType := range slices.Sorted(maps.Keys(c.access)) {
+		access := *c.access[itemType]
+		access.Allowed = slices.Clone(access.Allowed)
+		access.Denied = slices.Clone(access.Denied)
+
+		accessList = append(accessList, access)
+	}
+
+	return accessList
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/overmindtech/k8s-source/adapters"
+	"github.com/spf13/viper"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRestrictAdapters(t *testing.T) {
+	viper.Reset()
+	viper.Set("check-permissions", true)
+
+	// The source can only query things in team-a, which is also where new
+	// namespaces get access
+	cs := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+	)
+
+	cs.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+
+		return true, review, nil
+	})
+
+	cs.PrependReactor("create", "selfsubjectrulesreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+
+		if review.Spec.Namespace != "team-b" {
+			review.Status.ResourceRules = []authorizationv1.ResourceRule{
+				{
+					Verbs:     []string{"*"},
+					APIGroups: []string{"*"},
+					Resources: []string{"*"},
+				},
+			}
+		}
+
+		return true, review, nil
+	})
+
+	cluster := &Cluster{
+		Name:          "test",
+		ClientSet:     cs,
+		namespaces:    make(map[string]bool),
+		accessChecker: accessCheckerFromViper(cs),
+	}
+
+	adapterList, err := cluster.LoadAdapters(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pods interface{ Scopes() []string }
+
+	for _, adapter := range adapterList {
+		if adapter.Type() == "Node" {
+			t.Error("expected Node adapter to be dropped")
+		}
+
+		if adapter.Type() == "Pod" {
+			pods = adapter
+		}
+	}
+
+	if pods == nil {
+		t.Fatal("expected Pod adapter to be loaded")
+	}
+
+	// Pods can also be searched for in the cluster scope, which only covers
+	// the namespaces that are being queried
+	if !slices.Equal(pods.Scopes(), []string{"test.team-a", "test"}) {
+		t.Errorf("expected only team-a to be queried, got %v", pods.Scopes())
+	}
+
+	t.Run("new namespaces", func(t *testing.T) {
+		cluster.addNamespace("team-c")
+
+		if !slices.Equal(pods.Scopes(), []string{"test.team-a", "test.team-c", "test"}) {
+			t.Errorf("expected team-c to be added, got %v", pods.Scopes())
+		}
+	})
+
+	t.Run("report", func(t *testing.T) {
+		var podAccess *adapters.AdapterAccess
+
+		for _, access := range cluster.Access() {
+			if access.Type == "Pod" {
+				podAccess = &access
+			}
+		}
+
+		if podAccess == nil {
+			t.Fatal("expected Pod in the report")
+		}
+
+		if !slices.Equal(podAccess.Allowed, []string{"test.team-a", "test.team-c"}) || !slices.Equal(podAccess.Denied, []string{"test.team-b"}) {
+			t.Errorf("unexpected report %+v", podAccess)
+		}
+
+		cluster.removeNamespace("team-c")
+
+		for _, access := range cluster.Access() {
+			if access.Type == "Pod" && slices.Contains(access.Allowed, "test.team-c") {
+				t.Error("expected team-c to be removed from the report")
+			}
+		}
+	})
+}
+
+func TestRestrictAdaptersDeniedEverywhere(t *testing.T) {
+	viper.Reset()
+	viper.Set("check-permissions", true)
+
+	cs := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+	)
+
+	cs.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, action.(k8stesting.CreateAction).GetObject(), nil
+	})
+
+	// Pods can be queried in every namespace, but nothing else can
+	cs.PrependReactor("create", "selfsubjectrulesreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+
+		review.Status.ResourceRules = []authorizationv1.ResourceRule{
+			{
+				Verbs:     []string{"*"},
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+			},
+		}
+
+		return true, review, nil
+	})
+
+	cluster := &Cluster{
+		Name:          "test",
+		ClientSet:     cs,
+		namespaces:    make(map[string]bool),
+		accessChecker: accessCheckerFromViper(cs),
+	}
+
+	adapterList, err := cluster.LoadAdapters(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+
+	for _, adapter := range adapterList {
+		types = append(types, adapter.Type())
+	}
+
+	if !slices.Contains(types, "Pod") {
+		t.Errorf("expected Pod adapter to be loaded, got %v", types)
+	}
+
+	if slices.Contains(types, "ConfigMap") {
+		t.Error("expected ConfigMap adapter to be dropped since it can't query any namespace")
+	}
+
+	viper.Reset()
+}
@@ -0,0 +1,778 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/overmindtech/discovery"
+	"github.com/overmindtech/k8s-source/adapters"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// ClusterConfig The config for a single cluster. A list of these can be
+// provided under the `clusters` key of the config file to discover many
+// clusters from a single source
+type ClusterConfig struct {
+	// The descriptive name of the cluster, this is used to generate scopes.
+	// If this is blank the name of the context, or the hostname of the API
+	// server will be used
+	Name string `mapstructure:"name"`
+	// Path to the kubeconfig file. If this and `Context` are blank the
+	// in-cluster config will be used
+	Kubeconfig string `mapstructure:"kubeconfig"`
+	// The context to use from the kubeconfig. If this is blank the current
+	// context will be used
+	Context string `mapstructure:"context"`
+	// The namespaces to discover. If this is set the source doesn't need any
+	// cluster-wide permissions: namespaces aren't listed or watched, and
+	// cluster-scoped types are skipped. If this is blank the `namespaces`
+	// option is used
+	Namespaces []string `mapstructure:"namespaces"`
+}
+
+// clusterConfigsFromViper Works out which clusters to connect to. A list of
+// clusters in the config file takes precedence, followed by a list of
+// contexts, falling back to the single cluster given by `kubeconfig` and
+// `cluster-name`
+func clusterConfigsFromViper() ([]ClusterConfig, error) {
+	var configs []ClusterConfig
+
+	if viper.IsSet("clusters") {
+		err := viper.UnmarshalKey("clusters", &configs)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not parse clusters config: %w", err)
+		}
+
+		if len(configs) == 0 {
+			return nil, errors.New("clusters config is set but contains no clusters")
+		}
+
+		return configs, nil
+	}
+
+	kubeconfig := viper.GetString("kubeconfig")
+
+	if contexts := viper.GetStringSlice("kube-contexts"); len(contexts) > 0 {
+		for _, kubeContext := range contexts {
+			configs = append(configs, ClusterConfig{
+				Name:       kubeContext,
+				Kubeconfig: kubeconfig,
+				Context:    kubeContext,
+			})
+		}
+
+		return configs, nil
+	}
+
+	return []ClusterConfig{
+		{
+			Name:       viper.GetString("cluster-name"),
+			Kubeconfig: kubeconfig,
+		},
+	}, nil
+}
+
+// Cluster A connection to a single kubernetes cluster, along with the adapters
+// that have been loaded for it
+type Cluster struct {
+	Name          string
+	ClientSet     kubernetes.Interface
+	DynamicClient dynamic.Interface
+	// The config used to connect to the cluster, this is nil if the cluster
+	// is being served from a snapshot
+	RestConfig *rest.Config
+	// The recorded objects that the cluster is served from, this is nil
+	// unless the cluster is being served from a snapshot. Snapshot clusters
+	// have no clients
+	snapshot *adapters.ClusterSnapshot
+
+	// The adapters that are currently loaded for this cluster, and the
+	// namespaces that they are querying. These are only accessed from the
+	// main goroutine
+	adapters   []discovery.Adapter
+	namespaces map[string]bool
+
+	// Creates adapters for CRDs, this is nil if custom resources aren't
+	// being discovered
+	customResources *adapters.CustomResourceManager
+
+	// The namespaces given in the config. If this is set the cluster is in
+	// namespace-scoped mode and nothing cluster-wide is queried
+	fixedNamespaces []string
+
+	// Which namespaces and types should be discovered
+	namespaceFilter NamespaceFilter
+	typeFilter      adapters.TypeFilter
+
+	// Checks what the source has permission to query, this is nil if
+	// permissions aren't being checked
+	accessChecker *adapters.AccessChecker
+	// The result of the permissions check by type. This is read by the
+	// health endpoint so is protected by accessMu
+	access   map[string]*adapters.AdapterAccess
+	accessMu sync.Mutex
+
+	// Set if something has gone wrong with this cluster that means its data
+	// can't be trusted, such as losing the namespace watch
+	failure   error
+	failureMu sync.Mutex
+}
+
+// ConnectCluster Creates a client for the cluster in the given config
+func ConnectCluster(config ClusterConfig) (*Cluster, error) {
+	var restConfig *rest.Config
+	var err error
+
+	namespaceFilter, typeFilter, err := filtersFromViper()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Kubeconfig == "" && config.Context == "" {
+		log.Info("Using in-cluster config")
+
+		restConfig, err = rest.InClusterConfig()
+
+		if err != nil {
+			return nil, fmt.Errorf("could not load in-cluster config: %w", err)
+		}
+	} else {
+		// Load kubernetes config from a file, using the default loading rules
+		// if no file was given so that a context can be picked from
+		// $KUBECONFIG or ~/.kube/config
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		loadingRules.ExplicitPath = config.Kubeconfig
+
+		restConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			loadingRules,
+			&clientcmd.ConfigOverrides{CurrentContext: config.Context},
+		).ClientConfig()
+
+		if err != nil {
+			return nil, fmt.Errorf("could not load kubernetes config: %w", err)
+		}
+	}
+
+	restConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper { return otelhttp.NewTransport(rt) })
+	// Set up rate limiting
+	restConfig.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(
+		float32(viper.GetFloat64("rate-limit-qps")),
+		viper.GetInt("rate-limit-burst"),
+	)
+	// Create clientSet
+	clientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not create kubernetes client: %w", err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not create dynamic kubernetes client: %w", err)
+	}
+
+	// Work out the cluster name
+	name := config.Name
+
+	if name == "" {
+		k8sURL, err := url.Parse(restConfig.Host)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse kubernetes url %v: %w", restConfig.Host, err)
+		}
+
+		// If there is no port then set one
+		if k8sURL.Port() == "" {
+			switch k8sURL.Scheme {
+			case "http":
+				k8sURL.Host = k8sURL.Host + ":80"
+			case "https":
+				k8sURL.Host = k8sURL.Host + ":443"
+			}
+		}
+
+		name = k8sURL.Host
+	}
+
+	fixedNamespaces := config.Namespaces
+
+	if len(fixedNamespaces) == 0 {
+		fixedNamespaces = viper.GetStringSlice("namespaces")
+	}
+
+	return &Cluster{
+		Name:            name,
+		ClientSet:       clientSet,
+		DynamicClient:   dynamicClient,
+		RestConfig:      restConfig,
+		namespaces:      make(map[string]bool),
+		namespaceFilter: namespaceFilter,
+		typeFilter:      typeFilter,
+		accessChecker:   accessCheckerFromViper(clientSet),
+		fixedNamespaces: fixedNamespaces,
+	}, nil
+}
+
+// failedCluster Returns a cluster that couldn't be connected to. It has no
+// clients and is only used to report the error, it is named from the config
+// since the API server's hostname may not be known
+func failedCluster(config ClusterConfig, err error) *Cluster {
+	name := config.Name
+
+	if name == "" {
+		name = config.Context
+	}
+
+	if name == "" {
+		name = config.Kubeconfig
+	}
+
+	if name == "" {
+		name = "in-cluster"
+	}
+
+	cluster := &Cluster{
+		Name:            name,
+		namespaces:      make(map[string]bool),
+		fixedNamespaces: config.Namespaces,
+	}
+
+	cluster.setFailure(fmt.Errorf("could not connect: %w", err))
+
+	return cluster
+}
+
+// configString Describes the config used to connect to the cluster, so that
+// sources with the same config can share a queue. The rest config implements
+// redaction in the String() method so we don't have to worry about leaking
+// secrets. Filters are included since sources that discover different things
+// can't share a queue
+func (c *Cluster) configString() string {
+	config := fmt.Sprintf("snapshot:%v", c.Name)
+
+	switch {
+	case c.RestConfig != nil:
+		config = c.RestConfig.String()
+	case c.ClientSet == nil && c.snapshot == nil:
+		config = fmt.Sprintf("unconnected:%v", c.Name)
+	}
+
+	if filters := c.namespaceFilter.String(); filters != "" {
+		config += "\nnamespaces:" + filters
+	}
+
+	if filters := c.typeFilter.String(); filters != "" {
+		config += "\ntypes:" + filters
+	}
+
+	if c.NamespaceScoped() {
+		config += "\nfixed-namespaces:" + strings.Join(c.fixedNamespaces, ",")
+	}
+
+	return config
+}
+
+// Failure Returns the error that caused this cluster to fail, if any
+func (c *Cluster) Failure() error {
+	c.failureMu.Lock()
+	defer c.failureMu.Unlock()
+
+	return c.failure
+}
+
+// setFailure Records that something has gone wrong with this cluster, passing
+// nil clears the failure
+func (c *Cluster) setFailure(err error) {
+	c.failureMu.Lock()
+	defer c.failureMu.Unlock()
+
+	c.failure = err
+}
+
+// clustersHealthCheck Checks the health of all clusters. The source is still
+// useful as long as one cluster is healthy, so this only fails when they all
+// are. The health of each cluster is reported on /healthz/clusters
+func clustersHealthCheck(ctx context.Context, clusters []*Cluster) error {
+	errs := make([]error, 0)
+
+	for _, cluster := range clusters {
+		if err := cluster.HealthCheck(ctx); err != nil {
+			cluster.logger().WithError(err).Warn("Cluster is unhealthy")
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) == len(clusters) {
+		return errors.Join(errs...)
+	}
+
+	return nil
+}
+
+// HealthCheck Checks that the cluster is reachable and hasn't failed
+func (c *Cluster) HealthCheck(ctx context.Context) error {
+	if err := c.Failure(); err != nil {
+		return fmt.Errorf("cluster %v: %w", c.Name, err)
+	}
+
+	if c.snapshot != nil {
+		// There is no API server to reach
+		return nil
+	}
+
+	if c.NamespaceScoped() {
+		// We might not have permission to read anything in particular, but
+		// every user can review their own rules, so this checks that the API
+		// server is reachable and that our credentials are valid
+		_, err := c.ClientSet.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+			Spec: authorizationv1.SelfSubjectRulesReviewSpec{
+				Namespace: c.fixedNamespaces[0],
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("cluster %v: health check (reviewing rules in namespace %v) failed: %w", c.Name, c.fixedNamespaces[0], err)
+		}
+
+		return nil
+	}
+
+	// Make sure we can list nodes in the cluster
+	_, err := c.ClientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		Limit: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("cluster %v: health check (listing nodes) failed: %w", c.Name, err)
+	}
+
+	return nil
+}
+
+// NamespaceScoped Returns whether the cluster is in namespace-scoped mode,
+// where the namespaces are given in the config and nothing cluster-wide is
+// queried. This allows the source to run with only a Role in each namespace
+func (c *Cluster) NamespaceScoped() bool {
+	return len(c.fixedNamespaces) > 0
+}
+
+func (c *Cluster) logger() *log.Entry {
+	return log.WithField("cluster", c.Name)
+}
+
+// listNamespaces Lists the names of all namespaces in the cluster that match
+// the namespace filter
+func (c *Cluster) listNamespaces(ctx context.Context) ([]string, error) {
+	if c.NamespaceScoped() {
+		// We may not have permission to list namespaces, so the namespaces
+		// from the config are used as they are
+		return slices.Clone(c.fixedNamespaces), nil
+	}
+
+	var list *corev1.NamespaceList
+	var err error
+
+	if c.snapshot != nil {
+		list, err = c.snapshot.Namespaces()
+	} else {
+		c.logger().Info("Listing namespaces")
+		list, err = c.ClientSet.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]string, 0, len(list.Items))
+
+	for i := range list.Items {
+		if c.namespaceFilter.Matches(&list.Items[i]) {
+			namespaces = append(namespaces, list.Items[i].Name)
+		}
+	}
+
+	return namespaces, nil
+}
+
+// LoadAdapters Creates the adapters for this cluster using the current set of
+// namespaces
+func (c *Cluster) LoadAdapters(ctx context.Context) ([]discovery.Adapter, error) {
+	namespaces, err := c.listNamespaces(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger().Infof("got %v namespaces", len(namespaces))
+
+	if c.snapshot != nil {
+		c.adapters, err = c.snapshot.LoadAdapters(namespaces, c.typeFilter)
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		c.adapters = adapters.LoadAllAdapters(c.ClientSet, c.Name, namespaces, c.typeFilter)
+	}
+
+	c.customResources = nil
+
+	if c.NamespaceScoped() {
+		c.adapters = adapters.NamespacedAdapters(c.adapters)
+	}
+
+	c.accessMu.Lock()
+	c.access = nil
+	c.accessMu.Unlock()
+
+	// Finding custom resources means listing CRDs, which are cluster-wide
+	if viper.GetBool("discover-custom-resources") && !c.NamespaceScoped() {
+		// Custom resources mustn't shadow built-in types, including the ones
+		// that have been filtered out
+		if c.snapshot != nil {
+			c.customResources = c.snapshot.CustomResourceManager(adapters.BuiltInTypes(nil))
+		} else {
+			c.customResources = adapters.NewCustomResourceManager(c.DynamicClient, c.ClientSet.Discovery(), c.Name, adapters.BuiltInTypes(c.ClientSet))
+		}
+
+		// The manager is new so there is nothing for it to replace
+		crAdapters, _, err := c.customResources.Sync(ctx, namespaces)
+		c.logSkippedCustomResources()
+
+		if err != nil {
+			// Not being able to see CRDs shouldn't stop us from discovering
+			// everything else
+			c.logger().WithError(err).Warn("Could not discover custom resources")
+		} else {
+			crAdapters = c.typeFilter.Adapters(crAdapters)
+
+			c.logger().Infof("got %v custom resources", len(crAdapters))
+			c.adapters = append(c.adapters, crAdapters...)
+		}
+	}
+
+	c.adapters = c.restrictAdapters(ctx, c.adapters)
+
+	c.prepareAdapters(c.adapters)
+
+	c.namespaces = make(map[string]bool)
+
+	for _, namespace := range namespaces {
+		c.namespaces[namespace] = true
+	}
+
+	return c.adapters, nil
+}
+
+// SyncCustomResources Brings the custom resource adapters in line with the
+// CRDs in the cluster, returning any new adapters that need to be added to the
+// engine. If any adapters had to be replaced, e.g. because a CRD's storage
+// version changed, `restart` is set since the old adapters can only be removed
+// from the engine by restarting it
+func (c *Cluster) SyncCustomResources(ctx context.Context) (created []discovery.Adapter, restart bool, err error) {
+	if c.customResources == nil {
+		return nil, false, nil
+	}
+
+	namespaces := make([]string, 0, len(c.namespaces))
+
+	for namespace := range c.namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+
+	slices.Sort(namespaces)
+
+	created, replaced, err := c.customResources.Sync(ctx, namespaces)
+	c.logSkippedCustomResources()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(replaced) > 0 {
+		// Restarting reloads all of the adapters, including the new ones
+		return nil, true, nil
+	}
+
+	created = c.restrictAdapters(ctx, c.typeFilter.Adapters(created))
+
+	c.prepareAdapters(created)
+
+	c.adapters = append(c.adapters, created...)
+
+	return created, false, nil
+}
+
+// logSkippedCustomResources Warns about CRDs that aren't being discovered
+// because another adapter already has their type
+func (c *Cluster) logSkippedCustomResources() {
+	skipped := c.customResources.Skipped()
+
+	for _, name := range slices.Sorted(maps.Keys(skipped)) {
+		c.logger().WithFields(log.Fields{
+			"crd":    name,
+			"reason": skipped[name],
+		}).Warn("Skipping CustomResourceDefinition since its type is taken")
+	}
+}
+
+// prepareAdapters Applies any settings that need to be applied to adapters
+// before they are added to the engine. Snapshots don't change, so there is
+// nothing for informers to watch
+func (c *Cluster) prepareAdapters(adapterList []discovery.Adapter) {
+	if viper.GetBool("use-informers") && c.snapshot == nil {
+		for _, adapter := range adapterList {
+			if ia, ok := adapter.(adapters.InformerAdapter); ok {
+				ia.EnableInformers()
+			}
+		}
+	}
+}
+
+// StopAdapters Stops any background work the adapters are doing, such as
+// informers that are watching the API
+func (c *Cluster) StopAdapters() {
+	for _, adapter := range c.adapters {
+		if ia, ok := adapter.(adapters.InformerAdapter); ok {
+			ia.StopInformers()
+		}
+	}
+
+	c.adapters = nil
+}
+
+// addNamespace Adds a namespace to all loaded adapters
+func (c *Cluster) addNamespace(namespace string) {
+	if c.namespaces[namespace] {
+		return
+	}
+
+	c.logger().WithField("namespace", namespace).Info("Adding namespace")
+
+	for _, adapter := range c.adapters {
+		if nu, ok := adapter.(adapters.NamespaceUpdater); ok && c.canQueryNamespace(adapter, namespace) {
+			nu.AddNamespace(namespace)
+		}
+	}
+
+	c.namespaces[namespace] = true
+}
+
+// updateNamespace Adds or removes a namespace when it changes, depending on
+// whether it matches the namespace filter. Changes to labels can mean that a
+// namespace starts or stops matching
+func (c *Cluster) updateNamespace(ns *corev1.Namespace) {
+	if c.namespaceFilter.Matches(ns) {
+		c.addNamespace(ns.Name)
+	} else {
+		c.removeNamespace(ns.Name)
+	}
+}
+
+// removeNamespace Removes a namespace from all loaded adapters
+func (c *Cluster) removeNamespace(namespace string) {
+	if !c.namespaces[namespace] {
+		return
+	}
+
+	c.logger().WithField("namespace", namespace).Info("Removing namespace")
+
+	for _, adapter := range c.adapters {
+		if nu, ok := adapter.(adapters.NamespaceUpdater); ok {
+			nu.RemoveNamespace(namespace)
+		}
+	}
+
+	c.forgetNamespaceAccess(namespace)
+	delete(c.namespaces, namespace)
+}
+
+// resyncNamespaces Brings the loaded namespaces in line with the cluster
+// without restarting the engine
+func (c *Cluster) resyncNamespaces(ctx context.Context) error {
+	namespaces, err := c.listNamespaces(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]bool)
+
+	for _, namespace := range namespaces {
+		current[namespace] = true
+		c.addNamespace(namespace)
+	}
+
+	for namespace := range c.namespaces {
+		if !current[namespace] {
+			c.removeNamespace(namespace)
+		}
+	}
+
+	return nil
+}
+
+// The resources that are watched in each cluster
+const (
+	namespacesResource                = "namespaces"
+	customResourceDefinitionsResource = "customresourcedefinitions"
+)
+
+// clusterEvent A watch event, along with the cluster and the resource that it
+// came from
+type clusterEvent struct {
+	Cluster *Cluster
+	// The resource that is being watched e.g. "namespaces"
+	Resource string
+	Event    watch.Event
+	// The error that caused a FATAL event
+	Err error
+}
+
+// WatchNamespaces Starts watching namespaces and sends all events to the given
+// channel until the context is cancelled. If the watch can't be recovered a
+// FATAL event is sent
+func (c *Cluster) WatchNamespaces(ctx context.Context, events chan<- clusterEvent) error {
+	return c.watch(ctx, namespacesResource, func(ctx context.Context) (watch.Interface, error) {
+		return watchNamespaces(ctx, c.ClientSet)
+	}, events)
+}
+
+// WatchCustomResourceDefinitions Starts watching CRDs and sends all events to
+// the given channel until the context is cancelled. If the watch can't be
+// recovered a FATAL event is sent
+func (c *Cluster) WatchCustomResourceDefinitions(ctx context.Context, events chan<- clusterEvent) error {
+	return c.watch(ctx, customResourceDefinitionsResource, func(ctx context.Context) (watch.Interface, error) {
+		crds := c.DynamicClient.Resource(adapters.CustomResourceDefinitionGVR)
+
+		// Get the initial starting point
+		list, err := crds.List(ctx, metav1.ListOptions{})
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Watch CRDs from here
+		return crds.Watch(ctx, metav1.ListOptions{
+			ResourceVersion: list.GetResourceVersion(),
+		})
+	}, events)
+}
+
+// watch Starts a watch using the given function, and restarts it whenever it
+// closes. All events are sent to the given channel until the context is
+// cancelled
+func (c *Cluster) watch(ctx context.Context, resource string, startWatch func(ctx context.Context) (watch.Interface, error), events chan<- clusterEvent) error {
+	wi, err := startWatch(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	watchLog := c.logger().WithField("resource", resource)
+
+	go func() {
+		attempts := 0
+		sleep := 1 * time.Second
+
+		for {
+			select {
+			case event, ok := <-wi.ResultChan():
+				if !ok {
+					// If the channel is closed then we need to restart the
+					// watch
+
+					watchLog.Error("Watch channel closed")
+					watchLog.Info("Re-subscribing to watch")
+
+					wi, err = startWatch(ctx)
+
+					// Check for transient network errors
+					if err != nil {
+						var netErr *net.OpError
+
+						if errors.As(err, &netErr) {
+							// Mark a failure
+							attempts++
+
+							// If we have had less than 3 failures then retry
+							if attempts < 4 {
+								// The watch interface will be nil if we
+								// couldn't connect, so create a fake watcher
+								// that is closed so that we end up in this loop
+								// again
+								wi = watch.NewFake()
+								wi.Stop()
+
+								jitter := time.Duration(rand.Int63n(int64(sleep))) // nolint:gosec // we don't need cryptographically secure randomness here
+								sleep = sleep + jitter/2
+
+								watchLog.WithError(err).Errorf("Transient network error, retrying in %v seconds", sleep.String())
+								time.Sleep(sleep)
+								continue
+							}
+						}
+
+						sentry.CaptureException(err)
+						watchLog.WithError(err).Error("could not restart watch")
+
+						// Send a fatal event so that the main goroutine can
+						// decide what to do
+						events <- clusterEvent{
+							Cluster:  c,
+							Resource: resource,
+							Event: watch.Event{
+								Type: watch.EventType("FATAL"),
+							},
+							Err: err,
+						}
+
+						return
+					}
+
+					// If it's worked, reset the failure counter
+					attempts = 0
+
+					// Events could have been missed while the watch was down,
+					// so reconcile against the current state
+					events <- clusterEvent{
+						Cluster:  c,
+						Resource: resource,
+						Event: watch.Event{
+							Type: watch.EventType("RESYNC"),
+						},
+					}
+				} else {
+					events <- clusterEvent{
+						Cluster:  c,
+						Resource: resource,
+						Event:    event,
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
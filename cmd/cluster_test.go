@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestClusterConfigsFromViper(t *testing.T) {
+	t.Run("single cluster", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("kubeconfig", "/tmp/kubeconfig")
+		viper.Set("cluster-name", "prod")
+
+		configs, err := clusterConfigsFromViper()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(configs) != 1 {
+			t.Fatalf("expected 1 config, got %v", len(configs))
+		}
+
+		if configs[0].Name != "prod" || configs[0].Kubeconfig != "/tmp/kubeconfig" {
+			t.Errorf("unexpected config %+v", configs[0])
+		}
+	})
+
+	t.Run("contexts", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("kubeconfig", "/tmp/kubeconfig")
+		viper.Set("kube-contexts", []string{"dev", "prod"})
+
+		configs, err := clusterConfigsFromViper()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(configs) != 2 {
+			t.Fatalf("expected 2 configs, got %v", len(configs))
+		}
+
+		if configs[1].Name != "prod" || configs[1].Context != "prod" || configs[1].Kubeconfig != "/tmp/kubeconfig" {
+			t.Errorf("unexpected config %+v", configs[1])
+		}
+	})
+
+	t.Run("clusters", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("kube-contexts", []string{"ignored"})
+		viper.Set("clusters", []map[string]string{
+			{"name": "dev", "kubeconfig": "/tmp/dev"},
+			{"name": "prod", "context": "prod-admin"},
+		})
+
+		configs, err := clusterConfigsFromViper()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(configs) != 2 {
+			t.Fatalf("expected 2 configs, got %v", len(configs))
+		}
+
+		if configs[0].Kubeconfig != "/tmp/dev" {
+			t.Errorf("unexpected config %+v", configs[0])
+		}
+
+		if configs[1].Context != "prod-admin" {
+			t.Errorf("unexpected config %+v", configs[1])
+		}
+	})
+
+	t.Run("empty clusters", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("clusters", []map[string]string{})
+
+		_, err := clusterConfigsFromViper()
+		if err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+
+	viper.Reset()
+}
+
+func TestNamespaceScopedCluster(t *testing.T) {
+	viper.Reset()
+	viper.Set("discover-custom-resources", true)
+
+	cs := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+	)
+
+	// Anything cluster-wide should fail, since the source only has a Role in
+	// each namespace
+	var clusterWide []string
+
+	cs.PrependReactor("*", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		clusterWide = append(clusterWide, action.GetVerb()+" namespaces")
+
+		return true, nil, errors.New("forbidden")
+	})
+
+	cs.PrependReactor("*", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		clusterWide = append(clusterWide, action.GetVerb()+" nodes")
+
+		return true, nil, errors.New("forbidden")
+	})
+
+	cs.PrependReactor("create", "selfsubjectrulesreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, action.(k8stesting.CreateAction).GetObject(), nil
+	})
+
+	cluster := &Cluster{
+		Name:            "test",
+		ClientSet:       cs,
+		namespaces:      make(map[string]bool),
+		fixedNamespaces: []string{"team-a"},
+	}
+
+	adapterList, err := cluster.LoadAdapters(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, adapter := range adapterList {
+		if adapter.Type() == "Node" || adapter.Type() == "ClusterRole" {
+			t.Errorf("expected %v adapter to be skipped", adapter.Type())
+		}
+
+		// Pods can also be searched for in the cluster scope, which only
+		// covers the namespaces that are being queried
+		if adapter.Type() == "Pod" && !slices.Equal(adapter.Scopes(), []string{"test.team-a", "test"}) {
+			t.Errorf("expected only team-a to be queried, got %v", adapter.Scopes())
+		}
+	}
+
+	err = cluster.HealthCheck(context.Background())
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(clusterWide) > 0 {
+		t.Errorf("expected no cluster-wide calls, got %v", clusterWide)
+	}
+
+	if cluster.configString() != "snapshot:test\nfixed-namespaces:team-a" {
+		t.Errorf("unexpected config string %q", cluster.configString())
+	}
+
+	viper.Reset()
+}
+
+func TestClustersHealthCheck(t *testing.T) {
+	ctx := context.Background()
+
+	healthy := &Cluster{Name: "healthy", ClientSet: fake.NewClientset()}
+	failed := &Cluster{Name: "failed", ClientSet: fake.NewClientset()}
+	failed.setFailure(errors.New("namespace watch failed"))
+
+	if err := clustersHealthCheck(ctx, []*Cluster{healthy, failed}); err != nil {
+		t.Errorf("expected no error while a cluster is healthy, got %v", err)
+	}
+
+	if err := failed.HealthCheck(ctx); err == nil {
+		t.Error("expected the failed cluster to be unhealthy")
+	}
+
+	healthy.setFailure(errors.New("namespace watch failed"))
+
+	if err := clustersHealthCheck(ctx, []*Cluster{healthy, failed}); err == nil {
+		t.Error("expected an error when every cluster has failed")
+	}
+}
+
+func TestFailedCluster(t *testing.T) {
+	cluster := failedCluster(ClusterConfig{Context: "staging"}, errors.New("context not found"))
+
+	if cluster.Name != "staging" {
+		t.Errorf("expected the cluster to be named after its context, got %v", cluster.Name)
+	}
+
+	// Clusters that couldn't be connected to are still reported as unhealthy,
+	// rather than being missing from /healthz/clusters
+	err := cluster.HealthCheck(context.Background())
+
+	if err == nil || !strings.Contains(err.Error(), "context not found") {
+		t.Errorf("expected the connection error, got %v", err)
+	}
+
+	if cluster.configString() != "unconnected:staging" {
+		t.Errorf("unexpected config string %q", cluster.configString())
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/overmindtech/k8s-source/adapters"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NamespaceFilter Decides which namespaces are discovered. A namespace is
+// discovered if it matches one of the include globs (or there are none), none
+// of the exclude globs, and the label selector
+type NamespaceFilter struct {
+	Include []string
+	Exclude []string
+	// Nil if namespaces shouldn't be filtered by label
+	Selector labels.Selector
+}
+
+// Matches Returns whether the namespace should be discovered
+func (f NamespaceFilter) Matches(ns *corev1.Namespace) bool {
+	// The patterns are validated when the filter is created so errors can be
+	// ignored
+	for _, pattern := range f.Exclude {
+		if matched, _ := path.Match(pattern, ns.Name); matched {
+			return false
+		}
+	}
+
+	if f.Selector != nil && !f.Selector.Matches(labels.Set(ns.Labels)) {
+		return false
+	}
+
+	if len(f.Include) == 0 {
+		return true
+	}
+
+	for _, pattern := range f.Include {
+		if matched, _ := path.Match(pattern, ns.Name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String Describes the filter, this is blank if every namespace is discovered
+func (f NamespaceFilter) String() string {
+	var parts []string
+
+	if len(f.Include) > 0 {
+		parts = append(parts, "include="+strings.Join(f.Include, ","))
+	}
+
+	if len(f.Exclude) > 0 {
+		parts = append(parts, "exclude="+strings.Join(f.Exclude, ","))
+	}
+
+	if f.Selector != nil && !f.Selector.Empty() {
+		parts = append(parts, "selector="+f.Selector.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// filtersFromViper Reads the namespace and type filters from the config
+func filtersFromViper() (NamespaceFilter, adapters.TypeFilter, error) {
+	namespaceFilter := NamespaceFilter{
+		Include: viper.GetStringSlice("namespace-include"),
+		Exclude: viper.GetStringSlice("namespace-exclude"),
+	}
+
+	for _, pattern := range slices.Concat(namespaceFilter.Include, namespaceFilter.Exclude) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return NamespaceFilter{}, adapters.TypeFilter{}, fmt.Errorf("invalid namespace pattern %v: %w", pattern, err)
+		}
+	}
+
+	if selector := viper.GetString("namespace-selector"); selector != "" {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return NamespaceFilter{}, adapters.TypeFilter{}, fmt.Errorf("invalid namespace selector: %w", err)
+		}
+
+		namespaceFilter.Selector = parsed
+	}
+
+	typeFilter := adapters.TypeFilter{
+		Allow: viper.GetStringSlice("type-allow"),
+		Deny:  viper.GetStringSlice("type-deny"),
+	}
+
+	return namespaceFilter, typeFilter, nil
+}
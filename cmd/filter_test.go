@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNamespaceFilter(t *testing.T) {
+	viper.Reset()
+	viper.Set("namespace-include", []string{"team-*", "default"})
+	viper.Set("namespace-exclude", []string{"team-legacy-*"})
+	viper.Set("namespace-selector", "discover!=false")
+
+	filter, _, err := filtersFromViper()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name    string
+		Labels  map[string]string
+		Matches bool
+	}{
+		{Name: "default", Matches: true},
+		{Name: "team-payments", Matches: true},
+		{Name: "kube-system", Matches: false},
+		{Name: "team-legacy-billing", Matches: false},
+		{Name: "team-hidden", Labels: map[string]string{"discover": "false"}, Matches: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   test.Name,
+					Labels: test.Labels,
+				},
+			}
+
+			if matches := filter.Matches(ns); matches != test.Matches {
+				t.Errorf("expected %v, got %v", test.Matches, matches)
+			}
+		})
+	}
+
+	t.Run("empty filter matches everything", func(t *testing.T) {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}
+
+		if !(NamespaceFilter{}).Matches(ns) {
+			t.Error("expected empty filter to match")
+		}
+	})
+}
+
+func TestFiltersFromViper(t *testing.T) {
+	t.Run("types", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("type-deny", []string{"Secret"})
+
+		_, typeFilter, err := filtersFromViper()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if typeFilter.Allowed("Secret") || !typeFilter.Allowed("Pod") {
+			t.Errorf("unexpected type filter %+v", typeFilter)
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("namespace-exclude", []string{"team-["})
+
+		_, _, err := filtersFromViper()
+		if err == nil {
+			t.Error("expected an error for an invalid pattern")
+		}
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		viper.Reset()
+		viper.Set("namespace-selector", "!!")
+
+		_, _, err := filtersFromViper()
+		if err == nil {
+			t.Error("expected an error for an invalid selector")
+		}
+	})
+}
+
+func TestClusterNamespaceFilter(t *testing.T) {
+	viper.Reset()
+
+	cluster := &Cluster{
+		Name: "test",
+		ClientSet: fake.NewClientset(
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		),
+		namespaces: make(map[string]bool),
+		namespaceFilter: NamespaceFilter{
+			Exclude: []string{"kube-*"},
+		},
+	}
+
+	namespaces, err := cluster.listNamespaces(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(namespaces, []string{"default"}) {
+		t.Errorf("expected only default, got %v", namespaces)
+	}
+
+	t.Run("updateNamespace", func(t *testing.T) {
+		cluster.updateNamespace(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-public"}})
+		cluster.updateNamespace(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}})
+
+		if cluster.namespaces["kube-public"] || !cluster.namespaces["team-a"] {
+			t.Errorf("unexpected namespaces %v", cluster.namespaces)
+		}
+	})
+
+	t.Run("configString", func(t *testing.T) {
+		if cluster.configString() != "snapshot:test\nnamespaces:exclude=kube-*" {
+			t.Errorf("unexpected config string %q", cluster.configString())
+		}
+	})
+}
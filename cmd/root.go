@@ -3,14 +3,13 @@ package cmd
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"math/rand"
-	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -21,14 +20,11 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/uptrace/opentelemetry-go-extra/otellogrus"
-	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/flowcontrol"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -49,112 +45,76 @@ var rootCmd = &cobra.Command{
 }
 
 func run(_ *cobra.Command, _ []string) int {
-	kubeconfig := viper.GetString("kubeconfig")
 	// get engine config
 	engineConfig, err := discovery.EngineConfigFromViper("k8s", ServiceVersion)
 	if err != nil {
 		log.WithError(err).Fatal("Could not get engine config from viper")
 	}
 
-	log.WithFields(log.Fields{
-		"kubeconfig": kubeconfig,
-	}).Info("Got config")
-
-	var clientSet *kubernetes.Clientset
-	var restConfig *rest.Config
-
-	if kubeconfig == "" {
-		log.Info("Using in-cluster config")
-
-		restConfig, err = rest.InClusterConfig()
+	var clusters []*Cluster
 
+	if snapshotFile := viper.GetString("from-snapshot"); snapshotFile != "" {
+		clusters, err = SnapshotClusters(snapshotFile)
 		if err != nil {
 			sentry.CaptureException(err)
-			log.WithError(err).Error("Could not load in-cluster config")
+			log.WithError(err).Error("Could not load snapshot")
 
 			return 1
 		}
 	} else {
-		// Load kubernetes config from a file
-		restConfig, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-
+		clusters, err = connectClusters()
 		if err != nil {
 			sentry.CaptureException(err)
-			log.WithError(err).Error("Could not load kubernetes config")
+			log.WithError(err).Error("Could not get cluster config")
 
 			return 1
 		}
 	}
 
-	restConfig.Wrap(func(rt http.RoundTripper) http.RoundTripper { return otelhttp.NewTransport(rt) })
-	// Set up rate limiting
-	restConfig.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(
-		float32(viper.GetFloat64("rate-limit-qps")),
-		viper.GetInt("rate-limit-burst"),
-	)
-	// Create clientSet
-	clientSet, err = kubernetes.NewForConfig(restConfig)
-	if err != nil {
-		sentry.CaptureException(err)
-		log.WithError(err).Error("Could not create kubernetes client")
+	// The cluster name is used to generate scopes, so if two clusters had the
+	// same name their items would be indistinguishable
+	clusterNames := make(map[string]bool)
 
-		return 1
+	for _, cluster := range clusters {
+		if clusterNames[cluster.Name] {
+			err = fmt.Errorf("cluster name %v is used more than once, cluster names must be unique", cluster.Name)
+			sentry.CaptureException(err)
+			log.WithError(err).Error("Invalid cluster config")
+
+			return 1
+		}
+
+		clusterNames[cluster.Name] = true
 	}
-	//
-	// Discover info
-	//
-	// Now that we have a connection to the kubernetes cluster we need to go
-	// about generating some adapters.
-	var k8sURL *url.URL
-
-	k8sURL, err = url.Parse(restConfig.Host)
-	if err != nil {
-		sentry.CaptureException(err)
-		log.WithError(err).Errorf("Could not parse kubernetes url: %v", restConfig.Host)
+
+	if !slices.ContainsFunc(clusters, func(cluster *Cluster) bool { return cluster.Failure() == nil }) {
+		log.Error("Could not connect to any clusters")
 
 		return 1
 	}
 
 	// Calculate the SHA-1 hash of the config to use as the queue name. This
-	// means that adapters with the same config will be in the same queue.
-	// Note that the config object implements redaction in the String()
-	// method so we don't have to worry about leaking secrets
-	configHash := fmt.Sprintf("%x", sha256.Sum256([]byte(restConfig.String())))
-	engineConfig.NATSQueueName = fmt.Sprintf("k8s-source-%v", configHash)
+	// means that adapters with the same config will be in the same queue
+	restConfigs := make([]string, len(clusters))
 
-	// If there is no port then set one
-	if k8sURL.Port() == "" {
-		switch k8sURL.Scheme {
-		case "http":
-			k8sURL.Host = k8sURL.Host + ":80"
-		case "https":
-			k8sURL.Host = k8sURL.Host + ":443"
-		}
+	for i, cluster := range clusters {
+		restConfigs[i] = cluster.configString()
 	}
 
+	configHash := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(restConfigs, "\n"))))
+	engineConfig.NATSQueueName = fmt.Sprintf("k8s-source-%v", configHash)
+
 	err = engineConfig.CreateClients()
 	if err != nil {
 		sentry.CaptureException(err)
 		log.WithError(err).Fatal("could not create auth clients")
 	}
 
-	// Work out the cluster name
-	clusterName := viper.GetString("cluster-name")
-	if clusterName == "" {
-		clusterName = k8sURL.Host
-	}
-
 	engineConfig.HeartbeatOptions.HealthCheck = func() error {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		// Make sure we can list nodes in the cluster
-		_, err := clientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{
-			Limit: 1,
-		})
-		if err != nil {
-			return fmt.Errorf("health check (listing nodes) failed: %w", err)
-		}
-		return nil
+
+		return clustersHealthCheck(ctx, clusters)
 	}
 
 	e, err := discovery.NewEngine(engineConfig)
@@ -168,6 +128,7 @@ func run(_ *cobra.Command, _ []string) int {
 	// Start HTTP server for status
 	healthCheckPort := viper.GetInt("health-check-port")
 	healthCheckPath := "/healthz"
+	clusterHealthCheckPath := healthCheckPath + "/clusters"
 
 	http.HandleFunc(healthCheckPath, func(rw http.ResponseWriter, r *http.Request) {
 		if e.IsNATSConnected() {
@@ -185,18 +146,61 @@ func run(_ *cobra.Command, _ []string) int {
 	go func() {
 		defer sentry.Recover()
 
-		server := &http.Server{
-			Addr: fmt.Sprintf(":%v", healthCheckPort),
-			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Check NATS connections
-				if e.IsNATSConnected() {
-					// Return 200
-					w.WriteHeader(http.StatusOK)
+		mux := http.NewServeMux()
+
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			// Check NATS connections
+			if e.IsNATSConnected() {
+				// Return 200
+				w.WriteHeader(http.StatusOK)
+			} else {
+				// Return 500 including the error
+				http.Error(w, "NATS not connected", http.StatusInternalServerError)
+			}
+		})
+
+		// Per-cluster health is reported separately so that one broken
+		// cluster doesn't cause the whole source to be restarted
+		mux.HandleFunc(clusterHealthCheckPath, func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			defer cancel()
+
+			status := http.StatusOK
+			var body strings.Builder
+
+			for _, cluster := range clusters {
+				if err := cluster.HealthCheck(ctx); err != nil {
+					status = http.StatusInternalServerError
+					fmt.Fprintf(&body, "%v: %v\n", cluster.Name, err)
 				} else {
-					// Return 500 including the error
-					http.Error(w, "NATS not connected", http.StatusInternalServerError)
+					fmt.Fprintf(&body, "%v: ok\n", cluster.Name)
 				}
-			}),
+			}
+
+			w.WriteHeader(status)
+			fmt.Fprint(w, body.String())
+		})
+
+		// The result of the permissions check for each cluster, so that it's
+		// clear what isn't being discovered and why
+		mux.HandleFunc(healthCheckPath+"/permissions", func(w http.ResponseWriter, r *http.Request) {
+			report := make(map[string][]adapters.AdapterAccess)
+
+			for _, cluster := range clusters {
+				report[cluster.Name] = cluster.Access()
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+
+			err := json.NewEncoder(w).Encode(report)
+			if err != nil {
+				log.WithError(err).Error("Could not write permissions report")
+			}
+		})
+
+		server := &http.Server{
+			Addr:         fmt.Sprintf(":%v", healthCheckPort),
+			Handler:      mux,
 			ReadTimeout:  5 * time.Second,
 			WriteTimeout: 10 * time.Second,
 		}
@@ -211,121 +215,95 @@ func run(_ *cobra.Command, _ []string) int {
 	// Create channels for interrupts
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	restart := make(chan watch.Event, 1024)
-
-	// Get the initial starting point
-	list, err := clientSet.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
-
-	if err != nil {
-		sentry.CaptureException(err)
-		log.WithError(err).Error("could not list namespaces")
+	clusterEvents := make(chan clusterEvent, 1024)
 
-		return 1
-	}
-
-	// Watch namespaces from here
-	wi, err := clientSet.CoreV1().Namespaces().Watch(context.Background(), metav1.ListOptions{
-		ResourceVersion: list.ResourceVersion,
-	})
+	watchCtx, watchCancel := context.WithCancel(context.Background())
+	defer watchCancel()
 
-	if err != nil {
-		sentry.CaptureException(err)
-		log.WithError(err).Error("could not start watching namespaces")
+	// Watch namespaces in each cluster from here. Clusters that can't be
+	// watched are marked as failed and won't have adapters loaded
+	for _, cluster := range clusters {
+		if cluster.Failure() != nil {
+			// We couldn't connect to this cluster at all
+			continue
+		}
 
-		return 1
-	}
+		if cluster.snapshot != nil {
+			// Snapshots don't change, so there is nothing to watch
+			continue
+		}
 
-	watchCtx, watchCancel := context.WithCancel(context.Background())
-	defer watchCancel()
+		if cluster.NamespaceScoped() {
+			// The namespaces are fixed, and we may not have permission to
+			// watch them or CRDs
+			cluster.logger().Info("Namespaces are set in the config, cluster-wide types will not be discovered")
 
-	go func() {
-		attempts := 0
-		sleep := 1 * time.Second
+			if viper.GetBool("discover-custom-resources") {
+				cluster.logger().Warn("Custom resources, including the Gateway API, can't be discovered when namespaces are set in the config since CRDs are cluster-wide")
+			}
 
-		for {
-			select {
-			case event, ok := <-wi.ResultChan():
-				if !ok {
-					// If the channel is closed then we need to restart the
-					// watch
+			continue
+		}
 
-					log.Error("Namespace watch channel closed")
-					log.Info("Re-subscribing to namespace watch")
+		err = cluster.WatchNamespaces(watchCtx, clusterEvents)
 
-					wi, err = watchNamespaces(watchCtx, clientSet)
+		if err != nil {
+			err = fmt.Errorf("could not start watching namespaces: %w", err)
+			sentry.CaptureException(err)
+			cluster.logger().WithError(err).Error("Could not start watching namespaces")
+			cluster.setFailure(err)
 
-					// Check for transient network errors
-					if err != nil {
-						var netErr *net.OpError
-
-						if errors.As(err, &netErr) {
-							// Mark a failure
-							attempts++
-
-							// If we have had less than 3 failures then retry
-							if attempts < 4 {
-								// The watch interface will be nil if we
-								// couldn't connect, so create a fake watcher
-								// that is closed so that we end up in this loop
-								// again
-								wi = watch.NewFake()
-								wi.Stop()
-
-								jitter := time.Duration(rand.Int63n(int64(sleep))) // nolint:gosec // we don't need cryptographically secure randomness here
-								sleep = sleep + jitter/2
-
-								log.WithError(err).Errorf("Transient network error, retrying in %v seconds", sleep.String())
-								time.Sleep(sleep)
-								continue
-							}
-						}
+			continue
+		}
 
-						sentry.CaptureException(err)
-						log.WithError(err).Error("could not list namespaces")
+		if viper.GetBool("discover-custom-resources") {
+			// Without this we won't pick up CRDs that are installed or removed
+			// while running, but everything else will still work
+			err = cluster.WatchCustomResourceDefinitions(watchCtx, clusterEvents)
 
-						// Send a fatal event that will kill the main goroutine
-						restart <- watch.Event{
-							Type: watch.EventType("FATAL"),
-						}
+			if err != nil {
+				cluster.logger().WithError(err).Warn("Could not start watching CustomResourceDefinitions")
+			}
+		}
+	}
 
-						return
-					}
+	// healthyClusters Returns the clusters that haven't failed
+	healthyClusters := func() []*Cluster {
+		healthy := make([]*Cluster, 0, len(clusters))
 
-					// If it's worked, reset the failure counter
-					attempts = 0
-				} else {
-					// If a watch event is received then we need to restart the
-					// engine
-					restart <- event
-				}
-			case <-watchCtx.Done():
-				return
+		for _, cluster := range clusters {
+			if cluster.Failure() == nil {
+				healthy = append(healthy, cluster)
 			}
 		}
-	}()
+
+		return healthy
+	}
 
 	start := func() error {
-		// Query all namespaces
-		log.Info("Listing namespaces")
-		list, err := clientSet.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+		adapterList := make([]discovery.Adapter, 0)
 
-		if err != nil {
-			return err
-		}
+		for _, cluster := range healthyClusters() {
+			clusterAdapters, err := cluster.LoadAdapters(context.Background())
 
-		namespaces := make([]string, len(list.Items))
+			if err != nil {
+				err = fmt.Errorf("could not load adapters: %w", err)
+				sentry.CaptureException(err)
+				cluster.logger().WithError(err).Error("Could not load adapters")
+				cluster.setFailure(err)
 
-		for i := range list.Items {
-			namespaces[i] = list.Items[i].Name
-		}
+				continue
+			}
 
-		log.Infof("got %v namespaces", len(namespaces))
+			adapterList = append(adapterList, clusterAdapters...)
+		}
 
-		// Create the adapter list
-		adapterList := adapters.LoadAllAdapters(clientSet, clusterName, namespaces)
+		if len(adapterList) == 0 {
+			return errors.New("no adapters could be loaded from any cluster")
+		}
 
 		// Add adapters to the engine
-		err = e.AddAdapters(adapterList...)
+		err := e.AddAdapters(adapterList...)
 		if err != nil {
 			return err
 		}
@@ -343,12 +321,61 @@ func run(_ *cobra.Command, _ []string) int {
 			return err
 		}
 
+		// Stop any informers that are watching the API
+		for _, cluster := range clusters {
+			cluster.StopAdapters()
+		}
+
 		// Clear the adapters
 		e.ClearAdapters()
 
 		return nil
 	}
 
+	// syncCustomResources Updates the custom resource adapters for a cluster,
+	// adding any new ones to the running engine. If adapters have to be
+	// replaced the engine is restarted, and an error is only returned if that
+	// fails
+	syncCustomResources := func(cluster *Cluster) error {
+		created, restart, err := cluster.SyncCustomResources(context.Background())
+
+		if err != nil {
+			sentry.CaptureException(err)
+			cluster.logger().WithError(err).Error("Could not sync custom resources")
+
+			return nil
+		}
+
+		if restart {
+			// Adapters can't be removed from a running engine, so restart it
+			// to replace the old ones
+			cluster.logger().Info("Restarting engine to replace custom resource adapters")
+
+			err = stop()
+
+			if err == nil {
+				err = start()
+			}
+
+			return err
+		}
+
+		if len(created) == 0 {
+			return nil
+		}
+
+		cluster.logger().Infof("Adding %v custom resource adapters", len(created))
+
+		err = e.AddAdapters(created...)
+
+		if err != nil {
+			sentry.CaptureException(err)
+			cluster.logger().WithError(err).Error("Could not add custom resource adapters")
+		}
+
+		return nil
+	}
+
 	// Start the service initially
 	err = start()
 	if err != nil {
@@ -376,7 +403,35 @@ func run(_ *cobra.Command, _ []string) int {
 			// Stopping will be handled by deferred stop()
 
 			return 0
-		case event := <-restart:
+		case watchEvent := <-clusterEvents:
+			cluster := watchEvent.Cluster
+			event := watchEvent.Event
+
+			if watchEvent.Resource == customResourceDefinitionsResource {
+				switch event.Type { // nolint:exhaustive // we on purpose fall through to default
+				case "":
+					log.Debug("Discarding empty event")
+				case "FATAL":
+					// Losing the CRD watch only means that changes to CRDs
+					// won't be picked up, so the cluster is still usable
+					cluster.logger().WithError(watchEvent.Err).Error("Could not recover CustomResourceDefinition watch")
+				default:
+					// Any change to a CRD, including status changes such as
+					// becoming established, could change what is served
+					err = syncCustomResources(cluster)
+
+					if err != nil {
+						err = fmt.Errorf("Could not restart engine: %w", err)
+						sentry.CaptureException(err)
+						log.WithError(err).Error("Could not restart engine")
+
+						return 1
+					}
+				}
+
+				continue
+			}
+
 			switch event.Type { // nolint:exhaustive // we on purpose fall through to default
 			case "":
 				// Discard empty events. After a certain period kubernetes
@@ -385,35 +440,103 @@ func run(_ *cobra.Command, _ []string) int {
 				// represent anything and should be discarded
 				log.Debug("Discarding empty event")
 			case "FATAL":
-				// This is a custom event type that should signal the main
-				// goroutine to exit
-				log.Error("Fatal error in watch goroutine")
-				return 1
-			case "MODIFIED":
-				log.Debug("Namespace modified, ignoring")
-			default:
+				// This is a custom event type that signals that the watch for
+				// this cluster can't be recovered. The other clusters can carry
+				// on, but if there are none left there is nothing to do
+				cluster.logger().WithError(watchEvent.Err).Error("Fatal error in watch goroutine")
+				cluster.setFailure(fmt.Errorf("namespace watch failed: %w", watchEvent.Err))
+
+				if len(healthyClusters()) == 0 {
+					log.Error("All clusters have failed")
+					return 1
+				}
+
+				// Adapters can't be removed from a running engine, so restart
+				// it without the failed cluster. Otherwise its adapters would
+				// carry on serving whatever they have cached
 				err = stop()
 
+				if err == nil {
+					err = start()
+				}
+
 				if err != nil {
+					err = fmt.Errorf("Could not restart engine: %w", err)
 					sentry.CaptureException(err)
-					log.WithError(err).Error("Could not stop engine")
+					log.WithError(err).Error("Could not restart engine")
 
 					return 1
 				}
+			case "ADDED", "MODIFIED", "DELETED":
+				// Namespace churn is handled by updating the adapters in
+				// place rather than restarting the engine, this means that
+				// we don't lose cached data or drop queries
+				ns, ok := event.Object.(*corev1.Namespace)
 
-				err = start()
+				if !ok {
+					cluster.logger().Errorf("Unexpected object in namespace watch: %T", event.Object)
+					continue
+				}
+
+				if event.Type == "DELETED" {
+					cluster.removeNamespace(ns.Name)
+				} else {
+					// Modified namespaces are checked too since their
+					// labels could have changed whether they match the
+					// filter
+					cluster.updateNamespace(ns)
+				}
+			default:
+				// "RESYNC" and anything else (e.g. an error from the watch)
+				// means we can't trust our view of the namespaces in this
+				// cluster, so bring them back in line with the cluster. This
+				// is done in place so that other clusters aren't affected
+				err = cluster.resyncNamespaces(context.Background())
 
 				if err != nil {
 					sentry.CaptureException(err)
-					log.WithError(err).Error("Could not start engine")
-
-					return 1
+					cluster.logger().WithError(err).Error("Could not resync namespaces")
 				}
 			}
 		}
 	}
 }
 
+// connectClusters Connects to all of the clusters in the config. A cluster
+// that we can't connect to shouldn't stop us from discovering the others, so
+// it is returned as failed rather than an error. This means that it is still
+// reported by the health checks
+func connectClusters() ([]*Cluster, error) {
+	clusterConfigs, err := clusterConfigsFromViper()
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make([]*Cluster, 0, len(clusterConfigs))
+
+	for _, config := range clusterConfigs {
+		configLog := log.WithFields(log.Fields{
+			"kubeconfig":   config.Kubeconfig,
+			"context":      config.Context,
+			"cluster-name": config.Name,
+		})
+
+		configLog.Info("Got config")
+
+		cluster, err := ConnectCluster(config)
+		if err != nil {
+			sentry.CaptureException(err)
+			configLog.WithError(err).Error("Could not connect to cluster")
+
+			cluster = failedCluster(config, err)
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -424,7 +547,7 @@ func Execute() {
 }
 
 // Watches k8s namespaces from the current state, sending new events for each change
-func watchNamespaces(ctx context.Context, clientSet *kubernetes.Clientset) (watch.Interface, error) {
+func watchNamespaces(ctx context.Context, clientSet kubernetes.Interface) (watch.Interface, error) {
 	// Get the initial starting point
 	list, err := clientSet.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
 
@@ -464,6 +587,17 @@ func init() {
 	rootCmd.PersistentFlags().Float32("rate-limit-qps", 10.0, "The maximum sustained queries per second from this source to the kubernetes API")
 	rootCmd.PersistentFlags().Int("rate-limit-burst", 30, "The maximum burst of queries from this source to the kubernetes API")
 	rootCmd.PersistentFlags().String("cluster-name", "", "The descriptive name of the cluster this source is running on. If this is blank, the hostname will be used from the Kube config")
+	rootCmd.PersistentFlags().StringSlice("kube-contexts", []string{}, "A list of contexts from the kubeconfig to discover. Each context is treated as a separate cluster named after the context. For more control, provide a list of `clusters` in the config file, each with a `name`, `kubeconfig` and `context`")
+	rootCmd.PersistentFlags().Bool("discover-custom-resources", true, "Discover the custom resources that are installed in the cluster, and pick up CRDs as they are installed or removed. This includes the Gateway API. Requires permission to list and watch CustomResourceDefinitions, so has no effect when namespaces are set in the config")
+	rootCmd.PersistentFlags().String("from-snapshot", "", "Serve queries from a snapshot file created by the snapshot command, rather than from live clusters. The cluster config is ignored when this is set")
+	rootCmd.PersistentFlags().StringSlice("namespaces", []string{}, "Only discover these namespaces. When this is set the source doesn't need any cluster-wide permissions: namespaces aren't listed or watched, and cluster-scoped types such as Node and ClusterRole are skipped")
+	rootCmd.PersistentFlags().StringSlice("namespace-include", []string{}, "Only discover namespaces whose names match one of these globs, e.g. team-*. If this is empty all namespaces are discovered")
+	rootCmd.PersistentFlags().StringSlice("namespace-exclude", []string{}, "Don't discover namespaces whose names match any of these globs, e.g. kube-*. This takes precedence over namespace-include")
+	rootCmd.PersistentFlags().String("namespace-selector", "", "Only discover namespaces whose labels match this label selector, e.g. team=payments")
+	rootCmd.PersistentFlags().StringSlice("type-allow", []string{}, "Only discover these types, e.g. Pod,Deployment. If this is empty all types are discovered")
+	rootCmd.PersistentFlags().StringSlice("type-deny", []string{}, "Never discover these types, e.g. Secret. This takes precedence over type-allow")
+	rootCmd.PersistentFlags().Bool("check-permissions", true, "Check which types and namespaces the source has permission to query at startup, and only discover those. Without this, types that can't be queried fail with a 403 at query time")
+	rootCmd.PersistentFlags().Bool("use-informers", false, "Serve queries from a local store that is kept up to date by watching the kubernetes API, rather than polling the API and caching the results. Results are always fresh, at the cost of holding every resource in memory")
 
 	// tracing
 	rootCmd.PersistentFlags().String("honeycomb-api-key", "", "If specified, configures opentelemetry libraries to submit traces to honeycomb")
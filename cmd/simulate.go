@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/overmindtech/k8s-source/adapters"
+	"github.com/overmindtech/sdp-go"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// simulateCmd represents the simulate command
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Shows what applying a set of manifests would change",
+	Long: `Reads kubernetes manifests, such as the output of helm template or
+kustomize build, and compares them with the live cluster. The items that would
+be added, changed or removed are written to stdout as JSON along with their
+linked item queries, so that the blast radius of the change can be worked out
+before it is applied.
+
+This can be run against a snapshot using --from-snapshot
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		exitcode := simulate(cmd, args)
+		os.Exit(exitcode)
+	},
+}
+
+// simulatedChange The JSON output for a single change
+type simulatedChange struct {
+	Change            adapters.ChangeType    `json:"change"`
+	Type              string                 `json:"type"`
+	Scope             string                 `json:"scope"`
+	Name              string                 `json:"name"`
+	Before            map[string]interface{} `json:"before,omitempty"`
+	After             map[string]interface{} `json:"after,omitempty"`
+	LinkedItemQueries []simulatedQuery       `json:"linkedItemQueries"`
+}
+
+type simulatedQuery struct {
+	Type     string `json:"type"`
+	Method   string `json:"method"`
+	Query    string `json:"query"`
+	Scope    string `json:"scope"`
+	BlastIn  bool   `json:"blastIn"`
+	BlastOut bool   `json:"blastOut"`
+}
+
+// simulatedOutput The JSON output of the simulate command
+type simulatedOutput struct {
+	Changes []simulatedChange `json:"changes"`
+	// Objects that the source doesn't have an adapter for, formatted as
+	// `kind/name`
+	Unsupported []string `json:"unsupported"`
+}
+
+func simulate(cmd *cobra.Command, _ []string) int {
+	filenames, err := cmd.Flags().GetStringSlice("filename")
+	if err != nil {
+		log.WithError(err).Error("Could not get filename flag")
+
+		return 1
+	}
+
+	if len(filenames) == 0 {
+		log.Error("At least one manifest must be provided using --filename")
+
+		return 1
+	}
+
+	namespace, _ := cmd.Flags().GetString("namespace")
+	pruneSelector, _ := cmd.Flags().GetString("prune-selector")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	objects := make([]*unstructured.Unstructured, 0)
+
+	for _, filename := range filenames {
+		fileObjects, err := readManifestFile(filename)
+		if err != nil {
+			log.WithError(err).WithField("filename", filename).Error("Could not read manifests")
+
+			return 1
+		}
+
+		objects = append(objects, fileObjects...)
+	}
+
+	cluster, err := simulationCluster(clusterName)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.WithError(err).Error("Could not load cluster")
+
+		return 1
+	}
+
+	ctx := context.Background()
+
+	adapterList, err := cluster.LoadAdapters(ctx)
+	if err != nil {
+		sentry.CaptureException(err)
+		cluster.logger().WithError(err).Error("Could not load adapters")
+
+		return 1
+	}
+
+	defer cluster.StopAdapters()
+
+	diff, err := adapters.SimulateManifests(ctx, adapterList, objects, adapters.SimulateOptions{
+		Namespace:     namespace,
+		PruneSelector: pruneSelector,
+	})
+	if err != nil {
+		sentry.CaptureException(err)
+		cluster.logger().WithError(err).Error("Could not simulate manifests")
+
+		return 1
+	}
+
+	for _, obj := range diff.Unsupported {
+		cluster.logger().WithFields(log.Fields{
+			"kind": obj.GetKind(),
+			"name": obj.GetName(),
+		}).Warn("No adapter for object, its changes will not be included")
+	}
+
+	for _, err := range diff.Unpruned {
+		cluster.logger().WithError(err).Warn("Could not check for items to prune, their removal will not be included")
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(newSimulatedOutput(diff))
+	if err != nil {
+		log.WithError(err).Error("Could not write output")
+
+		return 1
+	}
+
+	return 0
+}
+
+// readManifestFile Reads the manifests from a file, or from stdin if the
+// filename is `-`
+func readManifestFile(filename string) ([]*unstructured.Unstructured, error) {
+	if filename == "-" {
+		return adapters.ReadManifests(os.Stdin)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return adapters.ReadManifests(f)
+}
+
+// simulationCluster Loads the cluster that the manifests would be applied to,
+// either from a snapshot or by connecting to it
+func simulationCluster(name string) (*Cluster, error) {
+	var clusters []*Cluster
+	var err error
+
+	if snapshotPath := viper.GetString("from-snapshot"); snapshotPath != "" {
+		clusters, err = SnapshotClusters(snapshotPath)
+	} else {
+		clusters, err = connectClusters()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		if len(clusters) != 1 {
+			return nil, fmt.Errorf("%v clusters are configured, use --cluster to choose one", len(clusters))
+		}
+
+		return clusters[0], nil
+	}
+
+	for _, cluster := range clusters {
+		if cluster.Name == name {
+			return cluster, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cluster %v not found", name)
+}
+
+func newSimulatedOutput(diff *adapters.ManifestDiff) simulatedOutput {
+	output := simulatedOutput{
+		Changes:     make([]simulatedChange, len(diff.Changes)),
+		Unsupported: make([]string, len(diff.Unsupported)),
+	}
+
+	for i, change := range diff.Changes {
+		item := change.After
+
+		if item == nil {
+			item = change.Before
+		}
+
+		output.Changes[i] = simulatedChange{
+			Change:            change.Type,
+			Type:              item.GetType(),
+			Scope:             item.GetScope(),
+			Name:              item.UniqueAttributeValue(),
+			Before:            simulatedAttributes(change.Before),
+			After:             simulatedAttributes(change.After),
+			LinkedItemQueries: make([]simulatedQuery, 0),
+		}
+
+		for _, q := range change.LinkedItemQueries() {
+			output.Changes[i].LinkedItemQueries = append(output.Changes[i].LinkedItemQueries, simulatedQuery{
+				Type:     q.GetQuery().GetType(),
+				Method:   q.GetQuery().GetMethod().String(),
+				Query:    q.GetQuery().GetQuery(),
+				Scope:    q.GetQuery().GetScope(),
+				BlastIn:  q.GetBlastPropagation().GetIn(),
+				BlastOut: q.GetBlastPropagation().GetOut(),
+			})
+		}
+	}
+
+	for i, obj := range diff.Unsupported {
+		output.Unsupported[i] = obj.GetKind() + "/" + obj.GetName()
+	}
+
+	return output
+}
+
+func simulatedAttributes(item *sdp.Item) map[string]interface{} {
+	if item == nil {
+		return nil
+	}
+
+	return item.GetAttributes().GetAttrStruct().AsMap()
+}
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+
+	simulateCmd.Flags().StringSliceP("filename", "f", []string{}, "The manifests to simulate applying. Use - to read from stdin")
+	simulateCmd.Flags().StringP("namespace", "n", "default", "The namespace for objects in the manifests that don't specify one")
+	simulateCmd.Flags().String("prune-selector", "", "If set, items that match this label selector but aren't in the manifests will be shown as removed, like kubectl apply --prune")
+	simulateCmd.Flags().String("cluster", "", "The name of the cluster to compare against. Only needed if more than one cluster is configured")
+}
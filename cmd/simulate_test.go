@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/overmindtech/k8s-source/adapters"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestSimulate(t *testing.T) {
+	viper.Reset()
+
+	ctx := context.Background()
+	recorded := &adapters.ClusterSnapshot{Name: "recorded"}
+
+	for _, obj := range []runtime.Object{
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"},
+			Data:       map[string]string{"colour": "blue"},
+		},
+	} {
+		u, err := adapters.SnapshotObject(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorded.AddObjects(u)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	err := (&adapters.Snapshot{
+		CreatedAt: time.Now(),
+		Clusters:  []*adapters.ClusterSnapshot{recorded},
+	}).WriteFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set("from-snapshot", path)
+
+	t.Run("unknown cluster", func(t *testing.T) {
+		_, err := simulationCluster("missing")
+
+		if err == nil {
+			t.Error("expected an error for a cluster that isn't in the snapshot")
+		}
+	})
+
+	cluster, err := simulationCluster("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adapterList, err := cluster.LoadAdapters(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := adapters.ReadManifests(strings.NewReader(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  colour: green
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := adapters.SimulateManifests(ctx, adapterList, objects, adapters.SimulateOptions{
+		Namespace: "default",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := newSimulatedOutput(diff)
+
+	if len(output.Changes) != 1 {
+		t.Fatalf("expected 1 change, got %v", len(output.Changes))
+	}
+
+	change := output.Changes[0]
+
+	if change.Change != adapters.ChangeChanged || change.Type != "ConfigMap" || change.Name != "settings" || change.Scope != "recorded.default" {
+		t.Errorf("unexpected change %+v", change)
+	}
+
+	if change.Before == nil || change.After == nil {
+		t.Error("expected both before and after to be set")
+	}
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/overmindtech/k8s-source/adapters"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Records the state of the clusters to a file",
+	Long: `Lists every resource in the clusters using the same adapters that serve
+queries, and writes the raw objects to a file. The file can then be served by
+running the source with --from-snapshot, without access to the clusters.
+
+Secrets are redacted in the same way as they are when serving queries
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		exitcode := snapshot(cmd, args)
+		os.Exit(exitcode)
+	},
+}
+
+func snapshot(cmd *cobra.Command, _ []string) int {
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		log.WithError(err).Error("Could not get output flag")
+
+		return 1
+	}
+
+	clusterConfigs, err := clusterConfigsFromViper()
+	if err != nil {
+		sentry.CaptureException(err)
+		log.WithError(err).Error("Could not get cluster config")
+
+		return 1
+	}
+
+	ctx := context.Background()
+	snapshot := &adapters.Snapshot{
+		CreatedAt: time.Now(),
+	}
+
+	// Unlike when serving queries, a cluster that can't be recorded is an
+	// error since the snapshot would be silently incomplete
+	for _, config := range clusterConfigs {
+		cluster, err := ConnectCluster(config)
+		if err != nil {
+			sentry.CaptureException(err)
+			log.WithError(err).WithField("context", config.Context).Error("Could not connect to cluster")
+
+			return 1
+		}
+
+		clusterSnapshot, err := cluster.Snapshot(ctx)
+		if err != nil {
+			sentry.CaptureException(err)
+			cluster.logger().WithError(err).Error("Could not snapshot cluster")
+
+			return 1
+		}
+
+		cluster.logger().Infof("Recorded %v objects", len(clusterSnapshot.Objects))
+
+		snapshot.Clusters = append(snapshot.Clusters, clusterSnapshot)
+	}
+
+	err = snapshot.WriteFile(output)
+	if err != nil {
+		sentry.CaptureException(err)
+		log.WithError(err).Error("Could not write snapshot")
+
+		return 1
+	}
+
+	log.WithField("output", output).Info("Wrote snapshot")
+
+	return 0
+}
+
+// Snapshot Records the raw objects from every adapter in the cluster, along
+// with the namespaces and CRDs that are needed to serve them again
+func (c *Cluster) Snapshot(ctx context.Context) (*adapters.ClusterSnapshot, error) {
+	adapterList, err := c.LoadAdapters(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not load adapters: %w", err)
+	}
+
+	defer c.StopAdapters()
+
+	snapshot := &adapters.ClusterSnapshot{
+		Name: c.Name,
+	}
+
+	namespaces, err := c.snapshotNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range namespaces.Items {
+		if !c.namespaceFilter.Matches(&namespaces.Items[i]) {
+			continue
+		}
+
+		obj, err := adapters.SnapshotObject(&namespaces.Items[i])
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot.AddObjects(obj)
+	}
+
+	if c.customResources != nil {
+		crds, err := c.DynamicClient.Resource(adapters.CustomResourceDefinitionGVR).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("could not list CustomResourceDefinitions: %w", err)
+		}
+
+		for i := range crds.Items {
+			snapshot.AddObjects(&crds.Items[i])
+		}
+	}
+
+	for _, adapter := range adapterList {
+		snapshotAdapter, ok := adapter.(adapters.SnapshotAdapter)
+
+		if !ok {
+			continue
+		}
+
+		for _, scope := range adapter.Scopes() {
+			objects, err := snapshotAdapter.SnapshotObjects(ctx, scope)
+			if err != nil {
+				// We might not have permission to list everything, but the
+				// rest of the snapshot is still useful
+				c.logger().WithError(err).WithFields(log.Fields{
+					"type":  adapter.Type(),
+					"scope": scope,
+				}).Warn("Could not snapshot objects")
+
+				continue
+			}
+
+			snapshot.AddObjects(objects...)
+		}
+	}
+
+	return snapshot, nil
+}
+
+// snapshotNamespaces Gets the namespaces to record. In namespace-scoped mode
+// we may not be able to read the namespaces, so placeholders are recorded
+// instead so that the same namespaces are served from the snapshot
+func (c *Cluster) snapshotNamespaces(ctx context.Context) (*corev1.NamespaceList, error) {
+	if c.NamespaceScoped() {
+		list := &corev1.NamespaceList{}
+
+		for _, namespace := range c.fixedNamespaces {
+			list.Items = append(list.Items, corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: namespace,
+				},
+			})
+		}
+
+		return list, nil
+	}
+
+	list, err := c.ClientSet.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("could not list namespaces: %w", err)
+	}
+
+	return list, nil
+}
+
+// SnapshotClusters Creates clusters that serve the objects in a snapshot file
+// rather than querying a live API server
+func SnapshotClusters(path string) ([]*Cluster, error) {
+	snapshot, err := adapters.ReadSnapshotFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshot.Clusters) == 0 {
+		return nil, errors.New("snapshot does not contain any clusters")
+	}
+
+	namespaceFilter, typeFilter, err := filtersFromViper()
+	if err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"path":      path,
+		"createdAt": snapshot.CreatedAt,
+	}).Info("Loaded snapshot")
+
+	clusters := make([]*Cluster, len(snapshot.Clusters))
+
+	for i, clusterSnapshot := range snapshot.Clusters {
+		clusters[i] = &Cluster{
+			Name:            clusterSnapshot.Name,
+			snapshot:        clusterSnapshot,
+			namespaces:      make(map[string]bool),
+			namespaceFilter: namespaceFilter,
+			typeFilter:      typeFilter,
+		}
+	}
+
+	return clusters, nil
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+
+	snapshotCmd.Flags().StringP("output", "o", "snapshot.json", "The file to write the snapshot to")
+}
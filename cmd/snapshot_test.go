@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/overmindtech/k8s-source/adapters"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestSnapshotClusters(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	// There is nothing for informers to watch in a snapshot, so queries
+	// should be served from the snapshot even when they are enabled
+	viper.Set("use-informers", true)
+
+	ctx := context.Background()
+	cluster := &adapters.ClusterSnapshot{Name: "recorded"}
+
+	for _, obj := range []runtime.Object{
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "kube-system"}},
+	} {
+		u, err := adapters.SnapshotObject(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cluster.AddObjects(u)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+
+	err := (&adapters.Snapshot{
+		CreatedAt: time.Now(),
+		Clusters:  []*adapters.ClusterSnapshot{cluster},
+	}).WriteFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusters, err := SnapshotClusters(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(clusters) != 1 || clusters[0].Name != "recorded" {
+		t.Fatalf("expected the recorded cluster, got %v", clusters)
+	}
+
+	if err := clusters[0].HealthCheck(ctx); err != nil {
+		t.Error(err)
+	}
+
+	adapterList, err := clusters[0].LoadAdapters(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, adapter := range adapterList {
+		if adapter.Type() != "ConfigMap" {
+			continue
+		}
+
+		item, err := adapter.Get(ctx, "recorded.kube-system", "settings", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if item.UniqueAttributeValue() != "settings" {
+			t.Errorf("expected settings, got %v", item.UniqueAttributeValue())
+		}
+
+		return
+	}
+
+	t.Error("ConfigMap adapter not found")
+}